@@ -1,53 +1,73 @@
 package api
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"net/http"
+	"simplebank/apierror"
 	db "simplebank/db/sqlc"
 	"simplebank/token"
+	"simplebank/util"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/lib/pq"
 )
 
 var (
-	ErrNotPower = errors.New("don't have power to get this account")
+	ErrNotPower      = apierror.New(http.StatusUnauthorized, apierror.CodePermissionDenied, "don't have power to get this account")
+	ErrNicknameTaken = apierror.New(http.StatusConflict, apierror.CodeNicknameTaken, "nickname is already used by another account of this owner")
 )
 
 type createAccountRequest struct {
 	Owner    string `json:"owner" binding:"required"`
 	Currency string `json:"currency" binding:"required,currency"`
+	// 可选，默认为checking
+	Type string `json:"type" binding:"omitempty,account_type"`
+	// 可选，同一用户的账户昵称不能重复
+	Nickname string `json:"nickname" binding:"max=64"`
 }
 
 func (server *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, apierror.FromBinding(err))
 		return
 	}
 
+	// 银行内部用户的账户只能由迁移创建
+	if db.IsBankOwner(req.Owner) {
+		respondError(ctx, apierror.New(http.StatusForbidden, apierror.CodePermissionDenied, "cannot create accounts for bank internal users"))
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = util.Checking
+	}
+
 	arg := db.CreateAccountParams{
 		Owner:    req.Owner,
 		Currency: req.Currency,
 		Balance:  0,
+		Type:     req.Type,
+		Nickname: sql.NullString{String: req.Nickname, Valid: req.Nickname != ""},
 	}
 
 	account, err := server.store.CreateAccount(ctx, arg)
 	if err != nil {
-		pqError, ok := err.(*pq.Error)
-		if ok {
-			switch pqError.Code.Name() {
-			case "foreign_key_violation", "unique_violation":
-				ctx.JSON(http.StatusForbidden, errorResponse(err))
-				return
-			}
+		switch db.ErrorCode(err) {
+		case db.ForeignKeyViolation:
+			respondError(ctx, apierror.New(http.StatusForbidden, apierror.CodeUserNotFound, "account owner does not exist").WithCause(err))
+			return
+		case db.UniqueViolation:
+			respondError(ctx, ErrNicknameTaken.WithCause(err))
+			return
 		}
 
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
+	db.MarkWritten(ctx, account.Owner)
 	ctx.JSON(http.StatusOK, account)
 }
 
@@ -60,27 +80,57 @@ func (server *Server) getAccount(ctx *gin.Context) {
 	err := ctx.ShouldBindUri(&req)
 
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, apierror.FromBinding(err))
 		return
 	}
 
-	account, err := server.store.GetAccount(ctx, req.ID)
+	account, flag := server.getAuthorizedAccount(ctx, req.ID, db.PermissionView)
+	if !flag {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, account)
+}
+
+type updateAccountRequest struct {
+	// 为空时清除昵称
+	Nickname string `json:"nickname" binding:"max=64"`
+}
+
+// 只有账户所有者可以修改昵称
+func (server *Server) updateAccount(ctx *gin.Context) {
+	var uri getAccountRequest
+	err := ctx.ShouldBindUri(&uri)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
-			return
-		}
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
 
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+	var req updateAccountRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
 		return
 	}
 
-	//不可以获取不是自己的账户信息
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if authPayload.Username != account.Owner {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(ErrNotPower))
+	account, flag := server.getAuthorizedAccount(ctx, uri.ID, db.PermissionManage)
+	if !flag {
+		return
+	}
+
+	account, err = server.store.UpdateAccountNickname(ctx, db.UpdateAccountNicknameParams{
+		ID:       account.ID,
+		Nickname: sql.NullString{String: req.Nickname, Valid: req.Nickname != ""},
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			respondError(ctx, ErrNicknameTaken.WithCause(err))
+			return
+		}
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
+	db.MarkWritten(ctx, account.Owner)
 
 	ctx.JSON(http.StatusOK, account)
 }
@@ -94,7 +144,7 @@ func (server *Server) listAccount(ctx *gin.Context) {
 	var req listAccountRequest
 	err := ctx.ShouldBindQuery(&req)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, apierror.FromBinding(err))
 		return
 	}
 
@@ -108,10 +158,130 @@ func (server *Server) listAccount(ctx *gin.Context) {
 
 	accouts, err := server.store.ListAccounts(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, accouts)
 
 }
+
+// 获取的账户用于权限校验，读主库，避免从库延迟时沿用已失效的状态
+func (server *Server) fetchAccount(ctx *gin.Context, accountID int64) (db.Account, bool) {
+	account, err := server.store.GetAccount(db.WithPrimary(ctx), accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(ctx, errAccountNotFound)
+		} else {
+			respondError(ctx, apierror.Internal(err))
+		}
+		return account, false
+	}
+
+	return account, true
+}
+
+type accountBalanceResponse struct {
+	AccountID        int64  `json:"account_id"`
+	Currency         string `json:"currency"`
+	Balance          int64  `json:"balance"`
+	HeldAmount       int64  `json:"held_amount"`
+	AvailableBalance int64  `json:"available_balance"`
+}
+
+type getAccountBalanceQuery struct {
+	// 可选，RFC3339格式，查询该时刻的历史余额
+	At *time.Time `form:"at" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type historicBalanceResponse struct {
+	AccountID int64     `json:"account_id"`
+	Currency  string    `json:"currency"`
+	At        time.Time `json:"at"`
+	Balance   int64     `json:"balance"`
+}
+
+// 可用余额 = 余额 - 有效预授权；传入at时返回该时刻的历史余额
+func (server *Server) getAccountBalance(ctx *gin.Context) {
+	var req getAccountRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	var query getAccountBalanceQuery
+	err = ctx.ShouldBindQuery(&query)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	account, flag := server.getAuthorizedAccount(ctx, req.ID, db.PermissionView)
+	if !flag {
+		return
+	}
+
+	if query.At != nil {
+		balance, err := server.store.GetBalanceAt(ctx, account.ID, *query.At)
+		if err != nil {
+			respondError(ctx, apierror.Internal(err))
+			return
+		}
+
+		ctx.JSON(http.StatusOK, historicBalanceResponse{
+			AccountID: account.ID,
+			Currency:  account.Currency,
+			At:        *query.At,
+			Balance:   balance,
+		})
+		return
+	}
+
+	held, err := server.store.GetActiveHoldsAmount(ctx, account.ID)
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, accountBalanceResponse{
+		AccountID:        account.ID,
+		Currency:         account.Currency,
+		Balance:          account.Balance,
+		HeldAmount:       held,
+		AvailableBalance: account.Balance - held,
+	})
+}
+
+// 获取账户并校验当前用户对账户是否有指定权限
+func (server *Server) getAuthorizedAccount(ctx *gin.Context, accountID int64, permission string) (db.Account, bool) {
+	account, flag := server.fetchAccount(ctx, accountID)
+	if !flag {
+		return account, false
+	}
+
+	return account, server.authorizeAccount(ctx, account, permission, ErrNotPower)
+}
+
+// 校验当前用户对账户的权限，没有权限时以deniedErr响应
+func (server *Server) authorizeAccount(ctx *gin.Context, account db.Account, permission string, deniedErr *apierror.Error) bool {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+	ok, err := server.canAccess(ctx, authPayload.Username, account, permission)
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return false
+	}
+
+	if !ok {
+		respondError(ctx, deniedErr)
+		return false
+	}
+
+	return true
+}
+
+// 所有账户权限校验的唯一入口，与gRPC接口共用db.CanAccess
+func (server *Server) canAccess(ctx context.Context, username string, account db.Account, permission string) (bool, error) {
+	return db.CanAccess(ctx, server.store, username, account, permission)
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"simplebank/apierror"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	ErrInviteOwner  = apierror.New(http.StatusBadRequest, apierror.CodeInvalidArgument, "the owner is already a holder of this account")
+	ErrNoInvitation = apierror.New(http.StatusNotFound, apierror.CodeInvitationNotFound, "no pending invitation for this account")
+)
+
+type inviteHolderRequest struct {
+	Username   string `json:"username" binding:"required"`
+	Permission string `json:"permission" binding:"required,oneof=view transfer"`
+}
+
+// 账户所有者邀请其他用户成为联名持有人，被邀请人接受后才生效
+func (server *Server) inviteAccountHolder(ctx *gin.Context) {
+	var uri getAccountRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	var req inviteHolderRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	account, flag := server.getAuthorizedAccount(ctx, uri.ID, db.PermissionManage)
+	if !flag {
+		return
+	}
+
+	if req.Username == account.Owner {
+		respondError(ctx, ErrInviteOwner)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	holder, err := server.store.CreateAccountHolder(ctx, db.CreateAccountHolderParams{
+		AccountID:  account.ID,
+		Username:   req.Username,
+		Permission: req.Permission,
+		InvitedBy:  authPayload.Username,
+	})
+	if err != nil {
+		switch db.ErrorCode(err) {
+		case db.ForeignKeyViolation:
+			respondError(ctx, errUserNotFound.WithCause(err))
+			return
+		case db.UniqueViolation:
+			respondError(ctx, errHolderExists.WithCause(err))
+			return
+		}
+
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+	db.MarkWritten(ctx, account.Owner, holder.Username)
+
+	ctx.JSON(http.StatusOK, holder)
+}
+
+// 被邀请人接受邀请
+func (server *Server) acceptAccountHolder(ctx *gin.Context) {
+	var uri getAccountRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	holder, err := server.store.AcceptAccountHolder(ctx, db.AcceptAccountHolderParams{
+		AccountID: uri.ID,
+		Username:  authPayload.Username,
+	})
+	if err != nil {
+		// 没有更新到记录说明没有待接受的邀请
+		if err == sql.ErrNoRows {
+			respondError(ctx, ErrNoInvitation)
+			return
+		}
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+	db.MarkWritten(ctx, holder.InvitedBy)
+
+	ctx.JSON(http.StatusOK, holder)
+}
+
+// 能查看账户的用户都可以查看联名持有人列表
+func (server *Server) listAccountHolders(ctx *gin.Context) {
+	var uri getAccountRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	account, flag := server.getAuthorizedAccount(ctx, uri.ID, db.PermissionView)
+	if !flag {
+		return
+	}
+
+	holders, err := server.store.ListAccountHolders(ctx, account.ID)
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, holders)
+}
@@ -0,0 +1,308 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanAccess(t *testing.T) {
+	account := randomAccount()
+
+	testCases := []struct {
+		name       string
+		username   string
+		permission string
+		holder     *db.AccountHolder
+		want       bool
+	}{
+		{name: "OwnerManage", username: account.Owner, permission: db.PermissionManage, want: true},
+		{name: "HolderCannotManage", username: "joint", permission: db.PermissionManage, want: false},
+		{name: "NotHolder", username: "joint", permission: db.PermissionView, want: false},
+		{
+			name:       "InvitedOnly",
+			username:   "joint",
+			permission: db.PermissionView,
+			holder:     &db.AccountHolder{Permission: db.PermissionTransfer, Status: db.HolderStatusInvited},
+			want:       false,
+		},
+		{
+			name:       "ViewHolderView",
+			username:   "joint",
+			permission: db.PermissionView,
+			holder:     &db.AccountHolder{Permission: db.PermissionView, Status: db.HolderStatusAccepted},
+			want:       true,
+		},
+		{
+			name:       "ViewHolderTransfer",
+			username:   "joint",
+			permission: db.PermissionTransfer,
+			holder:     &db.AccountHolder{Permission: db.PermissionView, Status: db.HolderStatusAccepted},
+			want:       false,
+		},
+		{
+			name:       "TransferHolderView",
+			username:   "joint",
+			permission: db.PermissionView,
+			holder:     &db.AccountHolder{Permission: db.PermissionTransfer, Status: db.HolderStatusAccepted},
+			want:       true,
+		},
+		{
+			name:       "TransferHolderTransfer",
+			username:   "joint",
+			permission: db.PermissionTransfer,
+			holder:     &db.AccountHolder{Permission: db.PermissionTransfer, Status: db.HolderStatusAccepted},
+			want:       true,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			call := store.EXPECT().
+				GetAccountHolder(gomock.Any(), gomock.Eq(db.GetAccountHolderParams{AccountID: account.ID, Username: tc.username})).
+				AnyTimes()
+			if tc.holder != nil {
+				call.Return(*tc.holder, nil)
+			} else {
+				call.Return(db.AccountHolder{}, sql.ErrNoRows)
+			}
+
+			server := newTestServer(t, store)
+			ok, err := server.canAccess(context.Background(), tc.username, account, tc.permission)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, ok)
+		})
+	}
+}
+
+func TestJointHolderTransferAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	joint, _ := randomUser(t)
+
+	account1 := randomAccount()
+	account1.Owner = owner.Username
+	account2 := randomAccount()
+	account2.Currency = account1.Currency
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+	store.EXPECT().
+		GetAccountHolder(gomock.Any(), gomock.Eq(db.GetAccountHolderParams{AccountID: account1.ID, Username: joint.Username})).
+		Times(1).
+		Return(db.AccountHolder{AccountID: account1.ID, Username: joint.Username, Permission: db.PermissionTransfer, Status: db.HolderStatusAccepted}, nil)
+	store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, nil)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"from_account_id": account1.ID,
+		"to_account_id":   account2.ID,
+		"amount":          10,
+		"currency":        account1.Currency,
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/transfer", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	addAuthorizationHeader(t, request, server.tokenMaker, authorizationTypeBearer, joint.Username, token.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestInviteAccountHolderAPI(t *testing.T) {
+	owner, _ := randomUser(t)
+	invitee, _ := randomUser(t)
+
+	account := randomAccount()
+	account.Owner = owner.Username
+
+	testCases := []struct {
+		name          string
+		username      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: owner.Username,
+			body:     gin.H{"username": invitee.Username, "permission": db.PermissionView},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreateAccountHolder(gomock.Any(), gomock.Eq(db.CreateAccountHolderParams{
+						AccountID:  account.ID,
+						Username:   invitee.Username,
+						Permission: db.PermissionView,
+						InvitedBy:  owner.Username,
+					})).
+					Times(1).
+					Return(db.AccountHolder{AccountID: account.ID, Username: invitee.Username, Status: db.HolderStatusInvited}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: invitee.Username,
+			body:     gin.H{"username": invitee.Username, "permission": db.PermissionTransfer},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreateAccountHolder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:     "InviteOwner",
+			username: owner.Username,
+			body:     gin.H{"username": owner.Username, "permission": db.PermissionView},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().CreateAccountHolder(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidPermission",
+			username: owner.Username,
+			body:     gin.H{"username": invitee.Username, "permission": db.PermissionManage},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "UserNotFound",
+			username: owner.Username,
+			body:     gin.H{"username": "nobody", "permission": db.PermissionView},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					CreateAccountHolder(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountHolder{}, &pgconn.PgError{Code: "23503"})
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/holders", account.ID)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			addAuthorizationHeader(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, token.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
+
+func TestAcceptAccountHolderAPI(t *testing.T) {
+	invitee, _ := randomUser(t)
+	accountID := int64(7)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcceptAccountHolder(gomock.Any(), gomock.Eq(db.AcceptAccountHolderParams{AccountID: accountID, Username: invitee.Username})).
+					Times(1).
+					Return(db.AccountHolder{AccountID: accountID, Username: invitee.Username, Status: db.HolderStatusAccepted}, nil)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "NoInvitation",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					AcceptAccountHolder(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.AccountHolder{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/holders/accept", accountID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationHeader(t, request, server.tokenMaker, authorizationTypeBearer, invitee.Username, token.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"simplebank/apierror"
+	db "simplebank/db/sqlc"
+	"simplebank/reconcile"
+
+	"github.com/gin-gonic/gin"
+)
+
+type cashRequest struct {
+	Amount   int64  `json:"amount" binding:"required,gt=0"`
+	Currency string `json:"currency" binding:"required,currency"`
+}
+
+// 银行柜员为账户存款
+func (server *Server) deposit(ctx *gin.Context) {
+	server.handleCash(ctx, server.store.DepositTx)
+}
+
+// 银行柜员为账户取款
+func (server *Server) withdraw(ctx *gin.Context) {
+	server.handleCash(ctx, server.store.WithdrawTx)
+}
+
+func (server *Server) handleCash(
+	ctx *gin.Context,
+	cashTx func(ctx context.Context, arg db.CashTxParams) (db.TransferTxResult, error),
+) {
+	var uri getAccountRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	var req cashRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	_, flag := server.validAccount(ctx, uri.ID, req.Currency)
+	if !flag {
+		return
+	}
+
+	arg := db.CashTxParams{
+		AccountID: uri.ID,
+		Amount:    req.Amount,
+	}
+
+	result, err := cashTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respondError(ctx, errAccountNotFound)
+			return
+		}
+		respondError(ctx, apierror.FromStore(err))
+		return
+	}
+	// 客户的账户被柜员修改，客户之后的读请求也要走主库
+	db.MarkWritten(ctx, result.FromAccount.Owner, result.ToAccount.Owner)
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+type reconciliationResponse struct {
+	OK     bool             `json:"ok"`
+	Report reconcile.Report `json:"report"`
+}
+
+// 对账报告，存在差异时仍返回200，由调用方根据报告内容判断
+func (server *Server) getReconciliation(ctx *gin.Context) {
+	report, err := reconcile.NewReconciler(server.store, reconcile.DefaultBatchSize).Run(ctx)
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reconciliationResponse{
+		OK:     report.OK(),
+		Report: report,
+	})
+}
+
+// 校验账户账目的哈希链是否被篡改
+func (server *Server) verifyEntryChain(ctx *gin.Context) {
+	var uri getAccountRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	_, flag := server.fetchAccount(ctx, uri.ID)
+	if !flag {
+		return
+	}
+
+	report, err := reconcile.NewReconciler(server.store, reconcile.DefaultBatchSize).VerifyEntryChain(ctx, uri.ID)
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+	"simplebank/apierror"
+	"simplebank/logging"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errAccountNotFound    = apierror.New(http.StatusNotFound, apierror.CodeAccountNotFound, "account not found")
+	errUserNotFound       = apierror.New(http.StatusNotFound, apierror.CodeUserNotFound, "user not found")
+	errHoldNotFound       = apierror.New(http.StatusNotFound, apierror.CodeHoldNotFound, "hold not found")
+	errUsernameTaken      = apierror.New(http.StatusConflict, apierror.CodeUsernameTaken, "username or email is already registered")
+	errInvalidCredentials = apierror.New(http.StatusUnauthorized, apierror.CodeInvalidCredentials, "password is incorrect")
+	errHolderExists       = apierror.New(http.StatusConflict, apierror.CodeHolderExists, "user is already a holder of this account")
+)
+
+// 以统一格式响应错误并终止后续处理，500错误的原因只写日志
+func respondError(ctx *gin.Context, err *apierror.Error) {
+	response := *err
+	response.RequestID = ctx.GetString(requestIDKey)
+
+	if response.Status >= http.StatusInternalServerError {
+		logging.FromContext(ctx.Request.Context()).Error("request failed", "code", err.Code, "error", err)
+	}
+
+	ctx.AbortWithStatusJSON(response.Status, response)
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"simplebank/apierror"
+	mockdb "simplebank/db/mock"
+	"simplebank/token"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeAPIError(t *testing.T, recorder *httptest.ResponseRecorder) apierror.Error {
+	var apiErr apierror.Error
+	err := json.Unmarshal(recorder.Body.Bytes(), &apiErr)
+	require.NoError(t, err)
+	return apiErr
+}
+
+func TestErrorResponseValidation(t *testing.T) {
+	user, _ := randomUser(t)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	data, err := json.Marshal(gin.H{
+		"from_account_id": 1,
+		"to_account_id":   2,
+		"amount":          -5,
+		"currency":        "XYZ",
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/transfer", bytes.NewReader(data))
+	require.NoError(t, err)
+	request.Header.Set(requestIDHeaderKey, "client-request-1")
+
+	addAuthorizationHeader(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, token.DepositorRole, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	require.Equal(t, "client-request-1", recorder.Header().Get(requestIDHeaderKey))
+
+	apiErr := decodeAPIError(t, recorder)
+	require.Equal(t, apierror.CodeValidationFailed, apiErr.Code)
+	require.Equal(t, "client-request-1", apiErr.RequestID)
+	require.Len(t, apiErr.Details, 2)
+	require.Equal(t, "amount", apiErr.Details[0].Field)
+	require.Equal(t, "gt", apiErr.Details[0].Rule)
+	require.Equal(t, "currency", apiErr.Details[1].Field)
+	require.Equal(t, "currency", apiErr.Details[1].Rule)
+}
+
+func TestErrorResponseCodes(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount()
+	account.Owner = user.Username
+
+	testCases := []struct {
+		name       string
+		buildStubs func(store *mockdb.MockStore)
+		status     int
+		code       string
+		message    string
+	}{
+		{
+			name: "AccountNotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, sql.ErrNoRows)
+			},
+			status:  http.StatusNotFound,
+			code:    apierror.CodeAccountNotFound,
+			message: "account not found",
+		},
+		{
+			name: "Internal",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, sql.ErrConnDone)
+			},
+			status:  http.StatusInternalServerError,
+			code:    apierror.CodeInternal,
+			message: "internal server error",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/accounts/%d", account.ID), nil)
+			require.NoError(t, err)
+
+			addAuthorizationHeader(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, token.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, tc.status, recorder.Code)
+
+			apiErr := decodeAPIError(t, recorder)
+			require.Equal(t, tc.code, apiErr.Code)
+			require.Equal(t, tc.message, apiErr.Message)
+			require.NotEmpty(t, apiErr.RequestID)
+			require.Equal(t, recorder.Header().Get(requestIDHeaderKey), apiErr.RequestID)
+		})
+	}
+}
+
+func TestRequestIDMiddlewareRejectsUnsafeID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/accounts/1", nil)
+	require.NoError(t, err)
+	request.Header.Set(requestIDHeaderKey, "bad id\nwith newline")
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	apiErr := decodeAPIError(t, recorder)
+	require.Equal(t, apierror.CodeUnauthenticated, apiErr.Code)
+	require.NotEqual(t, "bad id\nwith newline", apiErr.RequestID)
+	require.Len(t, apiErr.RequestID, 36)
+}
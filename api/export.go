@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"simplebank/apierror"
+	db "simplebank/db/sqlc"
+	"simplebank/export"
+	"simplebank/statement"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type exportAccountQuery struct {
+	Format string `form:"format" binding:"required,oneof=ofx camt053"`
+	// YYYY-MM-DD，起止日期都包含在内
+	From string `form:"from" binding:"required"`
+	To   string `form:"to" binding:"required"`
+}
+
+// 导出账户明细，供财务软件导入
+func (server *Server) exportAccount(ctx *gin.Context) {
+	var req getAccountRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	var query exportAccountQuery
+	err = ctx.ShouldBindQuery(&query)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	start, end, err := export.ParseRange(query.From, query.To)
+	if err != nil {
+		respondError(ctx, apierror.InvalidField("from", "date_range", err.Error()))
+		return
+	}
+
+	account, flag := server.getAuthorizedAccount(ctx, req.ID, db.PermissionView)
+	if !flag {
+		return
+	}
+
+	openingBalance, err := server.store.GetBalanceAt(ctx, account.ID, start)
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	entries, err := server.store.ListStatementEntries(ctx, db.ListStatementEntriesParams{
+		AccountID: account.ID,
+		FromTime:  start,
+		ToTime:    end,
+	})
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	var buf bytes.Buffer
+	err = export.Write(&buf, query.Format, statement.Build(account, start, end, openingBalance, entries), time.Now())
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	contentType, ext := export.ContentType(query.Format)
+	filename := fmt.Sprintf("account-%d-%s-%s.%s", account.ID, query.From, query.To, ext)
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	ctx.Data(http.StatusOK, contentType, buf.Bytes())
+}
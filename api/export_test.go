@@ -0,0 +1,106 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportAccountAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	account := randomAccount()
+	account.Owner = user.Username
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	buildOKStubs := func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+		store.EXPECT().GetBalanceAt(gomock.Any(), gomock.Eq(account.ID), gomock.Eq(start)).Times(1).Return(int64(10), nil)
+		store.EXPECT().
+			ListStatementEntries(gomock.Any(), gomock.Eq(db.ListStatementEntriesParams{AccountID: account.ID, FromTime: start, ToTime: end})).
+			Times(1).
+			Return([]db.ListStatementEntriesRow{}, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "OFX",
+			query:      "format=ofx&from=2026-03-01&to=2026-03-31",
+			buildStubs: buildOKStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/x-ofx", recorder.Header().Get("Content-Type"))
+				require.True(t, strings.Contains(recorder.Body.String(), "<OFX>"))
+			},
+		},
+		{
+			name:       "CAMT053",
+			query:      "format=camt053&from=2026-03-01&to=2026-03-31",
+			buildStubs: buildOKStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/xml", recorder.Header().Get("Content-Type"))
+				require.True(t, strings.Contains(recorder.Body.String(), "camt.053.001.02"))
+			},
+		},
+		{
+			name:  "InvalidFormat",
+			query: "format=qif&from=2026-03-01&to=2026-03-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:  "InvalidRange",
+			query: "format=ofx&from=2026-03-31&to=2026-03-01",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/export?%s", account.ID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationHeader(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, token.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	db "simplebank/db/sqlc"
+	"simplebank/memstore"
+	"simplebank/token"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// 基于内存存储的端到端流程：注册、登录、开户、存款、转账、对账，不使用mock
+func TestTransferFlow(t *testing.T) {
+	server := newTestServer(t, memstore.New())
+
+	do := func(method, url, authToken string, body any) *httptest.ResponseRecorder {
+		data, err := json.Marshal(body)
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(method, url, bytes.NewReader(data))
+		require.NoError(t, err)
+		if authToken != "" {
+			request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+authToken)
+		}
+
+		recorder := httptest.NewRecorder()
+		server.router.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	signUp := func() (string, string) {
+		username := util.RandomOwner()
+		recorder := do(http.MethodPost, "/users", "", gin.H{
+			"username":        username,
+			"hashed_password": "secret123",
+			"full_name":       username,
+			"email":           util.RandomEmail(),
+		})
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+		recorder = do(http.MethodGet, "/users/login", "", gin.H{"username": username, "password": "secret123"})
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+		var login loginUserRespose
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &login))
+		return username, login.Token
+	}
+
+	openAccount := func(owner, authToken string) db.Account {
+		recorder := do(http.MethodPost, "/accounts", authToken, gin.H{"owner": owner, "currency": util.USD})
+		require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+		var account db.Account
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+		return account
+	}
+
+	alice, aliceToken := signUp()
+	bob, bobToken := signUp()
+	aliceAccount := openAccount(alice, aliceToken)
+	bobAccount := openAccount(bob, bobToken)
+
+	bankerToken, _, err := server.tokenMaker.CreateToken(util.RandomOwner(), token.BankerRole, time.Minute)
+	require.NoError(t, err)
+
+	recorder := do(http.MethodPost, fmt.Sprintf("/admin/accounts/%d/deposit", aliceAccount.ID), bankerToken, gin.H{
+		"amount":   100,
+		"currency": util.USD,
+	})
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	transfer := gin.H{
+		"from_account_id": aliceAccount.ID,
+		"to_account_id":   bobAccount.ID,
+		"amount":          30,
+		"currency":        util.USD,
+	}
+
+	// 只有账户所有者可以转出
+	recorder = do(http.MethodPost, "/transfer", bobToken, transfer)
+	require.Equal(t, http.StatusUnauthorized, recorder.Code, recorder.Body.String())
+
+	recorder = do(http.MethodPost, "/transfer", aliceToken, transfer)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	recorder = do(http.MethodGet, fmt.Sprintf("/accounts/%d", bobAccount.ID), bobToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var account db.Account
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &account))
+	require.Equal(t, int64(30), account.Balance)
+
+	recorder = do(http.MethodGet, "/admin/reconciliation", bankerToken, nil)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	var reconciliation reconciliationResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &reconciliation))
+	require.True(t, reconciliation.OK)
+	// 6个银行内部账户和2个用户账户
+	require.EqualValues(t, 8, reconciliation.Report.AccountsChecked)
+}
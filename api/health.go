@@ -0,0 +1,95 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"simplebank/db/migration"
+	db "simplebank/db/sqlc"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	healthzPath = "/healthz"
+	readyzPath  = "/readyz"
+
+	// 就绪检查访问数据库的超时时间，避免探针堆积
+	readinessTimeout = 2 * time.Second
+)
+
+// 最新版本取自编译进二进制的迁移文件，新增迁移时不需要手动修改
+var latestSchemaVersion = sync.OnceValues(migration.LatestVersion)
+
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+type readinessResponse struct {
+	Status string `json:"status"`
+	// 各项检查的结果，通过为ok，否则为错误信息
+	Checks map[string]string `json:"checks"`
+}
+
+// 存活检查，只要进程能处理请求就返回200，不依赖数据库
+func (server *Server) getHealthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// 就绪检查，数据库可用且迁移到最新版本才接收流量
+func (server *Server) getReadyz(ctx *gin.Context) {
+	checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	response := readinessResponse{
+		Status: "ready",
+		Checks: map[string]string{
+			"database":   "ok",
+			"migrations": "ok",
+		},
+	}
+
+	if err := server.store.Ping(checkCtx); err != nil {
+		response.Checks["database"] = err.Error()
+		response.Checks["migrations"] = "skipped"
+	} else if err := checkSchemaVersion(checkCtx, server.store); err != nil {
+		response.Checks["migrations"] = err.Error()
+	}
+
+	status := http.StatusOK
+	for _, result := range response.Checks {
+		if result != "ok" {
+			response.Status = "not_ready"
+			status = http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	ctx.JSON(status, response)
+}
+
+func checkSchemaVersion(ctx context.Context, store db.Store) error {
+	version, err := store.GetSchemaVersion(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errors.New("no migrations applied")
+		}
+		return err
+	}
+
+	if version.Dirty {
+		return fmt.Errorf("%w at version %d", db.ErrSchemaDirty, version.Version)
+	}
+	latest, err := latestSchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version.Version != int64(latest) {
+		return fmt.Errorf("schema version %d, want %d", version.Version, latest)
+	}
+	return nil
+}
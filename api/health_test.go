@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthz(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 存活检查不访问数据库
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().Ping(gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, healthzPath, nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+}
+
+func TestReadyz(t *testing.T) {
+	latest := currentSchemaVersion(t)
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, status int, response readinessResponse)
+	}{
+		{
+			name: "Ready",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).
+					Return(db.SchemaVersion{Version: latest}, nil)
+			},
+			checkResponse: func(t *testing.T, status int, response readinessResponse) {
+				require.Equal(t, http.StatusOK, status)
+				require.Equal(t, "ready", response.Status)
+				require.Equal(t, "ok", response.Checks["database"])
+				require.Equal(t, "ok", response.Checks["migrations"])
+			},
+		},
+		{
+			name: "DatabaseDown",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(errors.New("connection refused"))
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, status int, response readinessResponse) {
+				require.Equal(t, http.StatusServiceUnavailable, status)
+				require.Equal(t, "not_ready", response.Status)
+				require.Equal(t, "connection refused", response.Checks["database"])
+				require.Equal(t, "skipped", response.Checks["migrations"])
+			},
+		},
+		{
+			name: "NoMigrations",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(db.SchemaVersion{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, status int, response readinessResponse) {
+				require.Equal(t, http.StatusServiceUnavailable, status)
+				require.Equal(t, "no migrations applied", response.Checks["migrations"])
+			},
+		},
+		{
+			name: "OutdatedSchema",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).
+					Return(db.SchemaVersion{Version: latest - 1}, nil)
+			},
+			checkResponse: func(t *testing.T, status int, response readinessResponse) {
+				require.Equal(t, http.StatusServiceUnavailable, status)
+				require.Contains(t, response.Checks["migrations"], "schema version")
+			},
+		},
+		{
+			name: "DirtySchema",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).
+					Return(db.SchemaVersion{Version: latest, Dirty: true}, nil)
+			},
+			checkResponse: func(t *testing.T, status int, response readinessResponse) {
+				require.Equal(t, http.StatusServiceUnavailable, status)
+				require.Contains(t, response.Checks["migrations"], db.ErrSchemaDirty.Error())
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			request, err := http.NewRequest(http.MethodGet, readyzPath, nil)
+			require.NoError(t, err)
+
+			server.router.ServeHTTP(recorder, request)
+
+			var response readinessResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &response))
+			tc.checkResponse(t, recorder.Code, response)
+		})
+	}
+}
+
+// Shutdown要等处理中的请求完成后才返回，并且不再接收新请求
+func TestServerShutdown(t *testing.T) {
+	latest := currentSchemaVersion(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().Ping(gomock.Any()).Times(1).DoAndReturn(func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	store.EXPECT().GetSchemaVersion(gomock.Any()).Times(1).Return(db.SchemaVersion{Version: latest}, nil)
+
+	server := newTestServer(t, store)
+	address := freeAddress(t)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Start(address)
+	}()
+
+	url := "http://" + address + readyzPath
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", address)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	responseStatus := make(chan int, 1)
+	go func() {
+		response, err := http.Get(url)
+		if err != nil {
+			responseStatus <- 0
+			return
+		}
+		response.Body.Close()
+		responseStatus <- response.StatusCode
+	}()
+	<-started
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		shutdownErr <- server.Shutdown(context.Background())
+	}()
+
+	// 请求未完成时Shutdown不能返回
+	select {
+	case <-shutdownErr:
+		t.Fatal("shutdown returned before in-flight request finished")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_, err := http.Get(url)
+	require.Error(t, err)
+
+	close(release)
+	require.Equal(t, http.StatusOK, <-responseStatus)
+	require.NoError(t, <-shutdownErr)
+	require.NoError(t, <-serveErr)
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	latest := currentSchemaVersion(t)
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().Ping(gomock.Any()).Times(1).DoAndReturn(func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	store.EXPECT().GetSchemaVersion(gomock.Any()).AnyTimes().Return(db.SchemaVersion{Version: latest}, nil)
+
+	server := newTestServer(t, store)
+	address := freeAddress(t)
+	go server.Start(address)
+
+	require.Eventually(t, func() bool {
+		conn, err := net.Dial("tcp", address)
+		if err != nil {
+			return false
+		}
+		conn.Close()
+		return true
+	}, time.Second, 10*time.Millisecond)
+
+	go http.Get("http://" + address + readyzPath)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := server.Shutdown(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// 内嵌迁移文件中的最新版本，就绪检查要求数据库迁移到该版本
+func currentSchemaVersion(t *testing.T) int64 {
+	latest, err := latestSchemaVersion()
+	require.NoError(t, err)
+	return int64(latest)
+}
+
+func freeAddress(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().String()
+}
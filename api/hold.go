@@ -0,0 +1,186 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+	"simplebank/apierror"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errHoldNotOwner = apierror.New(http.StatusUnauthorized, apierror.CodePermissionDenied, "you can not place a hold on other's account")
+	errHoldNotPayee = apierror.New(http.StatusUnauthorized, apierror.CodePermissionDenied, "only the payee can capture this hold")
+	errHoldNotParty = apierror.New(http.StatusUnauthorized, apierror.CodePermissionDenied, "you can not release other's hold")
+)
+
+type placeHoldRequest struct {
+	AccountID   int64  `json:"account_id" binding:"required,min=1"`
+	ToAccountID int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	Currency    string `json:"currency" binding:"required,currency"`
+	// 预授权有效期（秒），最短1分钟，最长30天
+	ExpiresIn int64 `json:"expires_in" binding:"required,min=60,max=2592000"`
+}
+
+func (server *Server) placeHold(ctx *gin.Context) {
+	var req placeHoldRequest
+	err := ctx.ShouldBindJSON(&req)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	account, flag := server.validAccount(ctx, req.AccountID, req.Currency)
+	if !flag {
+		return
+	}
+
+	toAccount, flag := server.validAccount(ctx, req.ToAccountID, req.Currency)
+	if !flag {
+		return
+	}
+
+	if !server.authorizeAccount(ctx, account, db.PermissionTransfer, errHoldNotOwner) {
+		return
+	}
+
+	arg := db.PlaceHoldTxParams{
+		AccountID:   req.AccountID,
+		ToAccountID: req.ToAccountID,
+		Amount:      req.Amount,
+		ExpiresAt:   time.Now().Add(time.Duration(req.ExpiresIn) * time.Second),
+	}
+
+	hold, err := server.store.PlaceHoldTx(ctx, arg)
+	if err != nil {
+		respondError(ctx, apierror.FromStore(err))
+		return
+	}
+	db.MarkWritten(ctx, account.Owner, toAccount.Owner)
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+type holdURIRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type captureHoldRequest struct {
+	Amount int64 `json:"amount" binding:"required,gt=0"`
+}
+
+// 由收款方（类似商户）发起扣款，支持部分扣款
+func (server *Server) captureHold(ctx *gin.Context) {
+	var uri holdURIRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	var req captureHoldRequest
+	err = ctx.ShouldBindJSON(&req)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	hold, flag := server.validHold(ctx, uri.ID)
+	if !flag {
+		return
+	}
+
+	toAccount, flag := server.fetchAccount(ctx, hold.ToAccountID)
+	if !flag {
+		return
+	}
+
+	if !server.authorizeAccount(ctx, toAccount, db.PermissionTransfer, errHoldNotPayee) {
+		return
+	}
+
+	arg := db.CaptureHoldTxParams{
+		HoldID: hold.ID,
+		Amount: req.Amount,
+	}
+
+	result, err := server.store.CaptureHoldTx(ctx, arg)
+	if err != nil {
+		respondError(ctx, apierror.FromStore(err))
+		return
+	}
+	db.MarkWritten(ctx, result.Transfer.FromAccount.Owner, result.Transfer.ToAccount.Owner)
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// 付款方和收款方都可以释放预授权
+func (server *Server) releaseHold(ctx *gin.Context) {
+	var uri holdURIRequest
+	err := ctx.ShouldBindUri(&uri)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	hold, flag := server.validHold(ctx, uri.ID)
+	if !flag {
+		return
+	}
+
+	fromAccount, flag := server.fetchAccount(ctx, hold.AccountID)
+	if !flag {
+		return
+	}
+
+	toAccount, flag := server.fetchAccount(ctx, hold.ToAccountID)
+	if !flag {
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	canRelease, err := server.canAccess(ctx, authPayload.Username, fromAccount, db.PermissionTransfer)
+	if err == nil && !canRelease {
+		canRelease, err = server.canAccess(ctx, authPayload.Username, toAccount, db.PermissionTransfer)
+	}
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+	if !canRelease {
+		respondError(ctx, errHoldNotParty)
+		return
+	}
+
+	hold, err = server.store.ReleaseHold(ctx, hold.ID)
+	if err != nil {
+		// 没有更新到记录说明预授权已经不是active状态
+		if err == sql.ErrNoRows {
+			respondError(ctx, apierror.FromStore(db.ErrHoldNotActive))
+			return
+		}
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+	db.MarkWritten(ctx, fromAccount.Owner, toAccount.Owner)
+
+	ctx.JSON(http.StatusOK, hold)
+}
+
+func (server *Server) validHold(ctx *gin.Context, holdID int64) (db.Hold, bool) {
+	hold, err := server.store.GetHold(ctx, holdID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			respondError(ctx, errHoldNotFound)
+		} else {
+			respondError(ctx, apierror.Internal(err))
+		}
+		return hold, false
+	}
+
+	return hold, true
+}
@@ -1,24 +1,38 @@
 package api
 
 import (
-	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"runtime/debug"
+	"simplebank/apierror"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
 	"simplebank/token"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authorization_payload"
+	requestIDHeaderKey      = "X-Request-ID"
+	requestIDKey            = "request_id"
+	tracerName              = "simplebank/api"
 )
 
 var (
-	ErrNotAuthorizationHeader = errors.New("authorization header is not provided")
-	ErrWrongFormat            = errors.New("invalid authorization header format")
-	ErrWrongAuthorizationType = errors.New("invalid authorization type")
+	ErrNotAuthorizationHeader = apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, "authorization header is not provided")
+	ErrWrongFormat            = apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, "invalid authorization header format")
+	ErrWrongAuthorizationType = apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, "invalid authorization type")
+	ErrPermissionDenied       = apierror.New(http.StatusForbidden, apierror.CodePermissionDenied, "permission denied")
 )
 
 func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
@@ -26,7 +40,7 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 
 		if len(authorizationHeader) == 0 {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(ErrNotAuthorizationHeader))
+			respondError(ctx, ErrNotAuthorizationHeader)
 			return
 		}
 
@@ -34,24 +48,135 @@ func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
 		// 解析token
 		field := strings.Fields(authorizationHeader)
 		if len(field) < 2 {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(ErrWrongFormat))
+			respondError(ctx, ErrWrongFormat)
 			return
 		}
 
 		authorizationType := strings.ToLower(field[0])
 		if authorizationType != authorizationTypeBearer {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(ErrWrongAuthorizationType))
+			respondError(ctx, ErrWrongAuthorizationType)
 			return
 		}
 
 		accessToken := field[1]
+		_, span := otel.Tracer(tracerName).Start(ctx.Request.Context(), "auth.VerifyToken")
 		payload, err := tokenMaker.VerifyToken(accessToken)
+		span.End()
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errorResponse(err))
+			// token的错误信息（过期、无效）可以直接返回给客户端
+			respondError(ctx, apierror.New(http.StatusUnauthorized, apierror.CodeUnauthenticated, err.Error()))
 			return
 		}
 
 		ctx.Set(authorizationPayloadKey, payload)
+		setRequestLogger(ctx, logging.FromContext(ctx.Request.Context()).With("username", payload.Username))
 		ctx.Next()
 	}
 }
+
+// 为每个请求分配请求ID，沿用客户端传入的X-Request-ID，并在响应头中返回
+// 同时把带有请求ID的logger放入请求的context，处理函数和store中的日志都能关联到同一个请求
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(requestIDHeaderKey)
+		if !logging.ValidRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		ctx.Set(requestIDKey, requestID)
+		ctx.Header(requestIDHeaderKey, requestID)
+
+		logger := slog.Default().With("request_id", requestID)
+		// otelgin在此之前创建了请求的span，日志中带上trace_id便于和链路关联
+		if spanContext := trace.SpanContextFromContext(ctx.Request.Context()); spanContext.HasTraceID() {
+			logger = logger.With("trace_id", spanContext.TraceID().String())
+		}
+		setRequestLogger(ctx, logger)
+		ctx.Next()
+	}
+}
+
+func setRequestLogger(ctx *gin.Context, logger *slog.Logger) {
+	ctx.Request = ctx.Request.WithContext(logging.WithContext(ctx.Request.Context(), logger))
+}
+
+// 访问日志，替代gin默认的文本日志，查询参数中的敏感字段会被脱敏
+func accessLogMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		status := ctx.Writer.Status()
+		attrs := []any{
+			"method", ctx.Request.Method,
+			"path", ctx.Request.URL.Path,
+			"route", ctx.FullPath(),
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip", ctx.ClientIP(),
+			"bytes", ctx.Writer.Size(),
+		}
+		if query := logging.RedactQuery(ctx.Request.URL.Query()); query != "" {
+			attrs = append(attrs, "query", query)
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		// username由authMiddleware写入logger
+		logging.FromContext(ctx.Request.Context()).Log(ctx.Request.Context(), level, "http request", attrs...)
+	}
+}
+
+// panic时记录堆栈信息并返回500
+func recoveryMiddleware() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(ctx *gin.Context, recovered any) {
+		logging.FromContext(ctx.Request.Context()).Error("panic recovered",
+			"panic", fmt.Sprint(recovered),
+			"stack", string(debug.Stack()),
+		)
+		respondError(ctx, apierror.Internal(fmt.Errorf("panic: %v", recovered)))
+	})
+}
+
+// 必须在authMiddleware之后使用，只允许指定角色的用户访问
+func roleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		for _, role := range roles {
+			if payload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		respondError(ctx, ErrPermissionDenied)
+	}
+}
+
+// 必须在authMiddleware之后使用。配置了从库时，写请求中的查询以及数据刚被写过的用户的读请求都走主库，
+// 保证用户能读到自己刚写入的数据（read-your-writes）；被写的用户包括操作者和处理函数用db.MarkWritten标记的账户所有者
+func readYourWritesMiddleware(writes *db.WriteTracker) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		payload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+
+		write := ctx.Request.Method != http.MethodGet && ctx.Request.Method != http.MethodHead
+		if write {
+			ctx.Request = ctx.Request.WithContext(db.WithWrittenOwners(db.WithPrimary(ctx.Request.Context())))
+		} else if writes.RecentlyWrote(payload.Username) {
+			ctx.Request = ctx.Request.WithContext(db.WithPrimary(ctx.Request.Context()))
+		}
+
+		ctx.Next()
+
+		if write && ctx.Writer.Status() < http.StatusBadRequest {
+			writes.Record(append(db.WrittenOwners(ctx.Request.Context()), payload.Username)...)
+		}
+	}
+}
@@ -1,9 +1,14 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
 	"simplebank/token"
 	"testing"
 	"time"
@@ -115,3 +120,145 @@ func addAuthorizationHeader(
 	authorizationHeader := fmt.Sprintf("%s %s", authorizationType, toekn)
 	request.Header.Add(authorizationHeaderKey, authorizationHeader)
 }
+
+// 访问日志使用请求时的默认logger，测试中替换为写入buffer的logger
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(logging.New(&buf, "debug"))
+	t.Cleanup(func() {
+		slog.SetDefault(defaultLogger)
+	})
+	return &buf
+}
+
+func findLogRecord(t *testing.T, buf *bytes.Buffer, msg string) map[string]interface{} {
+	for _, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(line, &record))
+		if record["msg"] == msg {
+			return record
+		}
+	}
+
+	require.Failf(t, "log record not found", "msg: %s", msg)
+	return nil
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	user, _ := randomUser(t)
+	buf := captureLogs(t)
+
+	server := newTestServer(t, nil)
+	logPath := "/logtest/:id"
+	server.router.GET(
+		logPath,
+		authMiddleware(server.tokenMaker),
+		func(c *gin.Context) {
+			logging.FromContext(c).Info("handler called")
+			c.JSON(http.StatusOK, "ok")
+		},
+	)
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/logtest/1?token=secret-value&page_id=2", nil)
+	require.NoError(t, err)
+	request.Header.Set(requestIDHeaderKey, "log-request-1")
+	addAuthorizationHeader(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, token.DepositorRole, time.Minute)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	// 处理函数中的日志带有请求ID和用户名
+	handlerRecord := findLogRecord(t, buf, "handler called")
+	require.Equal(t, "log-request-1", handlerRecord["request_id"])
+	require.Equal(t, user.Username, handlerRecord["username"])
+
+	accessRecord := findLogRecord(t, buf, "http request")
+	require.Equal(t, "INFO", accessRecord["level"])
+	require.Equal(t, "log-request-1", accessRecord["request_id"])
+	require.Equal(t, user.Username, accessRecord["username"])
+	require.Equal(t, http.MethodGet, accessRecord["method"])
+	require.Equal(t, logPath, accessRecord["route"])
+	require.Equal(t, float64(http.StatusOK), accessRecord["status"])
+	require.Contains(t, accessRecord, "latency_ms")
+	require.Equal(t, "page_id=2&token=%5BREDACTED%5D", accessRecord["query"])
+
+	require.NotContains(t, buf.String(), "secret-value")
+	require.NotContains(t, buf.String(), request.Header.Get(authorizationHeaderKey))
+}
+
+func TestRecoveryMiddleware(t *testing.T) {
+	buf := captureLogs(t)
+
+	server := newTestServer(t, nil)
+	server.router.GET("/panictest", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/panictest", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusInternalServerError, recorder.Code)
+
+	panicRecord := findLogRecord(t, buf, "panic recovered")
+	require.Equal(t, "boom", panicRecord["panic"])
+
+	accessRecord := findLogRecord(t, buf, "http request")
+	require.Equal(t, "ERROR", accessRecord["level"])
+	require.Equal(t, panicRecord["request_id"], accessRecord["request_id"])
+}
+
+func TestReadYourWritesMiddleware(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.writes = db.NewWriteTracker(time.Minute)
+
+	path := "/readyourwritestest"
+	var primary bool
+	handler := func(c *gin.Context) {
+		primary = db.IsPrimary(c)
+		if c.Query("fail") != "" {
+			c.JSON(http.StatusBadRequest, "fail")
+			return
+		}
+		// 模拟柜员修改了其他用户的账户
+		db.MarkWritten(c, c.Query("owner"))
+		c.JSON(http.StatusOK, "ok")
+	}
+	middlewares := []gin.HandlerFunc{authMiddleware(server.tokenMaker), readYourWritesMiddleware(server.writes), handler}
+	server.router.GET(path, middlewares...)
+	server.router.POST(path, middlewares...)
+
+	send := func(method string, username string, query string) bool {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, path+query, nil)
+		require.NoError(t, err)
+		addAuthorizationHeader(t, request, server.tokenMaker, authorizationTypeBearer, username, token.DepositorRole, time.Minute)
+
+		primary = false
+		server.router.ServeHTTP(recorder, request)
+		return primary
+	}
+
+	// 没有写过数据的用户读从库
+	require.False(t, send(http.MethodGet, "alice", ""))
+
+	// 写请求中的查询读主库，失败的写请求不影响之后的读请求
+	require.True(t, send(http.MethodPost, "alice", "?fail=1"))
+	require.False(t, send(http.MethodGet, "alice", ""))
+
+	// 写成功后该用户的读请求读主库，其他用户不受影响
+	require.True(t, send(http.MethodPost, "alice", ""))
+	require.True(t, send(http.MethodGet, "alice", ""))
+	require.False(t, send(http.MethodGet, "bob", ""))
+
+	// 被修改账户的所有者之后的读请求也读主库
+	require.True(t, send(http.MethodPost, "alice", "?owner=carol"))
+	require.True(t, send(http.MethodGet, "carol", ""))
+}
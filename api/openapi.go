@@ -0,0 +1,377 @@
+package api
+
+import (
+	"net/http"
+	"reflect"
+	"simplebank/apierror"
+	"simplebank/util"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	openAPIPath = "/openapi.json"
+	docsPath    = "/docs"
+)
+
+// 运维类的路由不属于业务接口，不写入OpenAPI文档
+var undocumentedRoutes = map[string]bool{
+	openAPIPath: true,
+	docsPath:    true,
+}
+
+// 路由的文档信息，URI、Query、Body、Response填写对应结构体的零值，文档根据其中的json/uri/form/binding标签生成
+type routeSpec struct {
+	Summary  string
+	Tags     []string
+	Public   bool
+	URI      interface{}
+	Query    interface{}
+	Body     interface{}
+	Response interface{}
+	// 除JSON外的响应类型，例如文件下载
+	Produces []string
+}
+
+// 响应可能是其中任意一种结构
+type oneOfResponses []interface{}
+
+type openAPIBuilder struct {
+	schemas map[string]interface{}
+}
+
+// 根据路由表和routeSpecs生成OpenAPI 3文档，没有文档信息的路由会被忽略（由测试保证覆盖）
+func buildOpenAPI(routes gin.RoutesInfo) gin.H {
+	builder := &openAPIBuilder{schemas: map[string]interface{}{}}
+
+	paths := gin.H{}
+	for _, route := range routes {
+		spec, ok := routeSpecs[routeKey(route.Method, route.Path)]
+		if !ok {
+			continue
+		}
+
+		path := openAPIPathOf(route.Path)
+		item, ok := paths[path].(gin.H)
+		if !ok {
+			item = gin.H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(route.Method)] = builder.operation(route, spec)
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":   "Simple Bank API",
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": gin.H{
+			"schemas": builder.schemas,
+			"securitySchemes": gin.H{
+				"bearerAuth": gin.H{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "PASETO",
+				},
+			},
+		},
+	}
+}
+
+func routeKey(method string, path string) string {
+	return method + " " + path
+}
+
+// gin的 /accounts/:id 转换为OpenAPI的 /accounts/{id}
+func openAPIPathOf(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// 处理函数名作为operationId，例如 simplebank/api.(*Server).createUser-fm -> createUser
+func operationIDOf(handler string) string {
+	name := handler[strings.LastIndex(handler, ".")+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
+
+func (builder *openAPIBuilder) operation(route gin.RouteInfo, spec routeSpec) gin.H {
+	operation := gin.H{
+		"operationId": operationIDOf(route.Handler),
+		"summary":     spec.Summary,
+		"tags":        spec.Tags,
+	}
+
+	var parameters []gin.H
+	if spec.URI != nil {
+		parameters = append(parameters, builder.parameters(reflect.TypeOf(spec.URI), "uri", "path")...)
+	}
+	if spec.Query != nil {
+		parameters = append(parameters, builder.parameters(reflect.TypeOf(spec.Query), "form", "query")...)
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if spec.Body != nil {
+		operation["requestBody"] = gin.H{
+			"required": true,
+			"content": gin.H{
+				"application/json": gin.H{"schema": builder.schema(reflect.TypeOf(spec.Body))},
+			},
+		}
+	}
+
+	content := gin.H{}
+	switch response := spec.Response.(type) {
+	case nil:
+	case oneOfResponses:
+		var schemas []interface{}
+		for _, r := range response {
+			schemas = append(schemas, builder.schema(reflect.TypeOf(r)))
+		}
+		content["application/json"] = gin.H{"schema": gin.H{"oneOf": schemas}}
+	default:
+		content["application/json"] = gin.H{"schema": builder.schema(reflect.TypeOf(response))}
+	}
+	for _, contentType := range spec.Produces {
+		content[contentType] = gin.H{"schema": gin.H{"type": "string", "format": "binary"}}
+	}
+
+	operation["responses"] = gin.H{
+		"200": gin.H{
+			"description": "OK",
+			"content":     content,
+		},
+		"default": gin.H{
+			"description": "Error",
+			"content": gin.H{
+				"application/json": gin.H{"schema": builder.schema(reflect.TypeOf(apierror.Error{}))},
+			},
+		},
+	}
+
+	if !spec.Public {
+		operation["security"] = []gin.H{{"bearerAuth": []string{}}}
+	}
+
+	return operation
+}
+
+// 由uri或form标签生成路径参数或查询参数
+func (builder *openAPIBuilder) parameters(t reflect.Type, tagKey string, in string) []gin.H {
+	var parameters []gin.H
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get(tagKey), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		schema := builder.schema(field.Type)
+		required := applyBinding(schema, field)
+		if _, ok := field.Tag.Lookup("time_format"); ok {
+			schema = gin.H{"type": "string", "format": "date-time"}
+		}
+
+		parameters = append(parameters, gin.H{
+			"name":     name,
+			"in":       in,
+			"required": required || in == "path",
+			"schema":   schema,
+		})
+	}
+	return parameters
+}
+
+func (builder *openAPIBuilder) schema(t reflect.Type) gin.H {
+	if t == reflect.TypeOf(time.Time{}) {
+		return gin.H{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := builder.schema(t.Elem())
+		if _, isRef := schema["$ref"]; isRef {
+			return gin.H{"allOf": []gin.H{schema}, "nullable": true}
+		}
+		schema["nullable"] = true
+		return schema
+	case reflect.String:
+		return gin.H{"type": "string"}
+	case reflect.Bool:
+		return gin.H{"type": "boolean"}
+	case reflect.Int32:
+		return gin.H{"type": "integer", "format": "int32"}
+	case reflect.Int64:
+		return gin.H{"type": "integer", "format": "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return gin.H{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return gin.H{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return gin.H{"type": "string", "format": "byte"}
+		}
+		return gin.H{"type": "array", "items": builder.schema(t.Elem())}
+	case reflect.Map:
+		return gin.H{"type": "object", "additionalProperties": builder.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return builder.object(t)
+		}
+		name := schemaName(t)
+		if _, ok := builder.schemas[name]; !ok {
+			// 先占位，避免自引用的结构体无限递归
+			builder.schemas[name] = gin.H{}
+			builder.schemas[name] = builder.object(t)
+		}
+		return gin.H{"$ref": "#/components/schemas/" + name}
+	}
+	return gin.H{}
+}
+
+// api、db和apierror的结构体直接使用类型名，其他包加上包名前缀，例如 reconcile.Report -> ReconcileReport
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	name = strings.ToUpper(name[:1]) + name[1:]
+
+	pkg := t.PkgPath()
+	pkg = pkg[strings.LastIndex(pkg, "/")+1:]
+	if pkg == "api" || pkg == "sqlc" || pkg == "apierror" {
+		return name
+	}
+	return strings.ToUpper(pkg[:1]) + pkg[1:] + name
+}
+
+func (builder *openAPIBuilder) object(t reflect.Type) gin.H {
+	properties := gin.H{}
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		// 匿名嵌入的结构体，字段平铺到外层
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded := builder.object(field.Type)
+			for key, value := range embedded["properties"].(gin.H) {
+				properties[key] = value
+			}
+			if fields, ok := embedded["required"].([]string); ok {
+				required = append(required, fields...)
+			}
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := builder.schema(field.Type)
+		if applyBinding(schema, field) {
+			required = append(required, name)
+		}
+		properties[name] = schema
+	}
+
+	object := gin.H{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		sort.Strings(required)
+		object["required"] = required
+	}
+	return object
+}
+
+// 把binding标签中的校验规则写入schema，返回字段是否必填
+func applyBinding(schema gin.H, field reflect.StructField) bool {
+	required := false
+	isString := schema["type"] == "string"
+
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "min", "max", "gt":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			switch {
+			case isString && name == "min":
+				schema["minLength"] = n
+			case isString && name == "max":
+				schema["maxLength"] = n
+			case name == "min":
+				schema["minimum"] = n
+			case name == "max":
+				schema["maximum"] = n
+			case name == "gt":
+				schema["minimum"] = n
+				schema["exclusiveMinimum"] = true
+			}
+		case "oneof":
+			schema["enum"] = strings.Fields(value)
+		case "email":
+			schema["format"] = "email"
+		case "alphanum":
+			schema["pattern"] = "^[a-zA-Z0-9]+$"
+		case "currency":
+			schema["enum"] = []string{util.USD, util.EUR, util.CAD}
+		case "account_type":
+			schema["enum"] = []string{util.Checking, util.Savings}
+		}
+	}
+
+	return required
+}
+
+func (server *Server) getOpenAPI(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, server.openAPI)
+}
+
+// Swagger UI从CDN加载，读取/openapi.json
+const docsHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>Simple Bank API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css" />
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({ url: "` + openAPIPath + `", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
+
+func (server *Server) getDocs(ctx *gin.Context) {
+	ctx.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsHTML))
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"net/http"
+	db "simplebank/db/sqlc"
+	"simplebank/reconcile"
+	"simplebank/statement"
+)
+
+// 每个注册到路由的接口都需要在这里登记，TestOpenAPICoverage会检查遗漏
+var routeSpecs = map[string]routeSpec{
+	routeKey(http.MethodGet, healthzPath): {
+		Summary:  "Liveness probe",
+		Tags:     []string{"health"},
+		Public:   true,
+		Response: healthResponse{},
+	},
+	routeKey(http.MethodGet, readyzPath): {
+		Summary:  "Readiness probe, checks the database and schema migrations",
+		Tags:     []string{"health"},
+		Public:   true,
+		Response: readinessResponse{},
+	},
+
+	routeKey(http.MethodPost, "/users"): {
+		Summary:  "Create a user",
+		Tags:     []string{"users"},
+		Public:   true,
+		Body:     createUserRequest{},
+		Response: userResponse{},
+	},
+	routeKey(http.MethodGet, "/users/login"): {
+		Summary:  "Log in and obtain an access token",
+		Tags:     []string{"users"},
+		Public:   true,
+		Body:     loginUserRequest{},
+		Response: loginUserRespose{},
+	},
+
+	routeKey(http.MethodPost, "/accounts"): {
+		Summary:  "Create an account",
+		Tags:     []string{"accounts"},
+		Body:     createAccountRequest{},
+		Response: db.Account{},
+	},
+	routeKey(http.MethodGet, "/accounts/:id"): {
+		Summary:  "Get an account",
+		Tags:     []string{"accounts"},
+		URI:      getAccountRequest{},
+		Response: db.Account{},
+	},
+	routeKey(http.MethodPatch, "/accounts/:id"): {
+		Summary:  "Update an account's nickname",
+		Tags:     []string{"accounts"},
+		URI:      getAccountRequest{},
+		Body:     updateAccountRequest{},
+		Response: db.Account{},
+	},
+	routeKey(http.MethodGet, "/accounts/list"): {
+		Summary:  "List owned and joint accounts",
+		Tags:     []string{"accounts"},
+		Query:    listAccountRequest{},
+		Response: []db.Account{},
+	},
+	routeKey(http.MethodGet, "/accounts/:id/balance"): {
+		Summary:  "Get the current or historic balance",
+		Tags:     []string{"accounts"},
+		URI:      getAccountRequest{},
+		Query:    getAccountBalanceQuery{},
+		Response: oneOfResponses{accountBalanceResponse{}, historicBalanceResponse{}},
+	},
+	routeKey(http.MethodGet, "/accounts/:id/statements"): {
+		Summary:  "Get a monthly statement",
+		Tags:     []string{"accounts"},
+		URI:      getAccountRequest{},
+		Query:    getAccountStatementQuery{},
+		Response: statement.Statement{},
+		Produces: []string{"text/csv", "application/pdf"},
+	},
+	routeKey(http.MethodGet, "/accounts/:id/export"): {
+		Summary:  "Export transactions as OFX or camt.053",
+		Tags:     []string{"accounts"},
+		URI:      getAccountRequest{},
+		Query:    exportAccountQuery{},
+		Produces: []string{"application/x-ofx", "application/xml"},
+	},
+	routeKey(http.MethodGet, "/accounts/:id/holders"): {
+		Summary:  "List joint holders of an account",
+		Tags:     []string{"holders"},
+		URI:      getAccountRequest{},
+		Response: []db.AccountHolder{},
+	},
+	routeKey(http.MethodPost, "/accounts/:id/holders"): {
+		Summary:  "Invite a joint holder",
+		Tags:     []string{"holders"},
+		URI:      getAccountRequest{},
+		Body:     inviteHolderRequest{},
+		Response: db.AccountHolder{},
+	},
+	routeKey(http.MethodPost, "/accounts/:id/holders/accept"): {
+		Summary:  "Accept a joint holder invitation",
+		Tags:     []string{"holders"},
+		URI:      getAccountRequest{},
+		Response: db.AccountHolder{},
+	},
+
+	routeKey(http.MethodPost, "/transfer"): {
+		Summary:  "Transfer money between accounts",
+		Tags:     []string{"transfers"},
+		Body:     transferRequest{},
+		Response: db.TransferTxResult{},
+	},
+
+	routeKey(http.MethodPost, "/holds"): {
+		Summary:  "Place a hold on funds",
+		Tags:     []string{"holds"},
+		Body:     placeHoldRequest{},
+		Response: db.Hold{},
+	},
+	routeKey(http.MethodPost, "/holds/:id/capture"): {
+		Summary:  "Capture a hold",
+		Tags:     []string{"holds"},
+		URI:      holdURIRequest{},
+		Body:     captureHoldRequest{},
+		Response: db.CaptureHoldTxResult{},
+	},
+	routeKey(http.MethodPost, "/holds/:id/release"): {
+		Summary:  "Release a hold",
+		Tags:     []string{"holds"},
+		URI:      holdURIRequest{},
+		Response: db.Hold{},
+	},
+
+	routeKey(http.MethodPost, "/admin/accounts/:id/deposit"): {
+		Summary:  "Deposit cash into an account (banker only)",
+		Tags:     []string{"admin"},
+		URI:      getAccountRequest{},
+		Body:     cashRequest{},
+		Response: db.TransferTxResult{},
+	},
+	routeKey(http.MethodPost, "/admin/accounts/:id/withdraw"): {
+		Summary:  "Withdraw cash from an account (banker only)",
+		Tags:     []string{"admin"},
+		URI:      getAccountRequest{},
+		Body:     cashRequest{},
+		Response: db.TransferTxResult{},
+	},
+	routeKey(http.MethodGet, "/admin/accounts/:id/verify-chain"): {
+		Summary:  "Verify an account's entry hash chain (banker only)",
+		Tags:     []string{"admin"},
+		URI:      getAccountRequest{},
+		Response: reconcile.ChainReport{},
+	},
+	routeKey(http.MethodGet, "/admin/reconciliation"): {
+		Summary:  "Run ledger reconciliation (banker only)",
+		Tags:     []string{"admin"},
+		Response: reconciliationResponse{},
+	},
+}
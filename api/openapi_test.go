@@ -0,0 +1,196 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"simplebank/apierror"
+	mockdb "simplebank/db/mock"
+	"simplebank/memstore"
+	"simplebank/token"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// 新增路由时必须在routeSpecs中登记，删除路由时也要同步移除
+func TestOpenAPICoverage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+
+	registered := map[string]bool{}
+	for _, route := range server.router.Routes() {
+		if undocumentedRoutes[route.Path] {
+			continue
+		}
+
+		key := routeKey(route.Method, route.Path)
+		registered[key] = true
+		require.Contains(t, routeSpecs, key, "route %s has no OpenAPI spec", key)
+	}
+
+	for key := range routeSpecs {
+		require.True(t, registered[key], "OpenAPI spec %s has no matching route", key)
+	}
+}
+
+// routeSpecs是手工登记的，用空请求调用每个接口，对比处理函数实际校验出的字段与文档中的字段，
+// 防止文档登记了错误的请求结构体，或者结构体改了而处理函数用的是另一个
+func TestOpenAPISpecMatchesHandlers(t *testing.T) {
+	server := newTestServer(t, memstore.New())
+	accessToken, _, err := server.tokenMaker.CreateToken(util.RandomOwner(), token.BankerRole, time.Minute)
+	require.NoError(t, err)
+
+	for key, spec := range routeSpecs {
+		method, path, _ := strings.Cut(key, " ")
+
+		t.Run(key, func(t *testing.T) {
+			request, err := http.NewRequest(method, strings.ReplaceAll(path, ":id", "1"), strings.NewReader("{}"))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "application/json")
+			if !spec.Public {
+				request.Header.Set(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+			}
+
+			recorder := httptest.NewRecorder()
+			server.router.ServeHTTP(recorder, request)
+
+			// 路径参数已经填了合法的值，只有查询参数和请求体中的必填字段会校验失败
+			documented, required := map[string]bool{}, []string{}
+			for i, v := range []interface{}{spec.URI, spec.Query, spec.Body} {
+				all, req := requestFields(v)
+				for _, name := range all {
+					documented[name] = true
+				}
+				if i > 0 {
+					required = append(required, req...)
+				}
+			}
+
+			var response apierror.Error
+			_ = json.Unmarshal(recorder.Body.Bytes(), &response)
+			if response.Code != apierror.CodeValidationFailed {
+				require.Empty(t, required, "handler accepted an empty request but the spec has required fields")
+				return
+			}
+
+			failed := map[string]bool{}
+			for _, detail := range response.Details {
+				require.True(t, documented[detail.Field], "handler validates %q which is not in the spec", detail.Field)
+				failed[detail.Field] = true
+			}
+			for _, name := range required {
+				require.True(t, failed[name], "spec requires %q but the handler does not", name)
+			}
+		})
+	}
+}
+
+// 请求结构体的所有字段名和必填字段名，与文档生成使用同样的规则
+func requestFields(v interface{}) (all []string, required []string) {
+	if v == nil {
+		return nil, nil
+	}
+
+	typ := reflect.TypeOf(v)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := requestFieldName(field)
+		all = append(all, name)
+		if applyBinding(gin.H{}, field) {
+			required = append(required, name)
+		}
+	}
+	return all, required
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, openAPIPath, nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+			Parameters  []struct {
+				Name     string `json:"name"`
+				In       string `json:"in"`
+				Required bool   `json:"required"`
+			} `json:"parameters"`
+			Security []map[string][]string `json:"security"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Required   []string                          `json:"required"`
+				Properties map[string]map[string]interface{} `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &doc)
+	require.NoError(t, err)
+	require.Equal(t, "3.0.3", doc.OpenAPI)
+
+	getAccount := doc.Paths["/accounts/{id}"]["get"]
+	require.Equal(t, "getAccount", getAccount.OperationID)
+	require.Len(t, getAccount.Parameters, 1)
+	require.Equal(t, "id", getAccount.Parameters[0].Name)
+	require.Equal(t, "path", getAccount.Parameters[0].In)
+	require.True(t, getAccount.Parameters[0].Required)
+	require.NotEmpty(t, getAccount.Security)
+
+	require.Empty(t, doc.Paths["/users"]["post"].Security)
+
+	transfer := doc.Components.Schemas["TransferRequest"]
+	require.ElementsMatch(t, []string{"from_account_id", "to_account_id", "amount", "currency"}, transfer.Required)
+	require.Equal(t, []interface{}{"USD", "EUR", "CAD"}, transfer.Properties["currency"]["enum"])
+	require.Equal(t, true, transfer.Properties["amount"]["exclusiveMinimum"])
+
+	require.Contains(t, doc.Components.Schemas, "Account")
+}
+
+func TestDocs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, docsPath, nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), openAPIPath)
+}
+
+// 监控指标在单独的地址上导出，业务接口的路由中没有/metrics
+func TestMetricsNotPublic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	server := newTestServer(t, mockdb.NewMockStore(ctrl))
+	recorder := httptest.NewRecorder()
+
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+}
@@ -1,15 +1,22 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	db "simplebank/db/sqlc"
+	"simplebank/metrics"
 	"simplebank/token"
+	"simplebank/tracing"
 	"simplebank/util"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Server struct {
@@ -17,10 +24,30 @@ type Server struct {
 	tokenMaker token.Maker
 	store      db.Store
 	router     *gin.Engine
+	openAPI    gin.H
+	metrics    *metrics.Metrics
+	httpServer *http.Server
+	writes     *db.WriteTracker
 }
 
-func NewServer(config util.Config, store db.Store) (*Server, error) {
-	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey)
+type ServerOption func(server *Server)
+
+// WithMetrics 使用调用方创建的监控指标，便于与store、连接池共用同一个registry
+func WithMetrics(metrics *metrics.Metrics) ServerOption {
+	return func(server *Server) {
+		server.metrics = metrics
+	}
+}
+
+// WithWriteTracker 与gRPC接口共用同一个WriteTracker，任一接口的写操作都会让之后的读请求走主库
+func WithWriteTracker(writes *db.WriteTracker) ServerOption {
+	return func(server *Server) {
+		server.writes = writes
+	}
+}
+
+func NewServer(config util.Config, store db.Store, options ...ServerOption) (*Server, error) {
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey, config.TokenPreviousSymmetricKeys...)
 	if err != nil {
 		return nil, fmt.Errorf("could not create token maker: %w", err)
 	}
@@ -29,11 +56,19 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 		config:     config,
 		tokenMaker: tokenMaker,
 		store:      store,
+		metrics:    metrics.New(),
+		writes:     db.NewWriteTracker(config.ReadYourWritesWindow),
+	}
+
+	for _, option := range options {
+		option(server)
 	}
 
 	v, ok := binding.Validator.Engine().(*validator.Validate)
 	if ok {
 		v.RegisterValidation("currency", validCurrency)
+		v.RegisterValidation("account_type", validAccountType)
+		v.RegisterTagNameFunc(requestFieldName)
 	} else {
 		log.Fatal("validator not found")
 	}
@@ -44,25 +79,71 @@ func NewServer(config util.Config, store db.Store) (*Server, error) {
 }
 
 func (server *Server) setUpRouter() {
-	router := gin.Default()
+	router := gin.New()
+	// 处理函数把*gin.Context作为context传给store，需要能取到请求context中的logger
+	router.ContextWithFallback = true
+	router.Use(
+		otelgin.Middleware(tracing.ServiceName),
+		requestIDMiddleware(),
+		accessLogMiddleware(),
+		recoveryMiddleware(),
+		server.metrics.GinMiddleware(),
+	)
+
+	router.GET(healthzPath, server.getHealthz)
+	router.GET(readyzPath, server.getReadyz)
 
 	router.POST("/users", server.createUser)
 	router.GET("/users/login", server.loginUser)
 
-	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker))
+	authRoutes := router.Group("/").Use(authMiddleware(server.tokenMaker), readYourWritesMiddleware(server.writes))
 	authRoutes.POST("/accounts", server.createAccount)
 	authRoutes.GET("/accounts/:id", server.getAccount)
+	authRoutes.PATCH("/accounts/:id", server.updateAccount)
 	authRoutes.GET("/accounts/list", server.listAccount)
+	authRoutes.GET("/accounts/:id/balance", server.getAccountBalance)
+	authRoutes.GET("/accounts/:id/statements", server.getAccountStatement)
+	authRoutes.GET("/accounts/:id/export", server.exportAccount)
+	authRoutes.GET("/accounts/:id/holders", server.listAccountHolders)
+	authRoutes.POST("/accounts/:id/holders", server.inviteAccountHolder)
+	authRoutes.POST("/accounts/:id/holders/accept", server.acceptAccountHolder)
 
 	authRoutes.POST("/transfer", server.createTransfer)
 
+	authRoutes.POST("/holds", server.placeHold)
+	authRoutes.POST("/holds/:id/capture", server.captureHold)
+	authRoutes.POST("/holds/:id/release", server.releaseHold)
+
+	adminRoutes := router.Group("/admin").Use(authMiddleware(server.tokenMaker), roleMiddleware(token.BankerRole), readYourWritesMiddleware(server.writes))
+	adminRoutes.POST("/accounts/:id/deposit", server.deposit)
+	adminRoutes.POST("/accounts/:id/withdraw", server.withdraw)
+	adminRoutes.GET("/accounts/:id/verify-chain", server.verifyEntryChain)
+	adminRoutes.GET("/reconciliation", server.getReconciliation)
+
+	server.openAPI = buildOpenAPI(router.Routes())
+	router.GET(openAPIPath, server.getOpenAPI)
+	router.GET(docsPath, server.getDocs)
+
 	server.router = router
+	// Start和Shutdown可能在不同的goroutine中调用，提前创建避免数据竞争
+	server.httpServer = &http.Server{Handler: router}
 }
 
+// Start 启动HTTP服务并阻塞，调用Shutdown后返回nil
 func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	err = server.httpServer.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// Shutdown 停止接收新请求，等待处理中的请求（例如转账）完成，ctx超时后强制返回
+func (server *Server) Shutdown(ctx context.Context) error {
+	return server.httpServer.Shutdown(ctx)
 }
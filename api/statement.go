@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"simplebank/apierror"
+	db "simplebank/db/sqlc"
+	"simplebank/statement"
+
+	"github.com/gin-gonic/gin"
+)
+
+type getAccountStatementQuery struct {
+	Month  string `form:"month" binding:"required"`
+	Format string `form:"format" binding:"omitempty,oneof=json csv pdf"`
+}
+
+// 月度对账单，支持json、csv、pdf三种格式
+func (server *Server) getAccountStatement(ctx *gin.Context) {
+	var req getAccountRequest
+	err := ctx.ShouldBindUri(&req)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	var query getAccountStatementQuery
+	err = ctx.ShouldBindQuery(&query)
+	if err != nil {
+		respondError(ctx, apierror.FromBinding(err))
+		return
+	}
+
+	start, end, err := statement.ParseMonth(query.Month)
+	if err != nil {
+		respondError(ctx, apierror.InvalidField("month", "month", err.Error()))
+		return
+	}
+
+	account, flag := server.getAuthorizedAccount(ctx, req.ID, db.PermissionView)
+	if !flag {
+		return
+	}
+
+	openingBalance, err := server.store.GetBalanceAt(ctx, account.ID, start)
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	entries, err := server.store.ListStatementEntries(ctx, db.ListStatementEntriesParams{
+		AccountID: account.ID,
+		FromTime:  start,
+		ToTime:    end,
+	})
+	if err != nil {
+		respondError(ctx, apierror.Internal(err))
+		return
+	}
+
+	result := statement.Build(account, start, end, openingBalance, entries)
+	filename := fmt.Sprintf("statement-%d-%s", account.ID, result.Month)
+
+	switch query.Format {
+	case "csv":
+		var buf bytes.Buffer
+		err = result.WriteCSV(&buf)
+		if err != nil {
+			respondError(ctx, apierror.Internal(err))
+			return
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, filename))
+		ctx.Data(http.StatusOK, "text/csv", buf.Bytes())
+	case "pdf":
+		var buf bytes.Buffer
+		err = result.WritePDF(&buf)
+		if err != nil {
+			respondError(ctx, apierror.Internal(err))
+			return
+		}
+		ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.pdf"`, filename))
+		ctx.Data(http.StatusOK, "application/pdf", buf.Bytes())
+	default:
+		ctx.JSON(http.StatusOK, result)
+	}
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/statement"
+	"simplebank/token"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAccountStatementAPI(t *testing.T) {
+	user, _ := randomUser(t)
+
+	account := randomAccount()
+	account.Owner = user.Username
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+	entries := []db.ListStatementEntriesRow{
+		{
+			ID:                    1,
+			AccountID:             account.ID,
+			Amount:                50,
+			CreatedAt:             start.Add(time.Hour),
+			Description:           "transfer",
+			TransferID:            sql.NullInt64{Int64: 9, Valid: true},
+			CounterpartyAccountID: sql.NullInt64{Int64: 2, Valid: true},
+			CounterpartyOwner:     sql.NullString{String: "alice", Valid: true},
+		},
+		{
+			ID:          2,
+			AccountID:   account.ID,
+			Amount:      -20,
+			CreatedAt:   start.Add(48 * time.Hour),
+			Description: "withdraw",
+		},
+	}
+
+	buildOKStubs := func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+		store.EXPECT().GetBalanceAt(gomock.Any(), gomock.Eq(account.ID), gomock.Eq(start)).Times(1).Return(int64(100), nil)
+		store.EXPECT().
+			ListStatementEntries(gomock.Any(), gomock.Eq(db.ListStatementEntriesParams{AccountID: account.ID, FromTime: start, ToTime: end})).
+			Times(1).
+			Return(entries, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:       "JSON",
+			query:      "month=2026-03",
+			username:   user.Username,
+			buildStubs: buildOKStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got statement.Statement
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Equal(t, int64(100), got.OpeningBalance)
+				require.Equal(t, int64(130), got.ClosingBalance)
+				require.Len(t, got.Lines, 2)
+				require.Equal(t, int64(150), got.Lines[0].RunningBalance)
+				require.Equal(t, "alice", got.Lines[0].CounterpartyOwner)
+			},
+		},
+		{
+			name:       "CSV",
+			query:      "month=2026-03&format=csv",
+			username:   user.Username,
+			buildStubs: buildOKStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv", recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Header().Get("Content-Disposition"), ".csv")
+				require.True(t, strings.HasPrefix(recorder.Body.String(), "entry_id,"))
+			},
+		},
+		{
+			name:       "PDF",
+			query:      "month=2026-03&format=pdf",
+			username:   user.Username,
+			buildStubs: buildOKStubs,
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "application/pdf", recorder.Header().Get("Content-Type"))
+				require.True(t, strings.HasPrefix(recorder.Body.String(), "%PDF-"))
+			},
+		},
+		{
+			name:     "InvalidMonth",
+			query:    "month=2026-13",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "InvalidFormat",
+			query:    "month=2026-03&format=xml",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:     "NotOwner",
+			query:    "month=2026-03",
+			username: "other",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountHolder(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountHolder{}, sql.ErrNoRows)
+				store.EXPECT().GetBalanceAt(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListStatementEntries(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/statements?%s", account.ID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			addAuthorizationHeader(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, token.DepositorRole, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(t, recorder)
+		})
+	}
+}
@@ -4,12 +4,14 @@ import (
 	"database/sql"
 	"fmt"
 	"net/http"
+	"simplebank/apierror"
 	db "simplebank/db/sqlc"
-	"simplebank/token"
 
 	"github.com/gin-gonic/gin"
 )
 
+var errTransferNotOwner = apierror.New(http.StatusUnauthorized, apierror.CodePermissionDenied, "you can not transfer other's money to yourself")
+
 type transferRequest struct {
 	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
 	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
@@ -21,7 +23,7 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 	var req transferRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, apierror.FromBinding(err))
 		return
 	}
 
@@ -35,10 +37,7 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if fromAccount.Owner != authPayload.Username {
-		err := fmt.Errorf("you can not transfer other's money to yourself")
-		ctx.JSON(http.StatusUnauthorized, errorResponse(err))
+	if !server.authorizeAccount(ctx, fromAccount, db.PermissionTransfer, errTransferNotOwner) {
 		return
 	}
 
@@ -50,27 +49,29 @@ func (server *Server) createTransfer(ctx *gin.Context) {
 
 	result, err := server.store.TransferTx(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, apierror.FromStore(err))
 		return
 	}
+	db.MarkWritten(ctx, result.FromAccount.Owner, result.ToAccount.Owner)
 
 	ctx.JSON(http.StatusOK, result)
 }
 
+// 转出账户的余额、状态用于后续校验，同样读主库
 func (server *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (db.Account, bool) {
-	account, err := server.store.GetAccount(ctx, accountID)
+	account, err := server.store.GetAccount(db.WithPrimary(ctx), accountID)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			respondError(ctx, errAccountNotFound)
 		} else {
-			ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+			respondError(ctx, apierror.Internal(err))
 		}
 		return account, false
 	}
 
 	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency is not matched", accountID)
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		message := fmt.Sprintf("account [%d] currency is not matched", accountID)
+		respondError(ctx, apierror.New(http.StatusBadRequest, apierror.CodeCurrencyMismatch, message))
 		return account, false
 	}
 
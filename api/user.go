@@ -2,10 +2,9 @@ package api
 
 import (
 	"database/sql"
-	"errors"
 	"net/http"
+	"simplebank/apierror"
 	db "simplebank/db/sqlc"
-	"simplebank/token"
 	"simplebank/util"
 	"time"
 
@@ -13,10 +12,10 @@ import (
 )
 
 type createUserRequest struct {
-	Username       string `json:"username" binding:"required"`
-	HashedPassword string `json:"hashed_password" binding:"required"`
+	Username       string `json:"username" binding:"required,alphanum"`
+	HashedPassword string `json:"hashed_password" binding:"required,min=6"`
 	FullName       string `json:"full_name" binding:"required"`
-	Email          string `json:"email" binding:"required"`
+	Email          string `json:"email" binding:"required,email"`
 }
 
 type userResponse struct {
@@ -42,13 +41,13 @@ func (server *Server) createUser(ctx *gin.Context) {
 
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, apierror.FromBinding(err))
 		return
 	}
 
 	req.HashedPassword, err = util.HashedPassword(req.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
@@ -61,7 +60,11 @@ func (server *Server) createUser(ctx *gin.Context) {
 
 	user, err := server.store.CreateUser(ctx, arg)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		if db.ErrorCode(err) == db.UniqueViolation {
+			respondError(ctx, errUsernameTaken.WithCause(err))
+			return
+		}
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
@@ -84,29 +87,31 @@ func (server *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 	err := ctx.ShouldBindJSON(&req)
 	if err != nil {
-		ctx.JSON(http.StatusBadRequest, errorResponse(err))
+		respondError(ctx, apierror.FromBinding(err))
 		return
 	}
 
 	user, err := server.store.GetUser(ctx, req.Username)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			ctx.JSON(http.StatusNotFound, errorResponse(err))
+			server.metrics.LoginFailed()
+			respondError(ctx, errUserNotFound)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
 	flag := util.CheckPassword(req.Password, user.HashedPassword)
 	if !flag {
-		ctx.JSON(http.StatusUnauthorized, errorResponse(errors.New("Passwrod is incorrect")))
+		server.metrics.LoginFailed()
+		respondError(ctx, errInvalidCredentials)
 		return
 	}
 
-	token, _, err := server.tokenMaker.CreateToken(user.Username, token.DepositorRole, server.config.AccessTokenDuration)
+	token, _, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errorResponse(err))
+		respondError(ctx, apierror.Internal(err))
 		return
 	}
 
@@ -13,6 +13,7 @@ import (
 	"reflect"
 	mockdb "simplebank/db/mock"
 	db "simplebank/db/sqlc"
+	"simplebank/token"
 	"simplebank/util"
 	"testing"
 
@@ -101,6 +102,40 @@ func TestCreateUser(t *testing.T) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
 			},
 		},
+		{
+			name: "InvalidUsername",
+			body: gin.H{
+				"username":        "bad user!",
+				"hashed_password": password,
+				"full_name":       user.FullName,
+				"email":           user.Email,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name: "InvalidEmail",
+			body: gin.H{
+				"username":        user.Username,
+				"hashed_password": password,
+				"full_name":       user.FullName,
+				"email":           "not-an-email",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
 		{
 			name: "InternalServerError",
 			body: gin.H{
@@ -185,8 +220,9 @@ func TestLoginUser(t *testing.T) {
 
 				//检验token是否有效
 				require.NotEmpty(t, response.Token)
-				_, err = server.tokenMaker.VerifyToken(response.Token)
+				payload, err := server.tokenMaker.VerifyToken(response.Token)
 				require.NoError(t, err)
+				require.Equal(t, user.Role, payload.Role)
 			},
 		},
 		{
@@ -218,6 +254,7 @@ func TestLoginUser(t *testing.T) {
 			},
 			checkResponse: func(server *Server, recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recoder.Code)
+				require.Contains(t, scrapeMetrics(t, server), "simple_bank_failed_logins_total 1")
 			},
 		},
 		{
@@ -250,6 +287,7 @@ func TestLoginUser(t *testing.T) {
 			},
 			checkResponse: func(server *Server, recoder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusUnauthorized, recoder.Code)
+				require.Contains(t, scrapeMetrics(t, server), "simple_bank_failed_logins_total 1")
 			},
 		},
 	}
@@ -282,6 +320,16 @@ func TestLoginUser(t *testing.T) {
 	}
 }
 
+func scrapeMetrics(t *testing.T, server *Server) string {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+
+	server.metrics.Handler().ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	return recorder.Body.String()
+}
+
 func randomUser(t *testing.T) (user db.User, password string) {
 	password = util.RandomString(6)
 	hashPassword, err := util.HashedPassword(password)
@@ -292,6 +340,7 @@ func randomUser(t *testing.T) (user db.User, password string) {
 		HashedPassword: hashPassword,
 		FullName:       util.RandomString(6),
 		Email:          util.RandomEmail(),
+		Role:           token.DepositorRole,
 	}
 
 	return
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"reflect"
 	"simplebank/util"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -13,3 +15,22 @@ var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
 	}
 	return false
 }
+
+var validAccountType validator.Func = func(fieldLevel validator.FieldLevel) bool {
+	accountType, ok := fieldLevel.Field().Interface().(string)
+	if ok {
+		return util.IsSupportedAccountType(accountType)
+	}
+	return false
+}
+
+// 校验错误中使用请求里的字段名（json/form/uri标签），而不是Go结构体的字段名
+func requestFieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri"} {
+		name := strings.Split(field.Tag.Get(key), ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
@@ -0,0 +1,189 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	db "simplebank/db/sqlc"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// 稳定的错误码，客户端应根据Code而不是Message判断错误类型，已发布的错误码不能修改
+const (
+	CodeInvalidArgument    = "INVALID_ARGUMENT"
+	CodeValidationFailed   = "VALIDATION_FAILED"
+	CodeUnauthenticated    = "UNAUTHENTICATED"
+	CodePermissionDenied   = "PERMISSION_DENIED"
+	CodeInvalidCredentials = "INVALID_CREDENTIALS"
+	CodeUserNotFound       = "USER_NOT_FOUND"
+	CodeUsernameTaken      = "USERNAME_TAKEN"
+	CodeAccountNotFound    = "ACCOUNT_NOT_FOUND"
+	CodeCurrencyMismatch   = "CURRENCY_MISMATCH"
+	CodeInsufficientFunds  = "INSUFFICIENT_FUNDS"
+	CodeAccountFrozen      = "ACCOUNT_FROZEN"
+	CodeNicknameTaken      = "NICKNAME_TAKEN"
+	CodeSettlementAccount  = "SETTLEMENT_ACCOUNT"
+	CodeHolderExists       = "HOLDER_EXISTS"
+	CodeInvitationNotFound = "INVITATION_NOT_FOUND"
+	CodeHoldNotFound       = "HOLD_NOT_FOUND"
+	CodeHoldNotActive      = "HOLD_NOT_ACTIVE"
+	CodeHoldAmountExceeded = "HOLD_AMOUNT_EXCEEDED"
+	CodeInternal           = "INTERNAL"
+)
+
+// Error 是HTTP接口统一的错误响应体
+type Error struct {
+	Status    int          `json:"-"`
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Details   []FieldError `json:"details,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	// 原始错误只用于日志，不返回给客户端
+	cause error
+}
+
+// FieldError 描述单个请求字段的校验失败
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+func New(status int, code string, message string) *Error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.cause)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// WithCause 返回附带原始错误的副本，预定义的错误变量可以安全复用
+func (e *Error) WithCause(err error) *Error {
+	copied := *e
+	copied.cause = err
+	return &copied
+}
+
+// Internal 隐藏内部错误的细节，只返回通用信息
+func Internal(err error) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, "internal server error").WithCause(err)
+}
+
+// FromStore 把存储层返回的业务错误映射为接口错误，其他错误一律按500处理；
+// gRPC接口按同样的分类转换状态码
+func FromStore(err error) *Error {
+	switch {
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return New(http.StatusUnprocessableEntity, CodeInsufficientFunds, db.ErrInsufficientFunds.Error())
+	case errors.Is(err, db.ErrAccountFrozen):
+		return New(http.StatusUnprocessableEntity, CodeAccountFrozen, db.ErrAccountFrozen.Error())
+	case errors.Is(err, db.ErrSettlementAccount):
+		return New(http.StatusBadRequest, CodeSettlementAccount, db.ErrSettlementAccount.Error())
+	case errors.Is(err, db.ErrHoldNotActive):
+		return New(http.StatusConflict, CodeHoldNotActive, db.ErrHoldNotActive.Error())
+	case errors.Is(err, db.ErrHoldAmountExceeded):
+		return New(http.StatusBadRequest, CodeHoldAmountExceeded, db.ErrHoldAmountExceeded.Error())
+	}
+	return Internal(err)
+}
+
+// InvalidField 用于通过了binding校验、但在处理时发现格式不正确的字段
+func InvalidField(field string, rule string, message string) *Error {
+	apiErr := New(http.StatusBadRequest, CodeValidationFailed, "request validation failed")
+	apiErr.Details = []FieldError{{
+		Field:   field,
+		Rule:    rule,
+		Message: message,
+	}}
+	return apiErr
+}
+
+// FromBinding 把gin绑定请求时的错误转换为400响应，validator的错误会翻译成字段级的详情
+func FromBinding(err error) *Error {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		apiErr := New(http.StatusBadRequest, CodeValidationFailed, "request validation failed").WithCause(err)
+		for _, fieldErr := range validationErrors {
+			apiErr.Details = append(apiErr.Details, FieldError{
+				Field:   fieldErr.Field(),
+				Rule:    fieldErr.Tag(),
+				Param:   fieldErr.Param(),
+				Message: fieldMessage(fieldErr),
+			})
+		}
+		return apiErr
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		apiErr := New(http.StatusBadRequest, CodeValidationFailed, "request validation failed").WithCause(err)
+		apiErr.Details = []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Param:   typeErr.Type.String(),
+			Message: fmt.Sprintf("must be of type %s", typeErr.Type.String()),
+		}}
+		return apiErr
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return New(http.StatusBadRequest, CodeInvalidArgument, "request body is not valid JSON").WithCause(err)
+	}
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		return New(http.StatusBadRequest, CodeInvalidArgument, fmt.Sprintf("%q is not a valid number", numErr.Num)).WithCause(err)
+	}
+
+	return New(http.StatusBadRequest, CodeInvalidArgument, "invalid request").WithCause(err)
+}
+
+func fieldMessage(fieldErr validator.FieldError) string {
+	isString := fieldErr.Kind() == reflect.String
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if isString {
+			return fmt.Sprintf("must be at least %s characters long", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at least %s", fieldErr.Param())
+	case "max":
+		if isString {
+			return fmt.Sprintf("must be at most %s characters long", fieldErr.Param())
+		}
+		return fmt.Sprintf("must be at most %s", fieldErr.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(fieldErr.Param()), ", "))
+	case "email":
+		return "must be a valid email address"
+	case "alphanum":
+		return "must contain only letters and digits"
+	case "currency":
+		return "is not a supported currency"
+	case "account_type":
+		return "is not a supported account type"
+	}
+	return fmt.Sprintf("failed the %s validation", fieldErr.Tag())
+}
@@ -0,0 +1,92 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	db "simplebank/db/sqlc"
+	"strconv"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+)
+
+type testRequest struct {
+	Name     string `json:"name" validate:"required,min=3"`
+	Amount   int64  `json:"amount" validate:"gt=0"`
+	Currency string `json:"currency" validate:"oneof=USD EUR"`
+}
+
+func TestFromBindingValidation(t *testing.T) {
+	validate := validator.New()
+	err := validate.Struct(testRequest{Name: "ab", Amount: 0, Currency: "JPY"})
+	require.Error(t, err)
+
+	apiErr := FromBinding(err)
+	require.Equal(t, http.StatusBadRequest, apiErr.Status)
+	require.Equal(t, CodeValidationFailed, apiErr.Code)
+	require.Len(t, apiErr.Details, 3)
+
+	require.Equal(t, FieldError{Field: "Name", Rule: "min", Param: "3", Message: "must be at least 3 characters long"}, apiErr.Details[0])
+	require.Equal(t, "must be greater than 0", apiErr.Details[1].Message)
+	require.Equal(t, "must be one of: USD, EUR", apiErr.Details[2].Message)
+	require.Equal(t, err, apiErr.Unwrap())
+}
+
+func TestFromBindingJSON(t *testing.T) {
+	var req testRequest
+
+	err := json.Unmarshal([]byte(`{"amount": "ten"}`), &req)
+	apiErr := FromBinding(err)
+	require.Equal(t, CodeValidationFailed, apiErr.Code)
+	require.Len(t, apiErr.Details, 1)
+	require.Equal(t, "amount", apiErr.Details[0].Field)
+	require.Equal(t, "type", apiErr.Details[0].Rule)
+
+	err = json.Unmarshal([]byte(`{`), &req)
+	apiErr = FromBinding(err)
+	require.Equal(t, CodeInvalidArgument, apiErr.Code)
+	require.Empty(t, apiErr.Details)
+
+	_, err = strconv.ParseInt("abc", 10, 64)
+	apiErr = FromBinding(err)
+	require.Equal(t, CodeInvalidArgument, apiErr.Code)
+	require.Equal(t, `"abc" is not a valid number`, apiErr.Message)
+}
+
+func TestInternalHidesCause(t *testing.T) {
+	cause := errors.New(`ERROR: relation "accounts" does not exist (SQLSTATE 42P01)`)
+	apiErr := Internal(cause)
+
+	data, err := json.Marshal(apiErr)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "accounts")
+	require.JSONEq(t, `{"code":"INTERNAL","message":"internal server error"}`, string(data))
+	require.ErrorIs(t, apiErr, cause)
+}
+
+func TestFromStore(t *testing.T) {
+	apiErr := FromStore(fmt.Errorf("transfer: %w", db.ErrInsufficientFunds))
+	require.Equal(t, http.StatusUnprocessableEntity, apiErr.Status)
+	require.Equal(t, CodeInsufficientFunds, apiErr.Code)
+
+	apiErr = FromStore(db.ErrHoldNotActive)
+	require.Equal(t, http.StatusConflict, apiErr.Status)
+	require.Equal(t, CodeHoldNotActive, apiErr.Code)
+
+	apiErr = FromStore(errors.New("connection reset"))
+	require.Equal(t, http.StatusInternalServerError, apiErr.Status)
+	require.Equal(t, CodeInternal, apiErr.Code)
+}
+
+func TestWithCauseCopies(t *testing.T) {
+	base := New(http.StatusConflict, CodeNicknameTaken, "taken")
+	cause := errors.New("duplicate key")
+
+	withCause := base.WithCause(cause)
+	require.ErrorIs(t, withCause, cause)
+	require.Nil(t, base.Unwrap())
+	require.Equal(t, base.Code, withCause.Code)
+}
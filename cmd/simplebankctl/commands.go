@@ -0,0 +1,404 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	db "simplebank/db/sqlc"
+	"simplebank/reconcile"
+	"simplebank/token"
+	"simplebank/util"
+	"strings"
+)
+
+var (
+	errUsage           = errors.New("invalid arguments")
+	errReconcileFailed = errors.New("ledger is inconsistent")
+)
+
+type cli struct {
+	config util.Config
+	store  db.Store
+	output string
+	stdout io.Writer
+}
+
+type command struct {
+	name    string
+	summary string
+	// 不需要数据库的命令（例如轮换密钥）不建立连接
+	needsStore bool
+	run        func(ctx context.Context, app *cli, args []string) error
+}
+
+var commands = []command{
+	{name: "create-user", summary: "create a user with a role", needsStore: true, run: createUser},
+	{name: "set-role", summary: "change the role of a user", needsStore: true, run: setRole},
+	{name: "create-account", summary: "create an account", needsStore: true, run: createAccount},
+	{name: "deposit", summary: "deposit cash into an account via the ledger", needsStore: true, run: deposit},
+	{name: "withdraw", summary: "withdraw cash from an account via the ledger", needsStore: true, run: withdraw},
+	{name: "freeze", summary: "freeze an account so that it cannot be debited", needsStore: true, run: freezeAccount},
+	{name: "unfreeze", summary: "unfreeze an account", needsStore: true, run: unfreezeAccount},
+	{name: "list-transfers", summary: "list transfers from or to an account", needsStore: true, run: listTransfers},
+	{name: "reconcile", summary: "verify that the ledger is consistent", needsStore: true, run: runReconcile},
+	{name: "rotate-token-key", summary: "generate a new token key and print the config to deploy", run: rotateTokenKey},
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// 每个命令有自己的参数，解析失败时返回errUsage
+func parseFlags(name string, args []string, define func(flags *flag.FlagSet)) error {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	define(flags)
+
+	err := flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errUsage, err)
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("%w: unexpected argument %q", errUsage, flags.Arg(0))
+	}
+	return nil
+}
+
+// required 检查必填参数，nameValues为参数名和值交替排列
+func required(nameValues ...string) error {
+	for i := 0; i+1 < len(nameValues); i += 2 {
+		if nameValues[i+1] == "" {
+			return fmt.Errorf("%w: -%s is required", errUsage, nameValues[i])
+		}
+	}
+	return nil
+}
+
+func validRole(role string) error {
+	switch role {
+	case token.DepositorRole, token.BankerRole:
+		return nil
+	}
+	return fmt.Errorf("%w: unsupported role %q", errUsage, role)
+}
+
+func createUser(ctx context.Context, app *cli, args []string) error {
+	var username, password, fullName, email, role string
+	err := parseFlags("create-user", args, func(flags *flag.FlagSet) {
+		flags.StringVar(&username, "username", "", "username")
+		flags.StringVar(&password, "password", "", "password, at least 6 characters")
+		flags.StringVar(&fullName, "full-name", "", "full name")
+		flags.StringVar(&email, "email", "", "email")
+		flags.StringVar(&role, "role", token.DepositorRole, "depositor or banker")
+	})
+	if err != nil {
+		return err
+	}
+	err = required("username", username, "password", password, "full-name", fullName, "email", email)
+	if err != nil {
+		return err
+	}
+	if len(password) < 6 {
+		return fmt.Errorf("%w: password must be at least 6 characters", errUsage)
+	}
+	if err := validRole(role); err != nil {
+		return err
+	}
+
+	hashedPassword, err := util.HashedPassword(password)
+	if err != nil {
+		return fmt.Errorf("cannot hash password: %w", err)
+	}
+
+	user, err := app.store.CreateUser(ctx, db.CreateUserParams{
+		Username:       username,
+		HashedPassword: hashedPassword,
+		FullName:       fullName,
+		Email:          email,
+		Role:           sql.NullString{String: role, Valid: true},
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			return errors.New("username or email is already registered")
+		}
+		return err
+	}
+
+	return app.print(user, userTable(user))
+}
+
+func setRole(ctx context.Context, app *cli, args []string) error {
+	var username, role string
+	err := parseFlags("set-role", args, func(flags *flag.FlagSet) {
+		flags.StringVar(&username, "username", "", "username")
+		flags.StringVar(&role, "role", "", "depositor or banker")
+	})
+	if err != nil {
+		return err
+	}
+	if err := required("username", username, "role", role); err != nil {
+		return err
+	}
+	if err := validRole(role); err != nil {
+		return err
+	}
+
+	user, err := app.store.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+		Username: username,
+		Role:     role,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("user %s not found", username)
+		}
+		return err
+	}
+
+	return app.print(user, userTable(user))
+}
+
+func createAccount(ctx context.Context, app *cli, args []string) error {
+	var owner, currency, accountType, nickname string
+	err := parseFlags("create-account", args, func(flags *flag.FlagSet) {
+		flags.StringVar(&owner, "owner", "", "username of the owner")
+		flags.StringVar(&currency, "currency", "", "USD, EUR or CAD")
+		flags.StringVar(&accountType, "type", util.Checking, "checking or savings")
+		flags.StringVar(&nickname, "nickname", "", "optional nickname, unique per owner")
+	})
+	if err != nil {
+		return err
+	}
+	if err := required("owner", owner, "currency", currency); err != nil {
+		return err
+	}
+	if !util.IsSupportedCurrency(currency) {
+		return fmt.Errorf("%w: unsupported currency %q", errUsage, currency)
+	}
+	if !util.IsSupportedAccountType(accountType) {
+		return fmt.Errorf("%w: unsupported account type %q", errUsage, accountType)
+	}
+	if db.IsBankOwner(owner) {
+		return fmt.Errorf("%w: cannot create accounts for bank internal user %s", errUsage, owner)
+	}
+
+	account, err := app.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    owner,
+		Balance:  0,
+		Currency: currency,
+		Type:     accountType,
+		Nickname: sql.NullString{String: nickname, Valid: nickname != ""},
+	})
+	if err != nil {
+		switch db.ErrorCode(err) {
+		case db.ForeignKeyViolation:
+			return fmt.Errorf("user %s not found", owner)
+		case db.UniqueViolation:
+			return errors.New("nickname is already used by another account of this owner")
+		}
+		return err
+	}
+
+	return app.print(account, accountTable(account))
+}
+
+func deposit(ctx context.Context, app *cli, args []string) error {
+	return cash(ctx, app, "deposit", args, app.store.DepositTx)
+}
+
+func withdraw(ctx context.Context, app *cli, args []string) error {
+	return cash(ctx, app, "withdraw", args, app.store.WithdrawTx)
+}
+
+// 存取款都与清算账户转账，保证账本平衡
+func cash(
+	ctx context.Context,
+	app *cli,
+	name string,
+	args []string,
+	cashTx func(ctx context.Context, arg db.CashTxParams) (db.TransferTxResult, error),
+) error {
+	var accountID, amount int64
+	err := parseFlags(name, args, func(flags *flag.FlagSet) {
+		flags.Int64Var(&accountID, "account", 0, "account id")
+		flags.Int64Var(&amount, "amount", 0, "amount in minor units")
+	})
+	if err != nil {
+		return err
+	}
+	if accountID <= 0 || amount <= 0 {
+		return fmt.Errorf("%w: -account and -amount must be positive", errUsage)
+	}
+
+	result, err := cashTx(ctx, db.CashTxParams{
+		AccountID: accountID,
+		Amount:    amount,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("account %d not found", accountID)
+		}
+		return err
+	}
+
+	account := result.ToAccount
+	if name == "withdraw" {
+		account = result.FromAccount
+	}
+	return app.print(result, accountTable(account))
+}
+
+func freezeAccount(ctx context.Context, app *cli, args []string) error {
+	return setAccountStatus(ctx, app, "freeze", args, db.AccountStatusFrozen)
+}
+
+func unfreezeAccount(ctx context.Context, app *cli, args []string) error {
+	return setAccountStatus(ctx, app, "unfreeze", args, db.AccountStatusActive)
+}
+
+func setAccountStatus(ctx context.Context, app *cli, name string, args []string, status string) error {
+	var accountID int64
+	err := parseFlags(name, args, func(flags *flag.FlagSet) {
+		flags.Int64Var(&accountID, "account", 0, "account id")
+	})
+	if err != nil {
+		return err
+	}
+	if accountID <= 0 {
+		return fmt.Errorf("%w: -account must be positive", errUsage)
+	}
+
+	account, err := app.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("account %d not found", accountID)
+		}
+		return err
+	}
+
+	// 冻结清算或利息支出账户会让所有存取款、结息失败
+	if status == db.AccountStatusFrozen && (account.Owner == db.SettlementOwner || account.Owner == db.InterestOwner) {
+		return fmt.Errorf("account %d: %w", accountID, db.ErrSettlementAccount)
+	}
+
+	account, err = app.store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{
+		ID:     accountID,
+		Status: status,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("account %d not found", accountID)
+		}
+		return err
+	}
+
+	return app.print(account, accountTable(account))
+}
+
+func listTransfers(ctx context.Context, app *cli, args []string) error {
+	var accountID int64
+	var limit, offset int
+	err := parseFlags("list-transfers", args, func(flags *flag.FlagSet) {
+		flags.Int64Var(&accountID, "account", 0, "account id")
+		flags.IntVar(&limit, "limit", 20, "maximum number of transfers")
+		flags.IntVar(&offset, "offset", 0, "number of transfers to skip")
+	})
+	if err != nil {
+		return err
+	}
+	if accountID <= 0 || limit <= 0 || offset < 0 {
+		return fmt.Errorf("%w: -account and -limit must be positive", errUsage)
+	}
+
+	transfers, err := app.store.ListTransfers(ctx, db.ListTransfersParams{
+		FromAccountID: accountID,
+		ToAccountID:   accountID,
+		Limit:         int32(limit),
+		Offset:        int32(offset),
+	})
+	if err != nil {
+		return err
+	}
+
+	return app.print(transfers, transferTable(transfers...))
+}
+
+// 对账，发现差异时输出报告并返回错误，方便定时任务告警
+func runReconcile(ctx context.Context, app *cli, args []string) error {
+	err := parseFlags("reconcile", args, func(flags *flag.FlagSet) {})
+	if err != nil {
+		return err
+	}
+
+	report, err := reconcile.NewReconciler(app.store, reconcile.DefaultBatchSize).Run(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = app.print(report, table{
+		header: []string{"CHECK", "RESULT"},
+		rows: [][]string{
+			{"accounts checked", fmt.Sprint(report.AccountsChecked)},
+			{"balance mismatches", fmt.Sprint(len(report.BalanceMismatches))},
+			{"unbalanced transfers", fmt.Sprint(len(report.UnbalancedTransfers))},
+			{"orphan entries", fmt.Sprint(len(report.OrphanEntries))},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	if !report.OK() {
+		return errReconcileFailed
+	}
+	return nil
+}
+
+type rotatedTokenKeys struct {
+	TokenSymmetricKey          string   `json:"TOKEN_SYMMETRIC_KEY"`
+	TokenPreviousSymmetricKeys []string `json:"TOKEN_PREVIOUS_SYMMETRIC_KEYS"`
+}
+
+// 生成新的token密钥，当前密钥移到TOKEN_PREVIOUS_SYMMETRIC_KEYS中，已签发的token在过期前仍然有效
+// 只输出需要部署的配置，不修改配置文件，密钥一般保存在密钥管理系统中
+func rotateTokenKey(ctx context.Context, app *cli, args []string) error {
+	var keep int
+	err := parseFlags("rotate-token-key", args, func(flags *flag.FlagSet) {
+		flags.IntVar(&keep, "keep", 1, "number of previous keys to keep")
+	})
+	if err != nil {
+		return err
+	}
+	if keep < 1 {
+		return fmt.Errorf("%w: -keep must be positive", errUsage)
+	}
+
+	key, err := token.NewSymmetricKey()
+	if err != nil {
+		return fmt.Errorf("cannot generate key: %w", err)
+	}
+
+	previous := append([]string{app.config.TokenSymmetricKey}, app.config.TokenPreviousSymmetricKeys...)
+	if len(previous) > keep {
+		previous = previous[:keep]
+	}
+
+	keys := rotatedTokenKeys{
+		TokenSymmetricKey:          key,
+		TokenPreviousSymmetricKeys: previous,
+	}
+	return app.print(keys, table{
+		header: []string{"VARIABLE", "VALUE"},
+		rows: [][]string{
+			{"TOKEN_SYMMETRIC_KEY", keys.TokenSymmetricKey},
+			{"TOKEN_PREVIOUS_SYMMETRIC_KEYS", strings.Join(keys.TokenPreviousSymmetricKeys, ",")},
+		},
+	})
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCLI(t *testing.T, output string) (*cli, *mockdb.MockStore, *bytes.Buffer) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	store := mockdb.NewMockStore(ctrl)
+	var stdout bytes.Buffer
+	app := &cli{
+		config: util.Config{TokenSymmetricKey: util.RandomString(32)},
+		store:  store,
+		output: output,
+		stdout: &stdout,
+	}
+	return app, store, &stdout
+}
+
+func TestCreateUserCommand(t *testing.T) {
+	app, store, stdout := newTestCLI(t, outputTable)
+
+	username := util.RandomOwner()
+	user := db.User{Username: username, FullName: "Bank Teller", Email: util.RandomEmail(), Role: token.BankerRole}
+
+	// 角色随用户一起创建，不会出现用户已创建但角色没设置上的情况
+	store.EXPECT().
+		CreateUser(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+			require.Equal(t, username, arg.Username)
+			require.True(t, util.CheckPassword("secret123", arg.HashedPassword))
+			require.Equal(t, sql.NullString{String: token.BankerRole, Valid: true}, arg.Role)
+			return user, nil
+		})
+	store.EXPECT().UpdateUserRole(gomock.Any(), gomock.Any()).Times(0)
+
+	err := createUser(context.Background(), app, []string{
+		"-username", username,
+		"-password", "secret123",
+		"-full-name", user.FullName,
+		"-email", user.Email,
+		"-role", token.BankerRole,
+	})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 2)
+	require.True(t, strings.HasPrefix(lines[0], "USERNAME"))
+	require.Contains(t, lines[1], username)
+	require.Contains(t, lines[1], token.BankerRole)
+}
+
+func TestCreateUserCommandInvalidArgs(t *testing.T) {
+	app, store, _ := newTestCLI(t, outputTable)
+	store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+
+	err := createUser(context.Background(), app, []string{"-username", "alice"})
+	require.ErrorIs(t, err, errUsage)
+	require.ErrorContains(t, err, "-password is required")
+
+	err = createUser(context.Background(), app, []string{
+		"-username", "alice", "-password", "secret123", "-full-name", "Alice", "-email", "alice@example.com", "-role", "admin",
+	})
+	require.ErrorIs(t, err, errUsage)
+}
+
+func TestFreezeCommand(t *testing.T) {
+	app, store, stdout := newTestCLI(t, outputJSON)
+
+	account := db.Account{ID: 7, Owner: util.RandomOwner(), Currency: util.USD, Status: db.AccountStatusFrozen}
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{ID: 7, Owner: account.Owner, Currency: util.USD, Status: db.AccountStatusActive}, nil)
+	store.EXPECT().
+		UpdateAccountStatus(gomock.Any(), gomock.Eq(db.UpdateAccountStatusParams{ID: account.ID, Status: db.AccountStatusFrozen})).
+		Times(1).
+		Return(account, nil)
+
+	err := freezeAccount(context.Background(), app, []string{"-account", "7"})
+	require.NoError(t, err)
+
+	var got db.Account
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &got))
+	require.Equal(t, account.ID, got.ID)
+	require.Equal(t, db.AccountStatusFrozen, got.Status)
+}
+
+func TestFreezeCommandNotFound(t *testing.T) {
+	app, store, _ := newTestCLI(t, outputTable)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(1).Return(db.Account{}, sql.ErrNoRows)
+	store.EXPECT().UpdateAccountStatus(gomock.Any(), gomock.Any()).Times(0)
+
+	err := freezeAccount(context.Background(), app, []string{"-account", "7"})
+	require.EqualError(t, err, "account 7 not found")
+}
+
+func TestFreezeCommandBankAccount(t *testing.T) {
+	for _, owner := range []string{db.SettlementOwner, db.InterestOwner} {
+		app, store, _ := newTestCLI(t, outputTable)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(int64(1))).Times(1).Return(db.Account{ID: 1, Owner: owner, Currency: util.USD}, nil)
+		store.EXPECT().UpdateAccountStatus(gomock.Any(), gomock.Any()).Times(0)
+
+		err := freezeAccount(context.Background(), app, []string{"-account", "1"})
+		require.ErrorIs(t, err, db.ErrSettlementAccount)
+	}
+}
+
+func TestCreateAccountCommandBankOwner(t *testing.T) {
+	app, store, _ := newTestCLI(t, outputTable)
+	store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+
+	err := createAccount(context.Background(), app, []string{"-owner", db.InterestOwner, "-currency", util.USD})
+	require.ErrorIs(t, err, errUsage)
+}
+
+func TestDepositCommand(t *testing.T) {
+	app, store, stdout := newTestCLI(t, outputTable)
+
+	account := db.Account{ID: 3, Owner: util.RandomOwner(), Balance: 150, Currency: util.EUR, Status: db.AccountStatusActive}
+	store.EXPECT().
+		DepositTx(gomock.Any(), gomock.Eq(db.CashTxParams{AccountID: account.ID, Amount: 150})).
+		Times(1).
+		Return(db.TransferTxResult{ToAccount: account}, nil)
+
+	err := deposit(context.Background(), app, []string{"-account", "3", "-amount", "150"})
+	require.NoError(t, err)
+	require.Contains(t, stdout.String(), account.Owner)
+	require.Contains(t, stdout.String(), "150")
+}
+
+func TestListTransfersCommand(t *testing.T) {
+	app, store, stdout := newTestCLI(t, outputTable)
+
+	transfers := []db.Transfer{
+		{ID: 1, FromAccountID: 3, ToAccountID: 4, Amount: 10},
+		{ID: 2, FromAccountID: 4, ToAccountID: 3, Amount: 5},
+	}
+	store.EXPECT().
+		ListTransfers(gomock.Any(), gomock.Eq(db.ListTransfersParams{FromAccountID: 3, ToAccountID: 3, Limit: 5, Offset: 0})).
+		Times(1).
+		Return(transfers, nil)
+
+	err := listTransfers(context.Background(), app, []string{"-account", "3", "-limit", "5"})
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	require.Len(t, lines, 3)
+	require.Equal(t, []string{"ID", "FROM", "TO", "AMOUNT", "CREATED", "AT"}, strings.Fields(lines[0]))
+	require.Equal(t, "1", strings.Fields(lines[1])[0])
+}
+
+func TestRotateTokenKeyCommand(t *testing.T) {
+	app, _, stdout := newTestCLI(t, outputJSON)
+	current := app.config.TokenSymmetricKey
+	app.config.TokenPreviousSymmetricKeys = []string{util.RandomString(32)}
+
+	err := rotateTokenKey(context.Background(), app, nil)
+	require.NoError(t, err)
+
+	var keys rotatedTokenKeys
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &keys))
+	require.Len(t, keys.TokenSymmetricKey, 32)
+	require.NotEqual(t, current, keys.TokenSymmetricKey)
+	// 默认只保留一个旧密钥
+	require.Equal(t, []string{current}, keys.TokenPreviousSymmetricKeys)
+
+	// 新配置可以校验轮换前签发的token
+	oldMaker, err := token.NewPasetoMaker(current)
+	require.NoError(t, err)
+	oldToken, _, err := oldMaker.CreateToken(util.RandomOwner(), token.DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	maker, err := token.NewPasetoMaker(keys.TokenSymmetricKey, keys.TokenPreviousSymmetricKeys...)
+	require.NoError(t, err)
+	_, err = maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+}
+
+func TestRunUsage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := run(context.Background(), []string{"-config", "../.."}, &stdout, &stderr)
+	require.Equal(t, 2, code)
+	require.Contains(t, stderr.String(), "commands:")
+
+	stderr.Reset()
+	code = run(context.Background(), []string{"-config", "../..", "drop-tables"}, &stdout, &stderr)
+	require.Equal(t, 2, code)
+	require.Contains(t, stderr.String(), "unknown command: drop-tables")
+
+	stderr.Reset()
+	code = run(context.Background(), []string{"-config", "../..", "-output", "yaml", "rotate-token-key"}, &stdout, &stderr)
+	require.Equal(t, 2, code)
+
+	stderr.Reset()
+	code = run(context.Background(), []string{"-config", "../..", "freeze"}, &stdout, &stderr)
+	require.Equal(t, 2, code)
+	require.Contains(t, stderr.String(), "-account must be positive")
+	require.Empty(t, stdout.String())
+}
@@ -0,0 +1,88 @@
+// simplebankctl 是运维使用的命令行管理工具，直接通过db.Store操作数据库，不经过HTTP接口
+//
+// 用法：simplebankctl [-config DIR] [-output table|json] <command> [flags]
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/util"
+)
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run 返回进程退出码：0成功，1执行失败，2参数错误
+func run(ctx context.Context, args []string, stdout io.Writer, stderr io.Writer) int {
+	flags := flag.NewFlagSet("simplebankctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", ".", "directory containing app.env")
+	output := flags.String("output", outputTable, "output format: table or json")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: simplebankctl [-config DIR] [-output table|json] <command> [flags]")
+		fmt.Fprintln(stderr, "\ncommands:")
+		for _, cmd := range commands {
+			fmt.Fprintf(stderr, "  %-18s %s\n", cmd.name, cmd.summary)
+		}
+		fmt.Fprintln(stderr, "\nflags:")
+		flags.PrintDefaults()
+	}
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 || (*output != outputTable && *output != outputJSON) {
+		flags.Usage()
+		return 2
+	}
+
+	cmd, ok := findCommand(flags.Arg(0))
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command: %s\n", flags.Arg(0))
+		flags.Usage()
+		return 2
+	}
+
+	config, err := util.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "cannot load config: %v\n", err)
+		return 1
+	}
+	// 日志写到标准错误，标准输出只有命令的结果，方便管道处理
+	slog.SetDefault(logging.New(stderr, config.LogLevel))
+
+	app := &cli{
+		config: config,
+		output: *output,
+		stdout: stdout,
+	}
+
+	if cmd.needsStore {
+		pool, err := db.OpenDB(ctx, config.DBDriver, config.DBSource, db.NewPoolConfig(config))
+		if err != nil {
+			fmt.Fprintf(stderr, "cannot connect to db: %v\n", err)
+			return 1
+		}
+		defer pool.Close()
+		app.store = db.NewStore(pool)
+	}
+
+	err = cmd.run(ctx, app, flags.Args()[1:])
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", cmd.name, err)
+		if errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+			return 2
+		}
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	db "simplebank/db/sqlc"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// table 命令结果的表格形式，json输出时使用原始的结构体
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// 按-output输出命令结果
+func (app *cli) print(value interface{}, t table) error {
+	if app.output == outputJSON {
+		encoder := json.NewEncoder(app.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	}
+	return writeTable(app.stdout, t)
+}
+
+func writeTable(w io.Writer, t table) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(t.header, "\t"))
+	for _, row := range t.rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func userTable(users ...db.User) table {
+	t := table{header: []string{"USERNAME", "FULL NAME", "EMAIL", "ROLE", "CREATED AT"}}
+	for _, user := range users {
+		t.rows = append(t.rows, []string{
+			user.Username,
+			user.FullName,
+			user.Email,
+			user.Role,
+			formatTime(user.CreatedAt),
+		})
+	}
+	return t
+}
+
+func accountTable(accounts ...db.Account) table {
+	t := table{header: []string{"ID", "OWNER", "BALANCE", "CURRENCY", "TYPE", "STATUS", "NICKNAME"}}
+	for _, account := range accounts {
+		t.rows = append(t.rows, []string{
+			fmt.Sprint(account.ID),
+			account.Owner,
+			fmt.Sprint(account.Balance),
+			account.Currency,
+			account.Type,
+			account.Status,
+			account.Nickname.String,
+		})
+	}
+	return t
+}
+
+func transferTable(transfers ...db.Transfer) table {
+	t := table{header: []string{"ID", "FROM", "TO", "AMOUNT", "CREATED AT"}}
+	for _, transfer := range transfers {
+		t.rows = append(t.rows, []string{
+			fmt.Sprint(transfer.ID),
+			fmt.Sprint(transfer.FromAccountID),
+			fmt.Sprint(transfer.ToAccountID),
+			fmt.Sprint(transfer.Amount),
+			formatTime(transfer.CreatedAt),
+		})
+	}
+	return t
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
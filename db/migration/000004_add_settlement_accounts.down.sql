@@ -0,0 +1,21 @@
+-- 清算账户一旦发生过存取款就不能回滚：删除它会破坏复式记账，账目和转账也会因外键无法删除
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM "accounts" a
+    WHERE a."owner" = 'bank_settlement'
+      AND (
+        EXISTS (SELECT 1 FROM "entries" e WHERE e."account_id" = a."id")
+        OR EXISTS (SELECT 1 FROM "transfers" t WHERE t."from_account_id" = a."id" OR t."to_account_id" = a."id")
+        OR EXISTS (SELECT 1 FROM "holds" h WHERE h."account_id" = a."id" OR h."to_account_id" = a."id")
+      )
+  ) THEN
+    RAISE EXCEPTION 'migration 4 is irreversible: settlement accounts already have entries, transfers or holds';
+  END IF;
+END $$;
+
+DELETE FROM "accounts" WHERE "owner" = 'bank_settlement';
+
+DELETE FROM "users" WHERE "username" = 'bank_settlement';
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "role";
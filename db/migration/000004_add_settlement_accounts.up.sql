@@ -0,0 +1,14 @@
+ALTER TABLE "users" ADD COLUMN "role" varchar NOT NULL DEFAULT 'depositor';
+
+-- 银行内部的清算用户，不能登录（hashed_password不是合法的bcrypt哈希）
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email", "role")
+VALUES ('bank_settlement', '!', 'Bank Settlement', 'settlement@simplebank.internal', 'banker');
+
+-- 每种币种一个清算账户，存取款都与它做复式记账，所以它的余额通常为负数
+INSERT INTO "accounts" ("owner", "balance", "currency")
+VALUES
+  ('bank_settlement', 0, 'USD'),
+  ('bank_settlement', 0, 'EUR'),
+  ('bank_settlement', 0, 'CAD');
+
+COMMENT ON COLUMN "users"."role" IS 'depositor or banker';
@@ -0,0 +1,70 @@
+CREATE TABLE "journal_transactions" (
+  "id" bigserial PRIMARY KEY,
+  "transfer_id" bigint UNIQUE,
+  "description" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "journal_transactions" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "entries" ADD COLUMN "journal_id" bigint;
+
+-- 回填：每笔历史转账生成一个凭证
+INSERT INTO "journal_transactions" ("transfer_id", "description", "created_at")
+SELECT "id", 'transfer', "created_at" FROM "transfers" ORDER BY "id";
+
+-- 同一事务内创建的转账记录和账目created_at相同，据此把账目挂到对应转账的凭证上
+UPDATE "entries" e
+SET "journal_id" = j."id"
+FROM "journal_transactions" j
+JOIN "transfers" t ON t."id" = j."transfer_id"
+WHERE e."journal_id" IS NULL
+  AND e."created_at" = t."created_at"
+  AND (
+    (e."account_id" = t."from_account_id" AND e."amount" = -t."amount") OR
+    (e."account_id" = t."to_account_id" AND e."amount" = t."amount")
+  );
+
+-- 匹配不到转账的历史账目各自生成一个凭证，由对账报告列出
+DO $$
+DECLARE
+  r record;
+  jid bigint;
+BEGIN
+  FOR r IN SELECT "id", "created_at" FROM "entries" WHERE "journal_id" IS NULL ORDER BY "id" LOOP
+    INSERT INTO "journal_transactions" ("description", "created_at")
+    VALUES ('legacy entry', r."created_at")
+    RETURNING "id" INTO jid;
+
+    UPDATE "entries" SET "journal_id" = jid WHERE "id" = r."id";
+  END LOOP;
+END $$;
+
+ALTER TABLE "entries" ALTER COLUMN "journal_id" SET NOT NULL;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("journal_id") REFERENCES "journal_transactions" ("id");
+
+CREATE INDEX ON "entries" ("journal_id");
+
+-- 同一凭证下的账目按币种求和必须为0，在事务提交时检查
+CREATE FUNCTION check_journal_balanced() RETURNS trigger AS $$
+BEGIN
+  IF EXISTS (
+    SELECT 1
+    FROM "entries" e
+    JOIN "accounts" a ON a."id" = e."account_id"
+    WHERE e."journal_id" = NEW."journal_id"
+    GROUP BY a."currency"
+    HAVING SUM(e."amount") <> 0
+  ) THEN
+    RAISE EXCEPTION 'journal transaction % is not balanced', NEW."journal_id"
+      USING ERRCODE = 'check_violation';
+  END IF;
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "entries_journal_balanced"
+AFTER INSERT OR UPDATE ON "entries"
+DEFERRABLE INITIALLY DEFERRED
+FOR EACH ROW EXECUTE FUNCTION check_journal_balanced();
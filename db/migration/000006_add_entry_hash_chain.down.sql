@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS "entries_no_truncate" ON "entries";
+
+DROP TRIGGER IF EXISTS "entries_append_only" ON "entries";
+
+DROP FUNCTION IF EXISTS forbid_entry_mutation();
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "hash";
+
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "prev_hash";
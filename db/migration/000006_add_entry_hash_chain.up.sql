@@ -0,0 +1,58 @@
+ALTER TABLE "entries" ADD COLUMN "prev_hash" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "entries" ADD COLUMN "hash" varchar NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN "entries"."hash" IS 'sha256(prev_hash|amount|account_id|created_at unix micros|id)';
+
+-- 回填历史账目的哈希链，计算方式必须与db.EntryHash一致
+ALTER TABLE "entries" DISABLE TRIGGER "entries_journal_balanced";
+
+DO $$
+DECLARE
+  r record;
+  prev varchar;
+  cur_account bigint;
+  h varchar;
+BEGIN
+  FOR r IN
+    SELECT
+      "id",
+      "account_id",
+      "amount",
+      EXTRACT(EPOCH FROM date_trunc('second', "created_at"))::bigint * 1000000
+        + EXTRACT(MICROSECONDS FROM "created_at")::bigint % 1000000 AS created_micros
+    FROM "entries"
+    ORDER BY "account_id", "id"
+  LOOP
+    IF cur_account IS DISTINCT FROM r."account_id" THEN
+      prev := '';
+      cur_account := r."account_id";
+    END IF;
+
+    h := encode(sha256(convert_to(
+      prev || '|' || r."amount" || '|' || r."account_id" || '|' || r.created_micros || '|' || r."id",
+      'UTF8'
+    )), 'hex');
+
+    UPDATE "entries" SET "prev_hash" = prev, "hash" = h WHERE "id" = r."id";
+    prev := h;
+  END LOOP;
+END $$;
+
+ALTER TABLE "entries" ENABLE TRIGGER "entries_journal_balanced";
+
+-- 账目只能追加，不能修改或删除
+CREATE FUNCTION forbid_entry_mutation() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'entries are append-only'
+    USING ERRCODE = 'insufficient_privilege';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "entries_append_only"
+BEFORE UPDATE OR DELETE ON "entries"
+FOR EACH ROW EXECUTE FUNCTION forbid_entry_mutation();
+
+CREATE TRIGGER "entries_no_truncate"
+BEFORE TRUNCATE ON "entries"
+FOR EACH STATEMENT EXECUTE FUNCTION forbid_entry_mutation();
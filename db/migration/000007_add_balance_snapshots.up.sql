@@ -0,0 +1,13 @@
+CREATE TABLE "balance_snapshots" (
+  "account_id" bigint NOT NULL,
+  "snapshot_date" date NOT NULL,
+  "balance" bigint NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "snapshot_date")
+);
+
+ALTER TABLE "balance_snapshots" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+CREATE INDEX ON "entries" ("account_id", "created_at");
+
+COMMENT ON COLUMN "balance_snapshots"."balance" IS 'sum of entries created before the end of snapshot_date (UTC)';
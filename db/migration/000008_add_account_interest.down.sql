@@ -0,0 +1,27 @@
+DROP INDEX IF EXISTS "accounts_type_id_idx";
+
+-- 利息支出账户一旦结过息就不能回滚，原因同清算账户
+DO $$
+BEGIN
+  IF EXISTS (
+    SELECT 1 FROM "accounts" a
+    WHERE a."owner" = 'bank_interest'
+      AND (
+        EXISTS (SELECT 1 FROM "entries" e WHERE e."account_id" = a."id")
+        OR EXISTS (SELECT 1 FROM "transfers" t WHERE t."from_account_id" = a."id" OR t."to_account_id" = a."id")
+        OR EXISTS (SELECT 1 FROM "holds" h WHERE h."account_id" = a."id" OR h."to_account_id" = a."id")
+        OR EXISTS (SELECT 1 FROM "balance_snapshots" s WHERE s."account_id" = a."id")
+      )
+  ) THEN
+    RAISE EXCEPTION 'migration 8 is irreversible: interest expense accounts already have entries, transfers, holds or snapshots';
+  END IF;
+END $$;
+
+DELETE FROM "accounts" WHERE "owner" = 'bank_interest';
+
+DELETE FROM "users" WHERE "username" = 'bank_interest';
+
+ALTER TABLE IF EXISTS "accounts" DROP CONSTRAINT IF EXISTS "accounts_type_check";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "interest_accrued_on";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "accrued_interest";
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "type";
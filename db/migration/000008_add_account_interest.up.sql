@@ -0,0 +1,20 @@
+ALTER TABLE "accounts" ADD COLUMN "type" varchar NOT NULL DEFAULT 'checking';
+ALTER TABLE "accounts" ADD COLUMN "accrued_interest" bigint NOT NULL DEFAULT 0;
+ALTER TABLE "accounts" ADD COLUMN "interest_accrued_on" date;
+
+ALTER TABLE "accounts" ADD CONSTRAINT "accounts_type_check" CHECK ("type" IN ('checking', 'savings'));
+
+-- 银行利息支出用户，每种币种一个利息支出账户，结息时从它转出利息
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email", "role")
+VALUES ('bank_interest', '!', 'Bank Interest Expense', 'interest@simplebank.internal', 'banker');
+
+INSERT INTO "accounts" ("owner", "balance", "currency")
+VALUES
+  ('bank_interest', 0, 'USD'),
+  ('bank_interest', 0, 'EUR'),
+  ('bank_interest', 0, 'CAD');
+
+CREATE INDEX ON "accounts" ("type", "id");
+
+COMMENT ON COLUMN "accounts"."accrued_interest" IS 'interest accrued in minor units but not yet capitalized';
+COMMENT ON COLUMN "accounts"."interest_accrued_on" IS 'last day (UTC) that interest was accrued for';
@@ -0,0 +1,26 @@
+CREATE TABLE "account_holders" (
+  "account_id" bigint NOT NULL,
+  "username" varchar NOT NULL,
+  "permission" varchar NOT NULL,
+  "status" varchar NOT NULL DEFAULT 'invited',
+  "invited_by" varchar NOT NULL,
+  "accepted_at" timestamptz,
+  "created_at" timestamptz NOT NULL DEFAULT (now()),
+  PRIMARY KEY ("account_id", "username")
+);
+
+ALTER TABLE "account_holders" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "account_holders" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+ALTER TABLE "account_holders" ADD FOREIGN KEY ("invited_by") REFERENCES "users" ("username");
+
+ALTER TABLE "account_holders" ADD CONSTRAINT "account_holders_permission_check" CHECK ("permission" IN ('view', 'transfer'));
+
+ALTER TABLE "account_holders" ADD CONSTRAINT "account_holders_status_check" CHECK ("status" IN ('invited', 'accepted'));
+
+CREATE INDEX ON "account_holders" ("username", "status");
+
+COMMENT ON COLUMN "account_holders"."permission" IS 'view or transfer, transfer implies view';
+
+COMMENT ON COLUMN "account_holders"."status" IS 'invited or accepted, only accepted holders can access the account';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "accounts_owner_nickname_key";
+
+ALTER TABLE IF EXISTS "accounts" DROP COLUMN IF EXISTS "nickname";
+
+ALTER TABLE "accounts" ADD CONSTRAINT "owner_currency_key" UNIQUE ("owner", "currency");
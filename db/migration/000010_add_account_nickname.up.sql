@@ -0,0 +1,8 @@
+-- 允许同一用户有多个相同币种的账户，用昵称区分
+ALTER TABLE "accounts" DROP CONSTRAINT IF EXISTS "owner_currency_key";
+
+ALTER TABLE "accounts" ADD COLUMN "nickname" varchar;
+
+CREATE UNIQUE INDEX "accounts_owner_nickname_key" ON "accounts" ("owner", "nickname") WHERE "nickname" IS NOT NULL;
+
+COMMENT ON COLUMN "accounts"."nickname" IS 'optional, unique per owner';
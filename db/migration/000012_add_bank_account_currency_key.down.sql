@@ -0,0 +1 @@
+DROP INDEX IF EXISTS "accounts_bank_owner_currency_key";
@@ -0,0 +1,4 @@
+-- 000010去掉了owner_currency_key，但存取款、结息按(owner, currency)查找银行内部账户，
+-- 银行内部用户每种币种只能有一个账户
+CREATE UNIQUE INDEX "accounts_bank_owner_currency_key" ON "accounts" ("owner", "currency")
+WHERE "owner" IN ('bank_settlement', 'bank_interest');
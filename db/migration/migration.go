@@ -0,0 +1,213 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// 迁移文件编译进二进制，部署时不需要额外拷贝db/migration目录
+//
+//go:embed *.sql
+var files embed.FS
+
+// 多个副本同时启动时只有一个执行迁移，其他副本等它完成后发现没有待执行的迁移
+// 与golang-migrate内部使用的锁互不影响
+const lockKey int64 = 5_826_437_109_316
+
+// Status 当前数据库的迁移状态
+type Status struct {
+	Version uint   `json:"version"`
+	Dirty   bool   `json:"dirty"`
+	Latest  uint   `json:"latest"`
+	Pending []uint `json:"pending"`
+}
+
+type Migrator struct {
+	db *sql.DB
+	// 为空时使用内嵌的迁移文件，否则为golang-migrate的source URL，例如file://db/migration
+	sourceURL string
+}
+
+func NewMigrator(db *sql.DB, sourceURL string) *Migrator {
+	return &Migrator{
+		db:        db,
+		sourceURL: sourceURL,
+	}
+}
+
+// Up 执行所有待执行的迁移，已是最新版本时返回nil
+func (migrator *Migrator) Up(ctx context.Context) error {
+	return migrator.withLock(ctx, func(instance *migrate.Migrate) error {
+		err := instance.Up()
+		if errors.Is(err, migrate.ErrNoChange) {
+			return nil
+		}
+		return err
+	})
+}
+
+// Down 回滚steps个迁移
+func (migrator *Migrator) Down(ctx context.Context, steps int) error {
+	if steps <= 0 {
+		return fmt.Errorf("invalid steps: %d", steps)
+	}
+
+	return migrator.withLock(ctx, func(instance *migrate.Migrate) error {
+		return instance.Steps(-steps)
+	})
+}
+
+func (migrator *Migrator) Status(ctx context.Context) (Status, error) {
+	var status Status
+
+	conn, err := migrator.db.Conn(ctx)
+	if err != nil {
+		return status, err
+	}
+
+	sourceDriver, err := migrator.openSource()
+	if err != nil {
+		conn.Close()
+		return status, err
+	}
+
+	instance, err := newMigrate(ctx, conn, sourceDriver)
+	if err != nil {
+		return status, err
+	}
+	defer instance.Close()
+
+	version, dirty, err := instance.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, err
+	}
+	status.Version = version
+	status.Dirty = dirty
+
+	versions, err := sourceVersions(sourceDriver)
+	if err != nil {
+		return status, err
+	}
+
+	status.Pending = []uint{}
+	for _, v := range versions {
+		if v > version {
+			status.Pending = append(status.Pending, v)
+		}
+		status.Latest = v
+	}
+
+	return status, nil
+}
+
+// LatestVersion 内嵌迁移文件中最新的版本号
+func LatestVersion() (uint, error) {
+	sourceDriver, err := iofs.New(files, ".")
+	if err != nil {
+		return 0, err
+	}
+	defer sourceDriver.Close()
+
+	versions, err := sourceVersions(sourceDriver)
+	if err != nil {
+		return 0, err
+	}
+	if len(versions) == 0 {
+		return 0, errors.New("no migrations found")
+	}
+	return versions[len(versions)-1], nil
+}
+
+// 在持有advisory lock的连接之外，用另一个连接执行迁移
+// 锁是会话级别的，必须在连接归还连接池之前释放
+func (migrator *Migrator) withLock(ctx context.Context, fn func(instance *migrate.Migrate) error) error {
+	lockConn, err := migrator.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer lockConn.Close()
+
+	_, err = lockConn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", lockKey)
+	if err != nil {
+		return fmt.Errorf("cannot acquire migration lock: %w", err)
+	}
+	defer lockConn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+
+	conn, err := migrator.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	sourceDriver, err := migrator.openSource()
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	instance, err := newMigrate(ctx, conn, sourceDriver)
+	if err != nil {
+		return err
+	}
+	// 关闭迁移源和迁移使用的连接，不会关闭连接池
+	defer instance.Close()
+
+	return fn(instance)
+}
+
+func (migrator *Migrator) openSource() (source.Driver, error) {
+	if migrator.sourceURL == "" {
+		return iofs.New(files, ".")
+	}
+	return source.Open(migrator.sourceURL)
+}
+
+// newMigrate 接管conn和sourceDriver，出错时负责关闭
+func newMigrate(ctx context.Context, conn *sql.Conn, sourceDriver source.Driver) (*migrate.Migrate, error) {
+	databaseDriver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+	if err != nil {
+		conn.Close()
+		sourceDriver.Close()
+		return nil, fmt.Errorf("cannot create migration driver: %w", err)
+	}
+
+	instance, err := migrate.NewWithInstance("source", sourceDriver, "postgres", databaseDriver)
+	if err != nil {
+		databaseDriver.Close()
+		sourceDriver.Close()
+		return nil, fmt.Errorf("cannot create migrator: %w", err)
+	}
+	return instance, nil
+}
+
+// 按顺序列出迁移源中的所有版本
+func sourceVersions(sourceDriver source.Driver) ([]uint, error) {
+	version, err := sourceDriver.First()
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	versions := []uint{version}
+	for {
+		version, err = sourceDriver.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return versions, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		versions = append(versions, version)
+	}
+}
@@ -0,0 +1,80 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"simplebank/util"
+	"strings"
+	"sync"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/stretchr/testify/require"
+)
+
+// 每个版本都要同时有up和down文件
+func TestEmbeddedMigrations(t *testing.T) {
+	names, err := fs.Glob(files, "*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, names)
+
+	for _, name := range names {
+		var pair string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			pair = strings.TrimSuffix(name, ".up.sql") + ".down.sql"
+		case strings.HasSuffix(name, ".down.sql"):
+			pair = strings.TrimSuffix(name, ".down.sql") + ".up.sql"
+		default:
+			t.Fatalf("unexpected migration file: %s", name)
+		}
+
+		_, err := fs.Stat(files, pair)
+		require.NoError(t, err, "missing %s", pair)
+	}
+
+	latest, err := LatestVersion()
+	require.NoError(t, err)
+	require.Equal(t, uint(len(names)/2), latest)
+}
+
+func TestDownInvalidSteps(t *testing.T) {
+	err := NewMigrator(nil, "").Down(context.Background(), 0)
+	require.EqualError(t, err, "invalid steps: 0")
+}
+
+// 多个副本同时启动时并发执行迁移，都应该成功
+func TestUpConcurrent(t *testing.T) {
+	config, err := util.LoadConfig("../..")
+	require.NoError(t, err)
+
+	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	migrator := NewMigrator(conn, "")
+
+	n := 3
+	errs := make(chan error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- migrator.Up(context.Background())
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	status, err := migrator.Status(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, status.Latest, status.Version)
+	require.False(t, status.Dirty)
+	require.Empty(t, status.Pending)
+}
@@ -0,0 +1,24 @@
+package mockdb
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+
+	"github.com/golang/mock/gomock"
+)
+
+type primaryContextMatcher struct{}
+
+func (primaryContextMatcher) Matches(x interface{}) bool {
+	ctx, ok := x.(context.Context)
+	return ok && db.IsPrimary(ctx)
+}
+
+func (primaryContextMatcher) String() string {
+	return "is a context marked by db.WithPrimary"
+}
+
+// PrimaryContext 匹配要求读主库的ctx，用于校验查询没有走从库
+func PrimaryContext() gomock.Matcher {
+	return primaryContextMatcher{}
+}
@@ -2,26 +2,33 @@
 INSERT INTO accounts (
   owner,
   balance,
-  currency
+  currency,
+  type,
+  nickname
 ) VALUES (
-  $1, $2, $3
+  $1, $2, $3, $4, $5
 )RETURNING *;
 
 -- name: GetAccount :one
 SELECT * FROM accounts
 WHERE id = $1 LIMIT 1;
 
+-- 自己的账户和已接受邀请的联名账户
 -- name: ListAccounts :many
 SELECT * FROM accounts
 WHERE accounts.owner = $1
+  OR accounts.id IN (
+    SELECT account_id FROM account_holders
+    WHERE username = $1 AND status = 'accepted'
+  )
 ORDER BY id
 LIMIT $2
 OFFSET $3;
 
--- name: UpdateAccount :one
-UPDATE accounts 
-SET balance = $2
-WHERE id = $1
+-- name: UpdateAccountNickname :one
+UPDATE accounts
+SET nickname = sqlc.narg(nickname)
+WHERE id = sqlc.arg(id)
 RETURNING *;
 
 -- name: DeleteAccount :exec
@@ -33,12 +40,50 @@ SELECT * FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE;
 
--- name: AddAccountBalance :one
+-- name: CountAccounts :one
+SELECT COUNT(*) 
+FROM accounts;
+
+-- name: GetAccountByOwnerAndCurrency :one
+SELECT * FROM accounts
+WHERE owner = $1 AND currency = $2
+ORDER BY id
+LIMIT 1;
+
+-- 需要计息的账户：某个类型下当天还未计息的账户，按id分页
+-- name: ListAccountsForAccrual :many
+SELECT * FROM accounts
+WHERE type = sqlc.arg(type)
+  AND (interest_accrued_on IS NULL OR interest_accrued_on < sqlc.arg(day)::date)
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
+
+-- 计息，每个账户每天只计一次
+-- name: AccrueInterest :execrows
 UPDATE accounts
-SET balance = balance + sqlc.arg(amount)
+SET accrued_interest = accrued_interest + sqlc.arg(amount),
+  interest_accrued_on = sqlc.arg(day)::date
+WHERE id = sqlc.arg(id)
+  AND (interest_accrued_on IS NULL OR interest_accrued_on < sqlc.arg(day)::date);
+
+-- name: ListAccountsWithAccruedInterest :many
+SELECT * FROM accounts
+WHERE accrued_interest > 0
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
+
+-- 结息后扣减已入账的应计利息
+-- name: SubtractAccruedInterest :one
+UPDATE accounts
+SET accrued_interest = accrued_interest - sqlc.arg(amount)
 WHERE id = sqlc.arg(id)
 RETURNING *;
 
--- name: CountAccounts :one
-SELECT COUNT(*) 
-FROM accounts;
\ No newline at end of file
+-- 冻结/解冻账户
+-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = sqlc.arg(status)
+WHERE id = sqlc.arg(id)
+RETURNING *;
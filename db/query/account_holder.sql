@@ -0,0 +1,26 @@
+-- name: CreateAccountHolder :one
+INSERT INTO account_holders (
+  account_id,
+  username,
+  permission,
+  invited_by
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetAccountHolder :one
+SELECT * FROM account_holders
+WHERE account_id = $1 AND username = $2
+LIMIT 1;
+
+-- name: AcceptAccountHolder :one
+UPDATE account_holders
+SET status = 'accepted',
+    accepted_at = now()
+WHERE account_id = $1 AND username = $2 AND status = 'invited'
+RETURNING *;
+
+-- name: ListAccountHolders :many
+SELECT * FROM account_holders
+WHERE account_id = $1
+ORDER BY created_at, username;
@@ -1,10 +1,20 @@
--- name: CreateEntry :one
-INSERT INTO entries (
-  account_id,
-  amount
-) VALUES (
-  $1, $2
-) RETURNING *;
+-- name: NextEntryID :one
+SELECT
+  nextval('entries_id_seq')::bigint AS id,
+  now()::timestamptz AS created_at;
+
+-- name: GetLastEntryHash :one
+SELECT hash FROM entries
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT 1;
+
+-- name: ListAccountEntriesAfter :many
+SELECT * FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND id > sqlc.arg(after_id)
+ORDER BY id
+LIMIT sqlc.arg(batch_size);
 
 -- name: GetEntry :one
 SELECT * FROM entries
@@ -16,3 +26,46 @@ WHERE account_id = $1
 ORDER BY id
 LIMIT $2
 OFFSET $3;
+
+-- name: ListAccountEntrySums :many
+SELECT
+  a.id AS account_id,
+  a.balance,
+  COALESCE(SUM(e.amount), 0)::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+WHERE a.id > sqlc.arg(after_id)
+GROUP BY a.id
+ORDER BY a.id
+LIMIT sqlc.arg(batch_size);
+
+-- 凭证没有对应转账的账目即为孤儿账目（迁移时无法匹配到转账的历史账目）
+-- name: ListOrphanEntries :many
+SELECT e.* FROM entries e
+JOIN journal_transactions j ON j.id = e.journal_id
+WHERE e.id > sqlc.arg(after_id)
+  AND j.transfer_id IS NULL
+ORDER BY e.id
+LIMIT sqlc.arg(batch_size);
+
+-- 对账单明细：账目及其对应转账的对手方
+-- name: ListStatementEntries :many
+SELECT
+  e.id,
+  e.account_id,
+  e.amount,
+  e.created_at,
+  j.description,
+  t.id AS transfer_id,
+  c.id AS counterparty_account_id,
+  c.owner AS counterparty_owner
+FROM entries e
+JOIN journal_transactions j ON j.id = e.journal_id
+LEFT JOIN transfers t ON t.id = j.transfer_id
+LEFT JOIN accounts c ON c.id = (
+  CASE WHEN t.from_account_id = e.account_id THEN t.to_account_id ELSE t.from_account_id END
+)
+WHERE e.account_id = sqlc.arg(account_id)
+  AND e.created_at >= sqlc.arg(from_time)::timestamptz
+  AND e.created_at < sqlc.arg(to_time)::timestamptz
+ORDER BY e.id;
@@ -0,0 +1,48 @@
+-- name: CreateHold :one
+INSERT INTO holds (
+  account_id,
+  to_account_id,
+  amount,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetHold :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1;
+
+-- name: GetHoldForUpdate :one
+SELECT * FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: GetActiveHoldsAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS amount
+FROM holds
+WHERE account_id = $1
+  AND status = 'active'
+  AND expires_at > now();
+
+-- 过期按数据库时间判断，与GetActiveHoldsAmount一致；已过期或不是有效状态时不更新任何行
+-- name: CaptureHold :one
+UPDATE holds
+SET status = 'captured',
+    captured_amount = sqlc.arg(captured_amount),
+    transfer_id = sqlc.arg(transfer_id)
+WHERE id = sqlc.arg(id)
+  AND status = 'active'
+  AND expires_at > now()
+RETURNING *;
+
+-- name: ReleaseHold :one
+UPDATE holds
+SET status = 'released'
+WHERE id = $1 AND status = 'active'
+RETURNING *;
+
+-- name: ReleaseExpiredHolds :many
+UPDATE holds
+SET status = 'expired'
+WHERE status = 'active' AND expires_at <= now()
+RETURNING *;
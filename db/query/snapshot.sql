@@ -0,0 +1,57 @@
+-- 在上一份快照的基础上累加当天的账目，生成每个账户的日终余额快照，重复执行不会覆盖已有快照。
+-- 账户的第一份快照从期初余额（余额减去全部账目之和，见GetOpeningBalance）开始累加
+-- name: CreateDailyBalanceSnapshots :execrows
+INSERT INTO balance_snapshots (account_id, snapshot_date, balance)
+SELECT
+  a.id,
+  sqlc.arg(snapshot_date)::date,
+  COALESCE(prev.balance, a.balance - (
+    SELECT COALESCE(SUM(all_entries.amount), 0)::bigint
+    FROM entries all_entries
+    WHERE all_entries.account_id = a.id
+  )) + COALESCE(SUM(e.amount), 0)::bigint
+FROM accounts a
+LEFT JOIN LATERAL (
+  SELECT s.balance, s.snapshot_date
+  FROM balance_snapshots s
+  WHERE s.account_id = a.id
+    AND s.snapshot_date < sqlc.arg(snapshot_date)::date
+  ORDER BY s.snapshot_date DESC
+  LIMIT 1
+) prev ON true
+LEFT JOIN entries e ON e.account_id = a.id
+  AND e.created_at < sqlc.arg(cutoff)::timestamptz
+  AND (
+    prev.snapshot_date IS NULL OR
+    e.created_at >= (prev.snapshot_date + 1)::timestamp AT TIME ZONE 'UTC'
+  )
+WHERE a.created_at < sqlc.arg(cutoff)::timestamptz
+GROUP BY a.id, a.balance, prev.balance
+ON CONFLICT (account_id, snapshot_date) DO NOTHING;
+
+-- name: GetLatestBalanceSnapshot :one
+SELECT * FROM balance_snapshots
+WHERE account_id = sqlc.arg(account_id)
+  AND snapshot_date < sqlc.arg(before_date)::date
+ORDER BY snapshot_date DESC
+LIMIT 1;
+
+-- name: SumEntriesBetween :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS amount
+FROM entries
+WHERE account_id = sqlc.arg(account_id)
+  AND created_at >= sqlc.arg(from_time)::timestamptz
+  AND created_at < sqlc.arg(to_time)::timestamptz;
+
+-- 期初余额：没有对应账目的那部分余额，例如开户时的初始余额和引入账目之前的历史余额，从开户时起计入。
+-- 余额的每次变动都会同时写入账目，所以用当前余额减去全部账目之和
+-- name: GetOpeningBalance :one
+SELECT
+  (a.balance - (
+    SELECT COALESCE(SUM(e.amount), 0)::bigint
+    FROM entries e
+    WHERE e.account_id = a.id
+  ))::bigint AS opening_balance,
+  a.created_at
+FROM accounts a
+WHERE a.id = $1;
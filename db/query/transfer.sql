@@ -19,3 +19,24 @@ WHERE
 ORDER BY id
 LIMIT $3
 OFFSET $4;
+
+-- 每笔转账必须恰好对应出钱方和收钱方两条账目
+-- name: ListUnbalancedTransfers :many
+SELECT
+  t.id,
+  t.from_account_id,
+  t.to_account_id,
+  t.amount,
+  COUNT(e.id) AS entry_count
+FROM transfers t
+LEFT JOIN journal_transactions j ON j.transfer_id = t.id
+LEFT JOIN entries e ON e.journal_id = j.id
+  AND (
+    (e.account_id = t.from_account_id AND e.amount = -t.amount) OR
+    (e.account_id = t.to_account_id AND e.amount = t.amount)
+  )
+WHERE t.id > sqlc.arg(after_id)
+GROUP BY t.id
+HAVING COUNT(e.id) <> 2
+ORDER BY t.id
+LIMIT sqlc.arg(batch_size);
@@ -1,13 +1,21 @@
+-- role为NULL时使用默认的depositor
 -- name: CreateUser :one
 INSERT INTO users (
   username,
   hashed_password,
   full_name,
-  email
+  email,
+  role
 ) VALUES (
-  $1, $2, $3, $4
+  sqlc.arg(username), sqlc.arg(hashed_password), sqlc.arg(full_name), sqlc.arg(email), COALESCE(sqlc.narg(role)::varchar, 'depositor')
 )RETURNING *;
 
 -- name: GetUser :one
 SELECT * FROM users
-WHERE username = $1 LIMIT 1;
\ No newline at end of file
+WHERE username = $1 LIMIT 1;
+
+-- name: UpdateUserRole :one
+UPDATE users
+SET role = sqlc.arg(role)
+WHERE username = sqlc.arg(username)
+RETURNING *;
@@ -7,31 +7,31 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
-const addAccountBalance = `-- name: AddAccountBalance :one
+const accrueInterest = `-- name: AccrueInterest :execrows
 UPDATE accounts
-SET balance = balance + $1
-WHERE id = $2
-RETURNING id, owner, balance, currency, created_at
+SET accrued_interest = accrued_interest + $1,
+  interest_accrued_on = $2::date
+WHERE id = $3
+  AND (interest_accrued_on IS NULL OR interest_accrued_on < $2::date)
 `
 
-type AddAccountBalanceParams struct {
-	Amount int64 `json:"amount"`
-	ID     int64 `json:"id"`
+type AccrueInterestParams struct {
+	Amount int64     `json:"amount"`
+	Day    time.Time `json:"day"`
+	ID     int64     `json:"id"`
 }
 
-func (q *Queries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, addAccountBalance, arg.Amount, arg.ID)
-	var i Account
-	err := row.Scan(
-		&i.ID,
-		&i.Owner,
-		&i.Balance,
-		&i.Currency,
-		&i.CreatedAt,
-	)
-	return i, err
+// 计息，每个账户每天只计一次
+func (q *Queries) AccrueInterest(ctx context.Context, arg AccrueInterestParams) (int64, error) {
+	result, err := q.db.Exec(ctx, accrueInterest, arg.Amount, arg.Day, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
 
 const countAccounts = `-- name: CountAccounts :one
@@ -40,7 +40,7 @@ FROM accounts
 `
 
 func (q *Queries) CountAccounts(ctx context.Context) (int64, error) {
-	row := q.db.QueryRowContext(ctx, countAccounts)
+	row := q.db.QueryRow(ctx, countAccounts)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -50,20 +50,30 @@ const createAccount = `-- name: CreateAccount :one
 INSERT INTO accounts (
   owner,
   balance,
-  currency
+  currency,
+  type,
+  nickname
 ) VALUES (
-  $1, $2, $3
-)RETURNING id, owner, balance, currency, created_at
+  $1, $2, $3, $4, $5
+)RETURNING id, owner, balance, currency, created_at, type, accrued_interest, interest_accrued_on, nickname, status
 `
 
 type CreateAccountParams struct {
-	Owner    string `json:"owner"`
-	Balance  int64  `json:"balance"`
-	Currency string `json:"currency"`
+	Owner    string         `json:"owner"`
+	Balance  int64          `json:"balance"`
+	Currency string         `json:"currency"`
+	Type     string         `json:"type"`
+	Nickname sql.NullString `json:"nickname"`
 }
 
 func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, createAccount, arg.Owner, arg.Balance, arg.Currency)
+	row := q.db.QueryRow(ctx, createAccount,
+		arg.Owner,
+		arg.Balance,
+		arg.Currency,
+		arg.Type,
+		arg.Nickname,
+	)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -71,6 +81,11 @@ func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Type,
+		&i.AccruedInterest,
+		&i.InterestAccruedOn,
+		&i.Nickname,
+		&i.Status,
 	)
 	return i, err
 }
@@ -81,17 +96,47 @@ WHERE id = $1
 `
 
 func (q *Queries) DeleteAccount(ctx context.Context, id int64) error {
-	_, err := q.db.ExecContext(ctx, deleteAccount, id)
+	_, err := q.db.Exec(ctx, deleteAccount, id)
 	return err
 }
 
 const getAccount = `-- name: GetAccount :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, type, accrued_interest, interest_accrued_on, nickname, status FROM accounts
 WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccount, id)
+	row := q.db.QueryRow(ctx, getAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Type,
+		&i.AccruedInterest,
+		&i.InterestAccruedOn,
+		&i.Nickname,
+		&i.Status,
+	)
+	return i, err
+}
+
+const getAccountByOwnerAndCurrency = `-- name: GetAccountByOwnerAndCurrency :one
+SELECT id, owner, balance, currency, created_at, type, accrued_interest, interest_accrued_on, nickname, status FROM accounts
+WHERE owner = $1 AND currency = $2
+ORDER BY id
+LIMIT 1
+`
+
+type GetAccountByOwnerAndCurrencyParams struct {
+	Owner    string `json:"owner"`
+	Currency string `json:"currency"`
+}
+
+func (q *Queries) GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error) {
+	row := q.db.QueryRow(ctx, getAccountByOwnerAndCurrency, arg.Owner, arg.Currency)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -99,18 +144,23 @@ func (q *Queries) GetAccount(ctx context.Context, id int64) (Account, error) {
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Type,
+		&i.AccruedInterest,
+		&i.InterestAccruedOn,
+		&i.Nickname,
+		&i.Status,
 	)
 	return i, err
 }
 
 const getAccountForUpdate = `-- name: GetAccountForUpdate :one
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, type, accrued_interest, interest_accrued_on, nickname, status FROM accounts
 WHERE id = $1 LIMIT 1
 FOR NO KEY UPDATE
 `
 
 func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, error) {
-	row := q.db.QueryRowContext(ctx, getAccountForUpdate, id)
+	row := q.db.QueryRow(ctx, getAccountForUpdate, id)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -118,13 +168,22 @@ func (q *Queries) GetAccountForUpdate(ctx context.Context, id int64) (Account, e
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Type,
+		&i.AccruedInterest,
+		&i.InterestAccruedOn,
+		&i.Nickname,
+		&i.Status,
 	)
 	return i, err
 }
 
 const listAccounts = `-- name: ListAccounts :many
-SELECT id, owner, balance, currency, created_at FROM accounts
+SELECT id, owner, balance, currency, created_at, type, accrued_interest, interest_accrued_on, nickname, status FROM accounts
 WHERE accounts.owner = $1
+  OR accounts.id IN (
+    SELECT account_id FROM account_holders
+    WHERE username = $1 AND status = 'accepted'
+  )
 ORDER BY id
 LIMIT $2
 OFFSET $3
@@ -136,8 +195,9 @@ type ListAccountsParams struct {
 	Offset int32  `json:"offset"`
 }
 
+// 自己的账户和已接受邀请的联名账户
 func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
-	rows, err := q.db.QueryContext(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listAccounts, arg.Owner, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -151,34 +211,195 @@ func (q *Queries) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]A
 			&i.Balance,
 			&i.Currency,
 			&i.CreatedAt,
+			&i.Type,
+			&i.AccruedInterest,
+			&i.InterestAccruedOn,
+			&i.Nickname,
+			&i.Status,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	return items, nil
+}
+
+const listAccountsForAccrual = `-- name: ListAccountsForAccrual :many
+SELECT id, owner, balance, currency, created_at, type, accrued_interest, interest_accrued_on, nickname, status FROM accounts
+WHERE type = $1
+  AND (interest_accrued_on IS NULL OR interest_accrued_on < $2::date)
+  AND id > $3
+ORDER BY id
+LIMIT $4
+`
+
+type ListAccountsForAccrualParams struct {
+	Type      string    `json:"type"`
+	Day       time.Time `json:"day"`
+	AfterID   int64     `json:"after_id"`
+	BatchSize int32     `json:"batch_size"`
+}
+
+// 需要计息的账户：某个类型下当天还未计息的账户，按id分页
+func (q *Queries) ListAccountsForAccrual(ctx context.Context, arg ListAccountsForAccrualParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsForAccrual,
+		arg.Type,
+		arg.Day,
+		arg.AfterID,
+		arg.BatchSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Type,
+			&i.AccruedInterest,
+			&i.InterestAccruedOn,
+			&i.Nickname,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 	return items, nil
 }
 
-const updateAccount = `-- name: UpdateAccount :one
-UPDATE accounts 
-SET balance = $2
-WHERE id = $1
-RETURNING id, owner, balance, currency, created_at
+const listAccountsWithAccruedInterest = `-- name: ListAccountsWithAccruedInterest :many
+SELECT id, owner, balance, currency, created_at, type, accrued_interest, interest_accrued_on, nickname, status FROM accounts
+WHERE accrued_interest > 0
+  AND id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListAccountsWithAccruedInterestParams struct {
+	AfterID   int64 `json:"after_id"`
+	BatchSize int32 `json:"batch_size"`
+}
+
+func (q *Queries) ListAccountsWithAccruedInterest(ctx context.Context, arg ListAccountsWithAccruedInterestParams) ([]Account, error) {
+	rows, err := q.db.Query(ctx, listAccountsWithAccruedInterest, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.Owner,
+			&i.Balance,
+			&i.Currency,
+			&i.CreatedAt,
+			&i.Type,
+			&i.AccruedInterest,
+			&i.InterestAccruedOn,
+			&i.Nickname,
+			&i.Status,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const subtractAccruedInterest = `-- name: SubtractAccruedInterest :one
+UPDATE accounts
+SET accrued_interest = accrued_interest - $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, type, accrued_interest, interest_accrued_on, nickname, status
+`
+
+type SubtractAccruedInterestParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+// 结息后扣减已入账的应计利息
+func (q *Queries) SubtractAccruedInterest(ctx context.Context, arg SubtractAccruedInterestParams) (Account, error) {
+	row := q.db.QueryRow(ctx, subtractAccruedInterest, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Type,
+		&i.AccruedInterest,
+		&i.InterestAccruedOn,
+		&i.Nickname,
+		&i.Status,
+	)
+	return i, err
+}
+
+const updateAccountNickname = `-- name: UpdateAccountNickname :one
+UPDATE accounts
+SET nickname = $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, type, accrued_interest, interest_accrued_on, nickname, status
+`
+
+type UpdateAccountNicknameParams struct {
+	Nickname sql.NullString `json:"nickname"`
+	ID       int64          `json:"id"`
+}
+
+func (q *Queries) UpdateAccountNickname(ctx context.Context, arg UpdateAccountNicknameParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountNickname, arg.Nickname, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Type,
+		&i.AccruedInterest,
+		&i.InterestAccruedOn,
+		&i.Nickname,
+		&i.Status,
+	)
+	return i, err
+}
+
+const updateAccountStatus = `-- name: UpdateAccountStatus :one
+UPDATE accounts
+SET status = $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, type, accrued_interest, interest_accrued_on, nickname, status
 `
 
-type UpdateAccountParams struct {
-	ID      int64 `json:"id"`
-	Balance int64 `json:"balance"`
+type UpdateAccountStatusParams struct {
+	Status string `json:"status"`
+	ID     int64  `json:"id"`
 }
 
-func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
-	row := q.db.QueryRowContext(ctx, updateAccount, arg.ID, arg.Balance)
+// 冻结/解冻账户
+func (q *Queries) UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error) {
+	row := q.db.QueryRow(ctx, updateAccountStatus, arg.Status, arg.ID)
 	var i Account
 	err := row.Scan(
 		&i.ID,
@@ -186,6 +407,11 @@ func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (A
 		&i.Balance,
 		&i.Currency,
 		&i.CreatedAt,
+		&i.Type,
+		&i.AccruedInterest,
+		&i.InterestAccruedOn,
+		&i.Nickname,
+		&i.Status,
 	)
 	return i, err
 }
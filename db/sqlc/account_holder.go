@@ -0,0 +1,59 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const (
+	// 只能查看账户
+	PermissionView = "view"
+	// 可以查看账户并从账户转出资金
+	PermissionTransfer = "transfer"
+	// 管理联名持有人，只有账户所有者拥有，不会保存到数据库
+	PermissionManage = "manage"
+)
+
+const (
+	HolderStatusInvited  = "invited"
+	HolderStatusAccepted = "accepted"
+)
+
+// Allows 判断联名持有人是否拥有某项权限，只有接受邀请后才生效，transfer权限包含view
+func (holder AccountHolder) Allows(permission string) bool {
+	if holder.Status != HolderStatusAccepted {
+		return false
+	}
+
+	switch permission {
+	case PermissionView:
+		return holder.Permission == PermissionView || holder.Permission == PermissionTransfer
+	case PermissionTransfer:
+		return holder.Permission == PermissionTransfer
+	}
+	return false
+}
+
+// CanAccess 账户权限校验：所有者拥有全部权限，联名持有人接受邀请后拥有被授予的权限
+func CanAccess(ctx context.Context, q Querier, username string, account Account, permission string) (bool, error) {
+	if account.Owner == username {
+		return true, nil
+	}
+
+	if permission == PermissionManage {
+		return false, nil
+	}
+
+	holder, err := q.GetAccountHolder(ctx, GetAccountHolderParams{
+		AccountID: account.ID,
+		Username:  username,
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return holder.Allows(permission), nil
+}
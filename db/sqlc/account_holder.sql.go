@@ -0,0 +1,136 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: account_holder.sql
+
+package db
+
+import (
+	"context"
+)
+
+const acceptAccountHolder = `-- name: AcceptAccountHolder :one
+UPDATE account_holders
+SET status = 'accepted',
+    accepted_at = now()
+WHERE account_id = $1 AND username = $2 AND status = 'invited'
+RETURNING account_id, username, permission, status, invited_by, accepted_at, created_at
+`
+
+type AcceptAccountHolderParams struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+func (q *Queries) AcceptAccountHolder(ctx context.Context, arg AcceptAccountHolderParams) (AccountHolder, error) {
+	row := q.db.QueryRow(ctx, acceptAccountHolder, arg.AccountID, arg.Username)
+	var i AccountHolder
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Permission,
+		&i.Status,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createAccountHolder = `-- name: CreateAccountHolder :one
+INSERT INTO account_holders (
+  account_id,
+  username,
+  permission,
+  invited_by
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING account_id, username, permission, status, invited_by, accepted_at, created_at
+`
+
+type CreateAccountHolderParams struct {
+	AccountID  int64  `json:"account_id"`
+	Username   string `json:"username"`
+	Permission string `json:"permission"`
+	InvitedBy  string `json:"invited_by"`
+}
+
+func (q *Queries) CreateAccountHolder(ctx context.Context, arg CreateAccountHolderParams) (AccountHolder, error) {
+	row := q.db.QueryRow(ctx, createAccountHolder,
+		arg.AccountID,
+		arg.Username,
+		arg.Permission,
+		arg.InvitedBy,
+	)
+	var i AccountHolder
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Permission,
+		&i.Status,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAccountHolder = `-- name: GetAccountHolder :one
+SELECT account_id, username, permission, status, invited_by, accepted_at, created_at FROM account_holders
+WHERE account_id = $1 AND username = $2
+LIMIT 1
+`
+
+type GetAccountHolderParams struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+func (q *Queries) GetAccountHolder(ctx context.Context, arg GetAccountHolderParams) (AccountHolder, error) {
+	row := q.db.QueryRow(ctx, getAccountHolder, arg.AccountID, arg.Username)
+	var i AccountHolder
+	err := row.Scan(
+		&i.AccountID,
+		&i.Username,
+		&i.Permission,
+		&i.Status,
+		&i.InvitedBy,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAccountHolders = `-- name: ListAccountHolders :many
+SELECT account_id, username, permission, status, invited_by, accepted_at, created_at FROM account_holders
+WHERE account_id = $1
+ORDER BY created_at, username
+`
+
+func (q *Queries) ListAccountHolders(ctx context.Context, accountID int64) ([]AccountHolder, error) {
+	rows, err := q.db.Query(ctx, listAccountHolders, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountHolder{}
+	for rows.Next() {
+		var i AccountHolder
+		if err := rows.Scan(
+			&i.AccountID,
+			&i.Username,
+			&i.Permission,
+			&i.Status,
+			&i.InvitedBy,
+			&i.AcceptedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
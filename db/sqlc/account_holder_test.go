@@ -0,0 +1,46 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountHolderInvitation(t *testing.T) {
+	account := createRandomAccount(t)
+	invitee := createRandomUser(t)
+
+	holder, err := testQueries.CreateAccountHolder(context.Background(), CreateAccountHolderParams{
+		AccountID:  account.ID,
+		Username:   invitee.Username,
+		Permission: PermissionTransfer,
+		InvitedBy:  account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HolderStatusInvited, holder.Status)
+	require.False(t, holder.Allows(PermissionView))
+
+	// 接受邀请之前联名账户不会出现在列表中
+	accounts, err := testQueries.ListAccounts(context.Background(), ListAccountsParams{Owner: invitee.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	holder, err = testQueries.AcceptAccountHolder(context.Background(), AcceptAccountHolderParams{
+		AccountID: account.ID,
+		Username:  invitee.Username,
+	})
+	require.NoError(t, err)
+	require.Equal(t, HolderStatusAccepted, holder.Status)
+	require.True(t, holder.AcceptedAt.Valid)
+	require.True(t, holder.Allows(PermissionTransfer))
+
+	accounts, err = testQueries.ListAccounts(context.Background(), ListAccountsParams{Owner: invitee.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	holders, err := testQueries.ListAccountHolders(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Len(t, holders, 1)
+}
@@ -0,0 +1,11 @@
+package db
+
+import "errors"
+
+// 冻结的账户不能转出、取款和预授权，但仍然可以入账（例如退款、结息）
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+)
+
+var ErrAccountFrozen = errors.New("account is frozen")
@@ -23,10 +23,24 @@ func createRandomAccount(t *testing.T, name ...string) Account {
 		user = createRandomUser(t)
 	}
 
+	return createUserAccount(t, user, util.RandomCurrency())
+}
+
+// 转账双方必须是相同币种，否则凭证按币种借贷不平衡
+func createRandomAccountPair(t *testing.T) (Account, Account) {
+	currency := util.RandomCurrency()
+	account1 := createUserAccount(t, createRandomUser(t), currency)
+	account2 := createUserAccount(t, createRandomUser(t), currency)
+	return account1, account2
+}
+
+func createUserAccount(t *testing.T, user User, currency string) Account {
+	// 转账不能透支，余额要足够覆盖测试中的并发转账
 	arg := CreateAccountParams{
 		Owner:    user.Username,
-		Balance:  util.RandomMoney(),
-		Currency: util.RandomCurrency(),
+		Balance:  util.RandomInt(100, 1000),
+		Currency: currency,
+		Type:     util.Checking,
 	}
 
 	account, err := testQueries.CreateAccount(context.Background(), arg)
@@ -36,6 +50,8 @@ func createRandomAccount(t *testing.T, name ...string) Account {
 	require.Equal(t, arg.Owner, account.Owner)
 	require.Equal(t, arg.Balance, account.Balance)
 	require.Equal(t, arg.Currency, account.Currency)
+	require.Equal(t, arg.Type, account.Type)
+	require.Zero(t, account.AccruedInterest)
 
 	require.NotZero(t, account.ID)
 	require.NotZero(t, account.CreatedAt)
@@ -56,25 +72,6 @@ func TestGetAccount(t *testing.T) {
 	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
 }
 
-func TestUpdateAccount(t *testing.T) {
-	account1 := createRandomAccount(t)
-
-	arg := UpdateAccountParams{
-		ID:      account1.ID,
-		Balance: util.RandomMoney(),
-	}
-
-	account2, err := testQueries.UpdateAccount(context.Background(), arg)
-	require.NoError(t, err)
-	require.NotEmpty(t, account2)
-
-	require.Equal(t, account1.ID, account2.ID)
-	require.Equal(t, account1.Owner, account2.Owner)
-	require.Equal(t, arg.Balance, account2.Balance)
-	require.Equal(t, account1.Currency, account2.Currency)
-	require.WithinDuration(t, account1.CreatedAt, account2.CreatedAt, time.Second)
-}
-
 func TestDeleteAccount(t *testing.T) {
 	account1 := createRandomAccount(t)
 	err := testQueries.DeleteAccount(context.Background(), account1.ID)
@@ -85,3 +82,82 @@ func TestDeleteAccount(t *testing.T) {
 	require.EqualError(t, err, sql.ErrNoRows.Error())
 	require.Empty(t, account2)
 }
+
+func TestAccountNickname(t *testing.T) {
+	user := createRandomUser(t)
+
+	// 同一用户可以有多个相同币种的账户
+	account1 := createUserAccount(t, user, util.USD)
+	account2 := createUserAccount(t, user, util.USD)
+
+	account1, err := testQueries.UpdateAccountNickname(context.Background(), UpdateAccountNicknameParams{
+		ID:       account1.ID,
+		Nickname: sql.NullString{String: "Rent", Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "Rent", account1.Nickname.String)
+
+	// 同一用户的昵称不能重复
+	_, err = testQueries.UpdateAccountNickname(context.Background(), UpdateAccountNicknameParams{
+		ID:       account2.ID,
+		Nickname: sql.NullString{String: "Rent", Valid: true},
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	// 其他用户可以使用相同的昵称
+	other := createRandomAccount(t)
+	_, err = testQueries.UpdateAccountNickname(context.Background(), UpdateAccountNicknameParams{
+		ID:       other.ID,
+		Nickname: sql.NullString{String: "Rent", Valid: true},
+	})
+	require.NoError(t, err)
+}
+
+func TestFrozenAccount(t *testing.T) {
+	store := NewStore(testDB)
+	account1, account2 := createRandomAccountPair(t)
+	require.Equal(t, AccountStatusActive, account1.Status)
+
+	account1, err := store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: AccountStatusFrozen,
+	})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, account1.Status)
+
+	// 冻结的账户不能转出，事务回滚后余额不变
+	_, err = store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	unchanged, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, unchanged.Balance)
+
+	_, err = store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      1,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrAccountFrozen)
+
+	// 仍然可以入账
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        1,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance+1, result.ToAccount.Balance)
+
+	// 状态只能是active或frozen
+	_, err = store.UpdateAccountStatus(context.Background(), UpdateAccountStatusParams{
+		ID:     account1.ID,
+		Status: "closed",
+	})
+	require.Equal(t, CheckViolation, ErrorCode(err))
+}
@@ -7,53 +7,132 @@ package db
 
 import (
 	"context"
+	"database/sql"
+	"time"
 )
 
-const createEntry = `-- name: CreateEntry :one
-INSERT INTO entries (
-  account_id,
-  amount
-) VALUES (
-  $1, $2
-) RETURNING id, account_id, amount, created_at
+const getEntry = `-- name: GetEntry :one
+SELECT id, account_id, amount, created_at, journal_id, prev_hash, hash FROM entries
+WHERE id = $1 LIMIT 1
 `
 
-type CreateEntryParams struct {
-	AccountID int64 `json:"account_id"`
-	Amount    int64 `json:"amount"`
-}
-
-func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	row := q.db.QueryRow(ctx, getEntry, id)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.JournalID,
+		&i.PrevHash,
+		&i.Hash,
 	)
 	return i, err
 }
 
-const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
-WHERE id = $1 LIMIT 1
+const getLastEntryHash = `-- name: GetLastEntryHash :one
+SELECT hash FROM entries
+WHERE account_id = $1
+ORDER BY id DESC
+LIMIT 1
 `
 
-func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, getEntry, id)
-	var i Entry
-	err := row.Scan(
-		&i.ID,
-		&i.AccountID,
-		&i.Amount,
-		&i.CreatedAt,
-	)
-	return i, err
+func (q *Queries) GetLastEntryHash(ctx context.Context, accountID int64) (string, error) {
+	row := q.db.QueryRow(ctx, getLastEntryHash, accountID)
+	var hash string
+	err := row.Scan(&hash)
+	return hash, err
+}
+
+const listAccountEntriesAfter = `-- name: ListAccountEntriesAfter :many
+SELECT id, account_id, amount, created_at, journal_id, prev_hash, hash FROM entries
+WHERE account_id = $1
+  AND id > $2
+ORDER BY id
+LIMIT $3
+`
+
+type ListAccountEntriesAfterParams struct {
+	AccountID int64 `json:"account_id"`
+	AfterID   int64 `json:"after_id"`
+	BatchSize int32 `json:"batch_size"`
+}
+
+func (q *Queries) ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listAccountEntriesAfter, arg.AccountID, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.JournalID,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listAccountEntrySums = `-- name: ListAccountEntrySums :many
+SELECT
+  a.id AS account_id,
+  a.balance,
+  COALESCE(SUM(e.amount), 0)::bigint AS entries_total
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+WHERE a.id > $1
+GROUP BY a.id
+ORDER BY a.id
+LIMIT $2
+`
+
+type ListAccountEntrySumsParams struct {
+	AfterID   int64 `json:"after_id"`
+	BatchSize int32 `json:"batch_size"`
+}
+
+type ListAccountEntrySumsRow struct {
+	AccountID    int64 `json:"account_id"`
+	Balance      int64 `json:"balance"`
+	EntriesTotal int64 `json:"entries_total"`
+}
+
+func (q *Queries) ListAccountEntrySums(ctx context.Context, arg ListAccountEntrySumsParams) ([]ListAccountEntrySumsRow, error) {
+	rows, err := q.db.Query(ctx, listAccountEntrySums, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountEntrySumsRow{}
+	for rows.Next() {
+		var i ListAccountEntrySumsRow
+		if err := rows.Scan(&i.AccountID, &i.Balance, &i.EntriesTotal); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, journal_id, prev_hash, hash FROM entries
 WHERE account_id = $1
 ORDER BY id
 LIMIT $2
@@ -67,7 +146,7 @@ type ListEntriesParams struct {
 }
 
 func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
-	rows, err := q.db.QueryContext(ctx, listEntries, arg.AccountID, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, listEntries, arg.AccountID, arg.Limit, arg.Offset)
 	if err != nil {
 		return nil, err
 	}
@@ -80,16 +159,146 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.JournalID,
+			&i.PrevHash,
+			&i.Hash,
 		); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
+	return items, nil
+}
+
+const listOrphanEntries = `-- name: ListOrphanEntries :many
+SELECT e.id, e.account_id, e.amount, e.created_at, e.journal_id, e.prev_hash, e.hash FROM entries e
+JOIN journal_transactions j ON j.id = e.journal_id
+WHERE e.id > $1
+  AND j.transfer_id IS NULL
+ORDER BY e.id
+LIMIT $2
+`
+
+type ListOrphanEntriesParams struct {
+	AfterID   int64 `json:"after_id"`
+	BatchSize int32 `json:"batch_size"`
+}
+
+// 凭证没有对应转账的账目即为孤儿账目（迁移时无法匹配到转账的历史账目）
+func (q *Queries) ListOrphanEntries(ctx context.Context, arg ListOrphanEntriesParams) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listOrphanEntries, arg.AfterID, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.JournalID,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
 	return items, nil
 }
+
+const listStatementEntries = `-- name: ListStatementEntries :many
+SELECT
+  e.id,
+  e.account_id,
+  e.amount,
+  e.created_at,
+  j.description,
+  t.id AS transfer_id,
+  c.id AS counterparty_account_id,
+  c.owner AS counterparty_owner
+FROM entries e
+JOIN journal_transactions j ON j.id = e.journal_id
+LEFT JOIN transfers t ON t.id = j.transfer_id
+LEFT JOIN accounts c ON c.id = (
+  CASE WHEN t.from_account_id = e.account_id THEN t.to_account_id ELSE t.from_account_id END
+)
+WHERE e.account_id = $1
+  AND e.created_at >= $2::timestamptz
+  AND e.created_at < $3::timestamptz
+ORDER BY e.id
+`
+
+type ListStatementEntriesParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+}
+
+type ListStatementEntriesRow struct {
+	ID                    int64          `json:"id"`
+	AccountID             int64          `json:"account_id"`
+	Amount                int64          `json:"amount"`
+	CreatedAt             time.Time      `json:"created_at"`
+	Description           string         `json:"description"`
+	TransferID            sql.NullInt64  `json:"transfer_id"`
+	CounterpartyAccountID sql.NullInt64  `json:"counterparty_account_id"`
+	CounterpartyOwner     sql.NullString `json:"counterparty_owner"`
+}
+
+// 对账单明细：账目及其对应转账的对手方
+func (q *Queries) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error) {
+	rows, err := q.db.Query(ctx, listStatementEntries, arg.AccountID, arg.FromTime, arg.ToTime)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListStatementEntriesRow{}
+	for rows.Next() {
+		var i ListStatementEntriesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.Description,
+			&i.TransferID,
+			&i.CounterpartyAccountID,
+			&i.CounterpartyOwner,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const nextEntryID = `-- name: NextEntryID :one
+SELECT
+  nextval('entries_id_seq')::bigint AS id,
+  now()::timestamptz AS created_at
+`
+
+type NextEntryIDRow struct {
+	ID        int64     `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (q *Queries) NextEntryID(ctx context.Context) (NextEntryIDRow, error) {
+	row := q.db.QueryRow(ctx, nextEntryID)
+	var i NextEntryIDRow
+	err := row.Scan(&i.ID, &i.CreatedAt)
+	return i, err
+}
@@ -0,0 +1,26 @@
+package db
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// PostgreSQL错误码
+const (
+	ForeignKeyViolation = "23503"
+	UniqueViolation     = "23505"
+	CheckViolation      = "23514"
+	// 以下两种错误发生时数据库已回滚事务，重新执行整个事务即可
+	SerializationFailure = "40001"
+	DeadlockDetected     = "40P01"
+)
+
+// ErrorCode 返回数据库错误的SQLSTATE错误码，不是数据库错误时返回空字符串
+func ErrorCode(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
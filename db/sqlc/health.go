@@ -0,0 +1,36 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrSchemaDirty = errors.New("schema migration is dirty")
+
+// SchemaVersion golang-migrate在schema_migrations表中记录的迁移状态
+type SchemaVersion struct {
+	Version int64 `json:"version"`
+	Dirty   bool  `json:"dirty"`
+}
+
+// Ping 检查数据库连接是否可用；配置了从库时同时检查从库
+func (store *SQLStore) Ping(ctx context.Context) error {
+	err := store.db.Ping(ctx)
+	if err != nil || store.replicaDB == nil {
+		return err
+	}
+
+	err = store.replicaDB.Ping(ctx)
+	if err != nil {
+		return fmt.Errorf("replica: %w", err)
+	}
+	return nil
+}
+
+// GetSchemaVersion 读取当前迁移版本，从未迁移时返回sql.ErrNoRows
+func (store *SQLStore) GetSchemaVersion(ctx context.Context) (SchemaVersion, error) {
+	var version SchemaVersion
+	err := store.Queries.db.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version.Version, &version.Dirty)
+	return version, err
+}
@@ -0,0 +1,22 @@
+package db
+
+import (
+	"context"
+	"simplebank/db/migration"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSchemaVersion(t *testing.T) {
+	store := NewStore(testDB)
+	require.NoError(t, store.Ping(context.Background()))
+
+	latest, err := migration.LatestVersion()
+	require.NoError(t, err)
+
+	version, err := store.GetSchemaVersion(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, int64(latest), version.Version)
+	require.False(t, version.Dirty)
+}
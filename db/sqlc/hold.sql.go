@@ -0,0 +1,208 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: hold.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const captureHold = `-- name: CaptureHold :one
+UPDATE holds
+SET status = 'captured',
+    captured_amount = $1,
+    transfer_id = $2
+WHERE id = $3
+  AND status = 'active'
+  AND expires_at > now()
+RETURNING id, account_id, to_account_id, amount, captured_amount, status, transfer_id, expires_at, created_at
+`
+
+type CaptureHoldParams struct {
+	CapturedAmount int64         `json:"captured_amount"`
+	TransferID     sql.NullInt64 `json:"transfer_id"`
+	ID             int64         `json:"id"`
+}
+
+// 过期按数据库时间判断，与GetActiveHoldsAmount一致；已过期或不是有效状态时不更新任何行
+func (q *Queries) CaptureHold(ctx context.Context, arg CaptureHoldParams) (Hold, error) {
+	row := q.db.QueryRow(ctx, captureHold, arg.CapturedAmount, arg.TransferID, arg.ID)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CapturedAmount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const createHold = `-- name: CreateHold :one
+INSERT INTO holds (
+  account_id,
+  to_account_id,
+  amount,
+  expires_at
+) VALUES (
+  $1, $2, $3, $4
+) RETURNING id, account_id, to_account_id, amount, captured_amount, status, transfer_id, expires_at, created_at
+`
+
+type CreateHoldParams struct {
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+func (q *Queries) CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error) {
+	row := q.db.QueryRow(ctx, createHold,
+		arg.AccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ExpiresAt,
+	)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CapturedAmount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getActiveHoldsAmount = `-- name: GetActiveHoldsAmount :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS amount
+FROM holds
+WHERE account_id = $1
+  AND status = 'active'
+  AND expires_at > now()
+`
+
+func (q *Queries) GetActiveHoldsAmount(ctx context.Context, accountID int64) (int64, error) {
+	row := q.db.QueryRow(ctx, getActiveHoldsAmount, accountID)
+	var amount int64
+	err := row.Scan(&amount)
+	return amount, err
+}
+
+const getHold = `-- name: GetHold :one
+SELECT id, account_id, to_account_id, amount, captured_amount, status, transfer_id, expires_at, created_at FROM holds
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetHold(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRow(ctx, getHold, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CapturedAmount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getHoldForUpdate = `-- name: GetHoldForUpdate :one
+SELECT id, account_id, to_account_id, amount, captured_amount, status, transfer_id, expires_at, created_at FROM holds
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE
+`
+
+func (q *Queries) GetHoldForUpdate(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRow(ctx, getHoldForUpdate, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CapturedAmount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const releaseExpiredHolds = `-- name: ReleaseExpiredHolds :many
+UPDATE holds
+SET status = 'expired'
+WHERE status = 'active' AND expires_at <= now()
+RETURNING id, account_id, to_account_id, amount, captured_amount, status, transfer_id, expires_at, created_at
+`
+
+func (q *Queries) ReleaseExpiredHolds(ctx context.Context) ([]Hold, error) {
+	rows, err := q.db.Query(ctx, releaseExpiredHolds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Hold{}
+	for rows.Next() {
+		var i Hold
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CapturedAmount,
+			&i.Status,
+			&i.TransferID,
+			&i.ExpiresAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const releaseHold = `-- name: ReleaseHold :one
+UPDATE holds
+SET status = 'released'
+WHERE id = $1 AND status = 'active'
+RETURNING id, account_id, to_account_id, amount, captured_amount, status, transfer_id, expires_at, created_at
+`
+
+func (q *Queries) ReleaseHold(ctx context.Context, id int64) (Hold, error) {
+	row := q.db.QueryRow(ctx, releaseHold, id)
+	var i Hold
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CapturedAmount,
+		&i.Status,
+		&i.TransferID,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlaceAndCaptureHoldTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1, account2 := createRandomAccountPair(t)
+
+	amount := account1.Balance
+	if amount == 0 {
+		_, err := store.DepositTx(context.Background(), CashTxParams{AccountID: account1.ID, Amount: 10})
+		require.NoError(t, err)
+		amount = 10
+	}
+
+	hold, err := store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      amount,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusActive, hold.Status)
+	require.Equal(t, amount, hold.Amount)
+
+	// 全部余额已被冻结，不能再次预授权
+	_, err = store.PlaceHoldTx(context.Background(), PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      1,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID, Amount: amount + 1})
+	require.ErrorIs(t, err, ErrHoldAmountExceeded)
+
+	// 部分扣款
+	captured := amount / 2
+	if captured == 0 {
+		captured = amount
+	}
+	result, err := store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID, Amount: captured})
+	require.NoError(t, err)
+	require.Equal(t, HoldStatusCaptured, result.Hold.Status)
+	require.Equal(t, captured, result.Hold.CapturedAmount)
+	require.Equal(t, sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true}, result.Hold.TransferID)
+	require.Equal(t, captured, result.Transfer.Transfer.Amount)
+
+	held, err := store.GetActiveHoldsAmount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, held)
+
+	_, err = store.CaptureHoldTx(context.Background(), CaptureHoldTxParams{HoldID: hold.ID, Amount: captured})
+	require.ErrorIs(t, err, ErrHoldNotActive)
+}
+
+func TestReleaseExpiredHolds(t *testing.T) {
+	account1, account2 := createRandomAccountPair(t)
+
+	hold, err := testQueries.CreateHold(context.Background(), CreateHoldParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      1,
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	holds, err := testQueries.ReleaseExpiredHolds(context.Background())
+	require.NoError(t, err)
+
+	var found bool
+	for _, h := range holds {
+		if h.ID == hold.ID {
+			found = true
+			require.Equal(t, HoldStatusExpired, h.Status)
+		}
+	}
+	require.True(t, found)
+
+	_, err = testQueries.ReleaseHold(context.Background(), hold.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
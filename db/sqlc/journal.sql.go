@@ -0,0 +1,88 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: journal.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createJournalTransaction = `-- name: CreateJournalTransaction :one
+INSERT INTO journal_transactions (
+  transfer_id,
+  description
+) VALUES (
+  $1, $2
+) RETURNING id, transfer_id, description, created_at
+`
+
+type CreateJournalTransactionParams struct {
+	TransferID  sql.NullInt64 `json:"transfer_id"`
+	Description string        `json:"description"`
+}
+
+func (q *Queries) CreateJournalTransaction(ctx context.Context, arg CreateJournalTransactionParams) (JournalTransaction, error) {
+	row := q.db.QueryRow(ctx, createJournalTransaction, arg.TransferID, arg.Description)
+	var i JournalTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.TransferID,
+		&i.Description,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getJournalTransaction = `-- name: GetJournalTransaction :one
+SELECT id, transfer_id, description, created_at FROM journal_transactions
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetJournalTransaction(ctx context.Context, id int64) (JournalTransaction, error) {
+	row := q.db.QueryRow(ctx, getJournalTransaction, id)
+	var i JournalTransaction
+	err := row.Scan(
+		&i.ID,
+		&i.TransferID,
+		&i.Description,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listJournalEntries = `-- name: ListJournalEntries :many
+SELECT id, account_id, amount, created_at, journal_id, prev_hash, hash FROM entries
+WHERE journal_id = $1
+ORDER BY id
+`
+
+func (q *Queries) ListJournalEntries(ctx context.Context, journalID int64) ([]Entry, error) {
+	rows, err := q.db.Query(ctx, listJournalEntries, journalID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Entry{}
+	for rows.Next() {
+		var i Entry
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.JournalID,
+			&i.PrevHash,
+			&i.Hash,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,53 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListJournalEntries(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1, account2 := createRandomAccountPair(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	entries, err := store.ListJournalEntries(context.Background(), result.Journal.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	var sum int64
+	for _, entry := range entries {
+		sum += entry.Amount
+	}
+	require.Zero(t, sum)
+}
+
+// 借贷不平衡的凭证在提交时会被数据库拒绝
+func TestUnbalancedJournalRejected(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+
+	err := store.execTx(context.Background(), "UnbalancedJournal", func(q TxQuerier) error {
+		journal, err := q.CreateJournalTransaction(context.Background(), CreateJournalTransactionParams{
+			TransferID:  sql.NullInt64{},
+			Description: "unbalanced",
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = appendEntry(context.Background(), q, account.ID, 10, journal.ID)
+		return err
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// 直接修改余额和写入账目的SQL不放在db/query中由sqlc生成：生成的方法是导出的，会出现在Querier和Store上，
+// 调用方可以绕过记账凭证改动余额。这里的方法只通过txQueries提供给事务函数
+
+const addAccountBalance = `-- name: AddAccountBalance :one
+UPDATE accounts
+SET balance = balance + $1
+WHERE id = $2
+RETURNING id, owner, balance, currency, created_at, type, accrued_interest, interest_accrued_on, nickname, status
+`
+
+type AddAccountBalanceParams struct {
+	Amount int64 `json:"amount"`
+	ID     int64 `json:"id"`
+}
+
+func (q *Queries) addAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	row := q.db.QueryRow(ctx, addAccountBalance, arg.Amount, arg.ID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.Owner,
+		&i.Balance,
+		&i.Currency,
+		&i.CreatedAt,
+		&i.Type,
+		&i.AccruedInterest,
+		&i.InterestAccruedOn,
+		&i.Nickname,
+		&i.Status,
+	)
+	return i, err
+}
+
+const createEntry = `-- name: CreateEntry :one
+INSERT INTO entries (
+  id,
+  account_id,
+  amount,
+  journal_id,
+  created_at,
+  prev_hash,
+  hash
+) VALUES (
+  $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, account_id, amount, created_at, journal_id, prev_hash, hash
+`
+
+type CreateEntryParams struct {
+	ID        int64     `json:"id"`
+	AccountID int64     `json:"account_id"`
+	Amount    int64     `json:"amount"`
+	JournalID int64     `json:"journal_id"`
+	CreatedAt time.Time `json:"created_at"`
+	PrevHash  string    `json:"prev_hash"`
+	Hash      string    `json:"hash"`
+}
+
+// id和created_at由NextEntryID预先取得，以便在插入前计算哈希
+func (q *Queries) createEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	row := q.db.QueryRow(ctx, createEntry,
+		arg.ID,
+		arg.AccountID,
+		arg.Amount,
+		arg.JournalID,
+		arg.CreatedAt,
+		arg.PrevHash,
+		arg.Hash,
+	)
+	var i Entry
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.JournalID,
+		&i.PrevHash,
+		&i.Hash,
+	)
+	return i, err
+}
@@ -1,29 +1,29 @@
 package db
 
 import (
-	"database/sql"
+	"context"
 	"log"
 	"os"
 	"simplebank/util"
 	"testing"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var testQueries *Queries
-var testDB *sql.DB
+var testDB *pgxpool.Pool
 
 func TestMain(m *testing.M) {
 	var err error
 
 	config, err := util.LoadConfig("../..")
 
-	testDB, err = sql.Open(config.DBDriver, config.DBSource)
+	testDB, err = pgxpool.New(context.Background(), config.DBSource)
 	if err != nil {
 		log.Fatal("cannot connect to db:", err)
 	}
 
-	testQueries = New(testDB)
+	testQueries = New(newTracedDBTX(testDB))
 
 	log.Println("Running tests...")
 
@@ -0,0 +1,39 @@
+package db
+
+import "time"
+
+// Metrics 由调用方注入监控实现，db包本身不依赖具体的监控系统
+type Metrics interface {
+	// ObserveTx 记录一次事务的耗时，err不为nil表示事务失败
+	ObserveTx(name string, duration time.Duration, err error)
+	// IncTxRetry 事务因序列化冲突或死锁被重试
+	IncTxRetry(name string)
+	// TransferCompleted 一笔资金转移成功，kind为TransferKind*之一，amount为最小货币单位
+	TransferCompleted(kind string, currency string, amount int64)
+}
+
+// 资金转移的类型，存取款、预授权扣款和结息在数据库中同样是一笔转账
+const (
+	TransferKindTransfer    = "transfer"
+	TransferKindDeposit     = "deposit"
+	TransferKindWithdrawal  = "withdrawal"
+	TransferKindHoldCapture = "hold_capture"
+	TransferKindInterest    = "interest"
+)
+
+type StoreOption func(store *SQLStore)
+
+// WithMetrics 为store开启监控
+func WithMetrics(metrics Metrics) StoreOption {
+	return func(store *SQLStore) {
+		store.metrics = metrics
+	}
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveTx(name string, duration time.Duration, err error) {}
+
+func (noopMetrics) IncTxRetry(name string) {}
+
+func (noopMetrics) TransferCompleted(kind string, currency string, amount int64) {}
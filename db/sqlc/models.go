@@ -5,6 +5,7 @@
 package db
 
 import (
+	"database/sql"
 	"time"
 )
 
@@ -14,6 +15,35 @@ type Account struct {
 	Balance   int64     `json:"balance"`
 	Currency  string    `json:"currency"`
 	CreatedAt time.Time `json:"created_at"`
+	Type      string    `json:"type"`
+	// interest accrued in minor units but not yet capitalized
+	AccruedInterest int64 `json:"accrued_interest"`
+	// last day (UTC) that interest was accrued for
+	InterestAccruedOn sql.NullTime `json:"interest_accrued_on"`
+	// optional, unique per owner
+	Nickname sql.NullString `json:"nickname"`
+	// frozen accounts cannot be debited
+	Status string `json:"status"`
+}
+
+type AccountHolder struct {
+	AccountID int64  `json:"account_id"`
+	Username  string `json:"username"`
+	// view or transfer, transfer implies view
+	Permission string `json:"permission"`
+	// invited or accepted, only accepted holders can access the account
+	Status     string       `json:"status"`
+	InvitedBy  string       `json:"invited_by"`
+	AcceptedAt sql.NullTime `json:"accepted_at"`
+	CreatedAt  time.Time    `json:"created_at"`
+}
+
+type BalanceSnapshot struct {
+	AccountID    int64     `json:"account_id"`
+	SnapshotDate time.Time `json:"snapshot_date"`
+	// sum of entries created before the end of snapshot_date (UTC)
+	Balance   int64     `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 type Entry struct {
@@ -22,6 +52,31 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `json:"amount"`
 	CreatedAt time.Time `json:"created_at"`
+	JournalID int64     `json:"journal_id"`
+	PrevHash  string    `json:"prev_hash"`
+	// sha256(prev_hash|amount|account_id|created_at unix micros|id)
+	Hash string `json:"hash"`
+}
+
+type Hold struct {
+	ID          int64 `json:"id"`
+	AccountID   int64 `json:"account_id"`
+	ToAccountID int64 `json:"to_account_id"`
+	// must be positive
+	Amount         int64 `json:"amount"`
+	CapturedAmount int64 `json:"captured_amount"`
+	// active, captured, released or expired
+	Status     string        `json:"status"`
+	TransferID sql.NullInt64 `json:"transfer_id"`
+	ExpiresAt  time.Time     `json:"expires_at"`
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+type JournalTransaction struct {
+	ID          int64         `json:"id"`
+	TransferID  sql.NullInt64 `json:"transfer_id"`
+	Description string        `json:"description"`
+	CreatedAt   time.Time     `json:"created_at"`
 }
 
 type Transfer struct {
@@ -40,4 +95,6 @@ type User struct {
 	Email             string    `json:"email"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
+	// depositor or banker
+	Role string `json:"role"`
 }
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"simplebank/util"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// 支持的数据库驱动
+const (
+	// DriverPgx 使用pgx/v5的连接池pgxpool
+	DriverPgx = "pgx"
+	// DriverPostgres 兼容旧配置，与DriverPgx相同
+	DriverPostgres = "postgres"
+)
+
+// PoolConfig 连接池配置，零值表示使用驱动的默认值
+type PoolConfig struct {
+	MaxConns        int32
+	MinConns        int32
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+	// 单条SQL的最长执行时间，超时后数据库取消该语句
+	StatementTimeout time.Duration
+}
+
+// NewPoolConfig 从应用配置中读取连接池配置，服务和simplebankctl共用
+func NewPoolConfig(config util.Config) PoolConfig {
+	return PoolConfig{
+		MaxConns:         config.DBMaxConns,
+		MinConns:         config.DBMinConns,
+		MaxConnLifetime:  config.DBMaxConnLifetime,
+		MaxConnIdleTime:  config.DBMaxConnIdleTime,
+		StatementTimeout: config.DBStatementTimeout,
+	}
+}
+
+// OpenDB 按driver打开pgxpool连接池，供NewStore使用；迁移需要*sql.DB时用stdlib.OpenDBFromPool包装。
+// 使用完毕后调用pool.Close关闭连接池
+func OpenDB(ctx context.Context, driver string, source string, config PoolConfig) (*pgxpool.Pool, error) {
+	switch driver {
+	case DriverPgx, DriverPostgres:
+		return openPgxPool(ctx, source, config)
+	default:
+		return nil, fmt.Errorf("unsupported db driver: %s", driver)
+	}
+}
+
+func openPgxPool(ctx context.Context, source string, config PoolConfig) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.MaxConns > 0 {
+		poolConfig.MaxConns = config.MaxConns
+	}
+	if config.MinConns > 0 {
+		poolConfig.MinConns = config.MinConns
+	}
+	if config.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = config.MaxConnLifetime
+	}
+	if config.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = config.MaxConnIdleTime
+	}
+	if config.StatementTimeout > 0 {
+		poolConfig.ConnConfig.RuntimeParams["statement_timeout"] = strconv.FormatInt(config.StatementTimeout.Milliseconds(), 10)
+	}
+
+	return pgxpool.NewWithConfig(ctx, poolConfig)
+}
@@ -9,22 +9,66 @@ import (
 )
 
 type Querier interface {
-	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	AcceptAccountHolder(ctx context.Context, arg AcceptAccountHolderParams) (AccountHolder, error)
+	// 计息，每个账户每天只计一次
+	AccrueInterest(ctx context.Context, arg AccrueInterestParams) (int64, error)
+	// 过期按数据库时间判断，与GetActiveHoldsAmount一致；已过期或不是有效状态时不更新任何行
+	CaptureHold(ctx context.Context, arg CaptureHoldParams) (Hold, error)
 	CountAccounts(ctx context.Context) (int64, error)
 	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
-	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+	CreateAccountHolder(ctx context.Context, arg CreateAccountHolderParams) (AccountHolder, error)
+	// 在上一份快照的基础上累加当天的账目，生成每个账户的日终余额快照，重复执行不会覆盖已有快照。
+	// 账户的第一份快照从期初余额（余额减去全部账目之和，见GetOpeningBalance）开始累加
+	CreateDailyBalanceSnapshots(ctx context.Context, arg CreateDailyBalanceSnapshotsParams) (int64, error)
+	CreateHold(ctx context.Context, arg CreateHoldParams) (Hold, error)
+	CreateJournalTransaction(ctx context.Context, arg CreateJournalTransactionParams) (JournalTransaction, error)
 	CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error)
+	// role为NULL时使用默认的depositor
 	CreateUser(ctx context.Context, arg CreateUserParams) (User, error)
 	DeleteAccount(ctx context.Context, id int64) error
 	GetAccount(ctx context.Context, id int64) (Account, error)
+	GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error)
 	GetAccountForUpdate(ctx context.Context, id int64) (Account, error)
+	GetAccountHolder(ctx context.Context, arg GetAccountHolderParams) (AccountHolder, error)
+	GetActiveHoldsAmount(ctx context.Context, accountID int64) (int64, error)
 	GetEntry(ctx context.Context, id int64) (Entry, error)
+	GetHold(ctx context.Context, id int64) (Hold, error)
+	GetHoldForUpdate(ctx context.Context, id int64) (Hold, error)
+	GetJournalTransaction(ctx context.Context, id int64) (JournalTransaction, error)
+	GetLastEntryHash(ctx context.Context, accountID int64) (string, error)
+	GetLatestBalanceSnapshot(ctx context.Context, arg GetLatestBalanceSnapshotParams) (BalanceSnapshot, error)
+	// 期初余额：没有对应账目的那部分余额，例如开户时的初始余额和引入账目之前的历史余额，从开户时起计入。
+	// 余额的每次变动都会同时写入账目，所以用当前余额减去全部账目之和
+	GetOpeningBalance(ctx context.Context, id int64) (GetOpeningBalanceRow, error)
 	GetTransfer(ctx context.Context, id int64) (Transfer, error)
 	GetUser(ctx context.Context, username string) (User, error)
+	ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) ([]Entry, error)
+	ListAccountEntrySums(ctx context.Context, arg ListAccountEntrySumsParams) ([]ListAccountEntrySumsRow, error)
+	ListAccountHolders(ctx context.Context, accountID int64) ([]AccountHolder, error)
+	// 自己的账户和已接受邀请的联名账户
 	ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error)
+	// 需要计息的账户：某个类型下当天还未计息的账户，按id分页
+	ListAccountsForAccrual(ctx context.Context, arg ListAccountsForAccrualParams) ([]Account, error)
+	ListAccountsWithAccruedInterest(ctx context.Context, arg ListAccountsWithAccruedInterestParams) ([]Account, error)
 	ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error)
+	ListJournalEntries(ctx context.Context, journalID int64) ([]Entry, error)
+	// 凭证没有对应转账的账目即为孤儿账目（迁移时无法匹配到转账的历史账目）
+	ListOrphanEntries(ctx context.Context, arg ListOrphanEntriesParams) ([]Entry, error)
+	// 对账单明细：账目及其对应转账的对手方
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error)
 	ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error)
-	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	// 每笔转账必须恰好对应出钱方和收钱方两条账目
+	ListUnbalancedTransfers(ctx context.Context, arg ListUnbalancedTransfersParams) ([]ListUnbalancedTransfersRow, error)
+	NextEntryID(ctx context.Context) (NextEntryIDRow, error)
+	ReleaseExpiredHolds(ctx context.Context) ([]Hold, error)
+	ReleaseHold(ctx context.Context, id int64) (Hold, error)
+	// 结息后扣减已入账的应计利息
+	SubtractAccruedInterest(ctx context.Context, arg SubtractAccruedInterestParams) (Account, error)
+	SumEntriesBetween(ctx context.Context, arg SumEntriesBetweenParams) (int64, error)
+	UpdateAccountNickname(ctx context.Context, arg UpdateAccountNicknameParams) (Account, error)
+	// 冻结/解冻账户
+	UpdateAccountStatus(ctx context.Context, arg UpdateAccountStatusParams) (Account, error)
+	UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (User, error)
 }
 
 var _ Querier = (*Queries)(nil)
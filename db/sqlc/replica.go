@@ -0,0 +1,124 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type primaryKey struct{}
+
+// WithPrimary 标记ctx中的查询必须读主库，用于写操作之后需要立即读到最新数据的场景
+func WithPrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+// IsPrimary ctx是否要求读主库
+func IsPrimary(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryKey{}).(bool)
+	return primary
+}
+
+// WithReplica 只读查询走从库，从库的数据可能落后于主库。
+// 事务、写操作、FOR UPDATE查询、用户信息、联名持有人（权限校验）和批处理任务使用的查询始终走主库；
+// 计息和对账等根据读到的数据写入或报告问题的任务，在入口处用WithPrimary读主库
+func WithReplica(replica *pgxpool.Pool) StoreOption {
+	return func(store *SQLStore) {
+		store.replica = New(newTracedDBTX(replica))
+		store.replicaDB = replica
+	}
+}
+
+// 只读查询使用的连接，没有配置从库或ctx要求读主库时使用主库
+func (store *SQLStore) reader(ctx context.Context) *Queries {
+	if store.replica == nil || IsPrimary(ctx) {
+		return store.Queries
+	}
+	return store.replica
+}
+
+// 以下只读查询可以走从库
+
+func (store *SQLStore) CountAccounts(ctx context.Context) (int64, error) {
+	return store.reader(ctx).CountAccounts(ctx)
+}
+
+func (store *SQLStore) GetAccount(ctx context.Context, id int64) (Account, error) {
+	return store.reader(ctx).GetAccount(ctx, id)
+}
+
+func (store *SQLStore) GetAccountByOwnerAndCurrency(ctx context.Context, arg GetAccountByOwnerAndCurrencyParams) (Account, error) {
+	return store.reader(ctx).GetAccountByOwnerAndCurrency(ctx, arg)
+}
+
+func (store *SQLStore) GetActiveHoldsAmount(ctx context.Context, accountID int64) (int64, error) {
+	return store.reader(ctx).GetActiveHoldsAmount(ctx, accountID)
+}
+
+func (store *SQLStore) GetEntry(ctx context.Context, id int64) (Entry, error) {
+	return store.reader(ctx).GetEntry(ctx, id)
+}
+
+func (store *SQLStore) GetHold(ctx context.Context, id int64) (Hold, error) {
+	return store.reader(ctx).GetHold(ctx, id)
+}
+
+func (store *SQLStore) GetJournalTransaction(ctx context.Context, id int64) (JournalTransaction, error) {
+	return store.reader(ctx).GetJournalTransaction(ctx, id)
+}
+
+func (store *SQLStore) GetLatestBalanceSnapshot(ctx context.Context, arg GetLatestBalanceSnapshotParams) (BalanceSnapshot, error) {
+	return store.reader(ctx).GetLatestBalanceSnapshot(ctx, arg)
+}
+
+func (store *SQLStore) GetOpeningBalance(ctx context.Context, accountID int64) (GetOpeningBalanceRow, error) {
+	return store.reader(ctx).GetOpeningBalance(ctx, accountID)
+}
+
+func (store *SQLStore) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
+	return store.reader(ctx).GetTransfer(ctx, id)
+}
+
+func (store *SQLStore) ListAccountEntriesAfter(ctx context.Context, arg ListAccountEntriesAfterParams) ([]Entry, error) {
+	return store.reader(ctx).ListAccountEntriesAfter(ctx, arg)
+}
+
+func (store *SQLStore) ListAccountEntrySums(ctx context.Context, arg ListAccountEntrySumsParams) ([]ListAccountEntrySumsRow, error) {
+	return store.reader(ctx).ListAccountEntrySums(ctx, arg)
+}
+
+func (store *SQLStore) ListAccountHolders(ctx context.Context, accountID int64) ([]AccountHolder, error) {
+	return store.reader(ctx).ListAccountHolders(ctx, accountID)
+}
+
+func (store *SQLStore) ListAccounts(ctx context.Context, arg ListAccountsParams) ([]Account, error) {
+	return store.reader(ctx).ListAccounts(ctx, arg)
+}
+
+func (store *SQLStore) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Entry, error) {
+	return store.reader(ctx).ListEntries(ctx, arg)
+}
+
+func (store *SQLStore) ListJournalEntries(ctx context.Context, journalID int64) ([]Entry, error) {
+	return store.reader(ctx).ListJournalEntries(ctx, journalID)
+}
+
+func (store *SQLStore) ListOrphanEntries(ctx context.Context, arg ListOrphanEntriesParams) ([]Entry, error) {
+	return store.reader(ctx).ListOrphanEntries(ctx, arg)
+}
+
+func (store *SQLStore) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]ListStatementEntriesRow, error) {
+	return store.reader(ctx).ListStatementEntries(ctx, arg)
+}
+
+func (store *SQLStore) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
+	return store.reader(ctx).ListTransfers(ctx, arg)
+}
+
+func (store *SQLStore) ListUnbalancedTransfers(ctx context.Context, arg ListUnbalancedTransfersParams) ([]ListUnbalancedTransfersRow, error) {
+	return store.reader(ctx).ListUnbalancedTransfers(ctx, arg)
+}
+
+func (store *SQLStore) SumEntriesBetween(ctx context.Context, arg SumEntriesBetweenParams) (int64, error) {
+	return store.reader(ctx).SumEntriesBetween(ctx, arg)
+}
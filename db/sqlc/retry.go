@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// RetryPolicy 事务遇到序列化冲突或死锁时的重试策略
+type RetryPolicy struct {
+	// 最多重试次数，0表示不重试
+	MaxRetries int
+	// 第n次重试前等待BaseDelay*2^(n-1)，再随机取其一半到全部，避免冲突的事务同时重试
+	BaseDelay time.Duration
+	// 单次等待的上限
+	MaxDelay time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries: 3,
+	BaseDelay:  10 * time.Millisecond,
+	MaxDelay:   200 * time.Millisecond,
+}
+
+// WithRetryPolicy 设置事务的重试策略
+func WithRetryPolicy(policy RetryPolicy) StoreOption {
+	return func(store *SQLStore) {
+		store.retryPolicy = policy
+	}
+}
+
+// WithTxIsolation 设置某个事务的隔离级别，name为事务方法名，例如TransferTx，未设置的事务使用数据库默认的隔离级别
+func WithTxIsolation(name string, level pgx.TxIsoLevel) StoreOption {
+	return func(store *SQLStore) {
+		store.isolation[name] = level
+	}
+}
+
+var isolationLevels = map[string]pgx.TxIsoLevel{
+	"read_committed":  pgx.ReadCommitted,
+	"repeatable_read": pgx.RepeatableRead,
+	"serializable":    pgx.Serializable,
+}
+
+// ParseIsolationLevel 解析配置中的隔离级别：read_committed、repeatable_read、serializable
+func ParseIsolationLevel(level string) (pgx.TxIsoLevel, error) {
+	isolation, ok := isolationLevels[strings.ToLower(strings.TrimSpace(level))]
+	if !ok {
+		return "", fmt.Errorf("unsupported isolation level: %s", level)
+	}
+	return isolation, nil
+}
+
+// 可重试的错误：序列化冲突和死锁，出错时数据库已经回滚了事务
+func isRetryable(err error) bool {
+	switch ErrorCode(err) {
+	case SerializationFailure, DeadlockDetected:
+		return true
+	}
+	return false
+}
+
+// 第retry次（从1开始）重试前的等待时间
+func (policy RetryPolicy) backoff(retry int) time.Duration {
+	delay := policy.BaseDelay
+	for i := 1; i < retry && delay < policy.MaxDelay; i++ {
+		delay *= 2
+	}
+	if policy.MaxDelay > 0 && delay > policy.MaxDelay {
+		delay = policy.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(delay-half)+1))
+}
+
+// run 执行attempt，遇到可重试的错误时等待后重新执行，最多重试MaxRetries次。
+// 等待期间ctx被取消时立即返回，返回的错误同时包含最后一次执行的错误和ctx的错误
+func (policy RetryPolicy) run(ctx context.Context, attempt func() error, onRetry func(retry int, err error)) error {
+	for retry := 1; ; retry++ {
+		err := attempt()
+		if err == nil || !isRetryable(err) || retry > policy.MaxRetries {
+			return err
+		}
+
+		onRetry(retry, err)
+
+		timer := time.NewTimer(policy.backoff(retry))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
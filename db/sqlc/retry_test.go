@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+// 记录重试次数的Metrics
+type retryCounter struct {
+	noopMetrics
+	mu      sync.Mutex
+	retries map[string]int
+}
+
+func (m *retryCounter) IncTxRetry(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retries[name]++
+}
+
+func (m *retryCounter) count(name string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.retries[name]
+}
+
+var errSerialization = &pgconn.PgError{Code: SerializationFailure}
+
+func TestRetryPolicyRun(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Millisecond}
+
+	testCases := []struct {
+		name         string
+		errs         []error
+		wantAttempts int
+		wantErr      error
+	}{
+		{
+			name:         "OK",
+			errs:         []error{nil},
+			wantAttempts: 1,
+		},
+		{
+			name:         "RetrySerializationFailure",
+			errs:         []error{errSerialization, errSerialization, nil},
+			wantAttempts: 3,
+		},
+		{
+			name:         "RetryDeadlock",
+			errs:         []error{&pgconn.PgError{Code: DeadlockDetected}, nil},
+			wantAttempts: 2,
+		},
+		{
+			name:         "WrappedError",
+			errs:         []error{errors.Join(errors.New("commit"), errSerialization), nil},
+			wantAttempts: 2,
+		},
+		{
+			name:         "RetriesExhausted",
+			errs:         []error{errSerialization, errSerialization, errSerialization, errSerialization, nil},
+			wantAttempts: 4,
+			wantErr:      errSerialization,
+		},
+		{
+			name:         "NotRetryable",
+			errs:         []error{&pgconn.PgError{Code: UniqueViolation}, nil},
+			wantAttempts: 1,
+			wantErr:      &pgconn.PgError{Code: UniqueViolation},
+		},
+		{
+			name:         "BusinessError",
+			errs:         []error{ErrInsufficientFunds, nil},
+			wantAttempts: 1,
+			wantErr:      ErrInsufficientFunds,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attempts := 0
+			var retries []int
+
+			err := policy.run(context.Background(), func() error {
+				err := tc.errs[attempts]
+				attempts++
+				return err
+			}, func(retry int, err error) {
+				require.True(t, isRetryable(err))
+				retries = append(retries, retry)
+			})
+
+			require.Equal(t, tc.wantErr, err)
+			require.Equal(t, tc.wantAttempts, attempts)
+			require.Len(t, retries, tc.wantAttempts-1)
+			for i, retry := range retries {
+				require.Equal(t, i+1, retry)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyNoRetries(t *testing.T) {
+	attempts := 0
+	err := RetryPolicy{}.run(context.Background(), func() error {
+		attempts++
+		return errSerialization
+	}, func(int, error) {
+		t.Fatal("should not retry")
+	})
+
+	require.Equal(t, errSerialization, err)
+	require.Equal(t, 1, attempts)
+}
+
+func TestRetryPolicyContextCanceled(t *testing.T) {
+	policy := RetryPolicy{MaxRetries: 3, BaseDelay: time.Hour, MaxDelay: time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts := 0
+	start := time.Now()
+	err := policy.run(ctx, func() error {
+		attempts++
+		return errSerialization
+	}, func(int, error) {
+		cancel()
+	})
+
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorIs(t, err, errSerialization)
+	require.Equal(t, 1, attempts)
+	require.Less(t, time.Since(start), time.Second)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 10 * time.Millisecond, MaxDelay: 50 * time.Millisecond}
+
+	for i := 0; i < 100; i++ {
+		for retry, max := range map[int]time.Duration{
+			1:  10 * time.Millisecond,
+			2:  20 * time.Millisecond,
+			3:  40 * time.Millisecond,
+			4:  50 * time.Millisecond,
+			10: 50 * time.Millisecond,
+		} {
+			delay := policy.backoff(retry)
+			require.GreaterOrEqual(t, delay, max/2)
+			require.LessOrEqual(t, delay, max)
+		}
+	}
+
+	require.Zero(t, RetryPolicy{}.backoff(1))
+}
+
+func TestParseIsolationLevel(t *testing.T) {
+	for value, want := range map[string]pgx.TxIsoLevel{
+		"read_committed":  pgx.ReadCommitted,
+		"repeatable_read": pgx.RepeatableRead,
+		"serializable":    pgx.Serializable,
+		" Serializable ":  pgx.Serializable,
+	} {
+		level, err := ParseIsolationLevel(value)
+		require.NoError(t, err)
+		require.Equal(t, want, level)
+	}
+
+	_, err := ParseIsolationLevel("snapshot")
+	require.Error(t, err)
+}
+
+// 可串行化隔离级别下相反方向的并发转账会发生序列化冲突，重试后应全部成功
+func TestTransferTxSerializableRetry(t *testing.T) {
+	counter := &retryCounter{retries: map[string]int{}}
+	store := NewStore(testDB,
+		WithMetrics(counter),
+		WithTxIsolation("TransferTx", pgx.Serializable),
+		WithRetryPolicy(RetryPolicy{MaxRetries: 50, BaseDelay: time.Millisecond, MaxDelay: 20 * time.Millisecond}),
+	)
+
+	account1, account2 := createRandomAccountPair(t)
+
+	n := 10
+	amount := int64(10)
+	errs := make(chan error)
+
+	for i := 0; i < n; i++ {
+		fromAccountID, toAccountID := account1.ID, account2.ID
+		if i%2 == 1 {
+			fromAccountID, toAccountID = account2.ID, account1.ID
+		}
+
+		go func() {
+			_, err := store.TransferTx(context.Background(), TransferTxParams{
+				FromAccountID: fromAccountID,
+				ToAccountID:   toAccountID,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	updatedAccount1, err := store.GetAccount(context.Background(), account1.ID)
+	require.NoError(t, err)
+	updatedAccount2, err := store.GetAccount(context.Background(), account2.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+	require.Positive(t, counter.count("TransferTx"))
+}
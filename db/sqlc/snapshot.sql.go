@@ -0,0 +1,128 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: snapshot.sql
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const createDailyBalanceSnapshots = `-- name: CreateDailyBalanceSnapshots :execrows
+INSERT INTO balance_snapshots (account_id, snapshot_date, balance)
+SELECT
+  a.id,
+  $1::date,
+  COALESCE(prev.balance, a.balance - (
+    SELECT COALESCE(SUM(all_entries.amount), 0)::bigint
+    FROM entries all_entries
+    WHERE all_entries.account_id = a.id
+  )) + COALESCE(SUM(e.amount), 0)::bigint
+FROM accounts a
+LEFT JOIN LATERAL (
+  SELECT s.balance, s.snapshot_date
+  FROM balance_snapshots s
+  WHERE s.account_id = a.id
+    AND s.snapshot_date < $1::date
+  ORDER BY s.snapshot_date DESC
+  LIMIT 1
+) prev ON true
+LEFT JOIN entries e ON e.account_id = a.id
+  AND e.created_at < $2::timestamptz
+  AND (
+    prev.snapshot_date IS NULL OR
+    e.created_at >= (prev.snapshot_date + 1)::timestamp AT TIME ZONE 'UTC'
+  )
+WHERE a.created_at < $2::timestamptz
+GROUP BY a.id, a.balance, prev.balance
+ON CONFLICT (account_id, snapshot_date) DO NOTHING
+`
+
+type CreateDailyBalanceSnapshotsParams struct {
+	SnapshotDate time.Time `json:"snapshot_date"`
+	Cutoff       time.Time `json:"cutoff"`
+}
+
+// 在上一份快照的基础上累加当天的账目，生成每个账户的日终余额快照，重复执行不会覆盖已有快照。
+// 账户的第一份快照从期初余额（余额减去全部账目之和，见GetOpeningBalance）开始累加
+func (q *Queries) CreateDailyBalanceSnapshots(ctx context.Context, arg CreateDailyBalanceSnapshotsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createDailyBalanceSnapshots, arg.SnapshotDate, arg.Cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const getLatestBalanceSnapshot = `-- name: GetLatestBalanceSnapshot :one
+SELECT account_id, snapshot_date, balance, created_at FROM balance_snapshots
+WHERE account_id = $1
+  AND snapshot_date < $2::date
+ORDER BY snapshot_date DESC
+LIMIT 1
+`
+
+type GetLatestBalanceSnapshotParams struct {
+	AccountID  int64     `json:"account_id"`
+	BeforeDate time.Time `json:"before_date"`
+}
+
+func (q *Queries) GetLatestBalanceSnapshot(ctx context.Context, arg GetLatestBalanceSnapshotParams) (BalanceSnapshot, error) {
+	row := q.db.QueryRow(ctx, getLatestBalanceSnapshot, arg.AccountID, arg.BeforeDate)
+	var i BalanceSnapshot
+	err := row.Scan(
+		&i.AccountID,
+		&i.SnapshotDate,
+		&i.Balance,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getOpeningBalance = `-- name: GetOpeningBalance :one
+SELECT
+  (a.balance - (
+    SELECT COALESCE(SUM(e.amount), 0)::bigint
+    FROM entries e
+    WHERE e.account_id = a.id
+  ))::bigint AS opening_balance,
+  a.created_at
+FROM accounts a
+WHERE a.id = $1
+`
+
+type GetOpeningBalanceRow struct {
+	OpeningBalance int64     `json:"opening_balance"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// 期初余额：没有对应账目的那部分余额，例如开户时的初始余额和引入账目之前的历史余额，从开户时起计入。
+// 余额的每次变动都会同时写入账目，所以用当前余额减去全部账目之和
+func (q *Queries) GetOpeningBalance(ctx context.Context, id int64) (GetOpeningBalanceRow, error) {
+	row := q.db.QueryRow(ctx, getOpeningBalance, id)
+	var i GetOpeningBalanceRow
+	err := row.Scan(&i.OpeningBalance, &i.CreatedAt)
+	return i, err
+}
+
+const sumEntriesBetween = `-- name: SumEntriesBetween :one
+SELECT COALESCE(SUM(amount), 0)::bigint AS amount
+FROM entries
+WHERE account_id = $1
+  AND created_at >= $2::timestamptz
+  AND created_at < $3::timestamptz
+`
+
+type SumEntriesBetweenParams struct {
+	AccountID int64     `json:"account_id"`
+	FromTime  time.Time `json:"from_time"`
+	ToTime    time.Time `json:"to_time"`
+}
+
+func (q *Queries) SumEntriesBetween(ctx context.Context, arg SumEntriesBetweenParams) (int64, error) {
+	row := q.db.QueryRow(ctx, sumEntriesBetween, arg.AccountID, arg.FromTime, arg.ToTime)
+	var amount int64
+	err := row.Scan(&amount)
+	return amount, err
+}
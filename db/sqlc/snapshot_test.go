@@ -0,0 +1,68 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetBalanceAt(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1, account2 := createRandomAccountPair(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	createdAt := result.ToEntry.CreatedAt
+
+	// 测试账户开户时带有初始余额，没有对应账目，作为期初余额计入
+	balance, err := store.GetBalanceAt(context.Background(), account2.ID, createdAt)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, balance)
+
+	balance, err = store.GetBalanceAt(context.Background(), account2.ID, createdAt.Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+10, balance)
+}
+
+func TestCreateDailyBalanceSnapshots(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1, account2 := createRandomAccountPair(t)
+
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	day := SnapshotDate(result.ToEntry.CreatedAt)
+	arg := CreateDailyBalanceSnapshotsParams{
+		SnapshotDate: day,
+		Cutoff:       SnapshotCutoff(day),
+	}
+
+	n, err := store.CreateDailyBalanceSnapshots(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, n)
+
+	snapshot, err := store.GetLatestBalanceSnapshot(context.Background(), GetLatestBalanceSnapshotParams{
+		AccountID:  account2.ID,
+		BeforeDate: SnapshotCutoff(day),
+	})
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+10, snapshot.Balance)
+
+	// 有快照后，历史余额从快照开始累加
+	balance, err := store.GetBalanceAt(context.Background(), account2.ID, SnapshotCutoff(day).Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance+10, balance)
+}
@@ -4,47 +4,110 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"simplebank/logging"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (Hold, error)
+	CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error)
+	DepositTx(ctx context.Context, arg CashTxParams) (TransferTxResult, error)
+	WithdrawTx(ctx context.Context, arg CashTxParams) (TransferTxResult, error)
+	CapitalizeInterestTx(ctx context.Context, accountID int64) (TransferTxResult, error)
+	GetBalanceAt(ctx context.Context, accountID int64, at time.Time) (int64, error)
+	Ping(ctx context.Context) error
+	GetSchemaVersion(ctx context.Context) (SchemaVersion, error)
 }
 
 type SQLStore struct {
 	*Queries
-	db *sql.DB
+	Transactions
+	db          *pgxpool.Pool
+	metrics     Metrics
+	retryPolicy RetryPolicy
+	isolation   map[string]pgx.TxIsoLevel
+	// 从库，为nil时所有查询都走主库
+	replica   *Queries
+	replicaDB *pgxpool.Pool
 }
 
-func NewStore(db *sql.DB) *SQLStore {
-	return &SQLStore{
-		db:      db,
-		Queries: New(db),
+func NewStore(db *pgxpool.Pool, options ...StoreOption) *SQLStore {
+	store := &SQLStore{
+		db:          db,
+		Queries:     New(newTracedDBTX(db)),
+		metrics:     noopMetrics{},
+		retryPolicy: DefaultRetryPolicy,
+		isolation:   map[string]pgx.TxIsoLevel{},
+	}
+
+	for _, option := range options {
+		option(store)
 	}
+	store.Transactions = NewTransactions(store.execTx, store.metrics)
+
+	return store
 }
 
 // 执行事务，为了安全，此方法不对外暴露（为什么要独立出来？因为涉及到的事物的代码结构基本一致，所以独立出来可以复用代码）
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
+// name用于监控指标，一般为对外暴露的事务方法名
+// 遇到序列化冲突或死锁时按retryPolicy重新执行整个事务，因此fn可能被执行多次，不能有数据库以外的副作用
+func (store *SQLStore) execTx(ctx context.Context, name string, fn func(TxQuerier) error) (err error) {
+	start := time.Now()
+	ctx, span := startTxSpan(ctx, name)
+	attempts := 0
+	defer func() {
+		span.SetAttributes(attribute.Int("db.tx.attempts", attempts))
+		store.metrics.ObserveTx(name, time.Since(start), err)
+		endSpan(span, err)
+	}()
+
+	return store.retryPolicy.run(ctx, func() error {
+		attempts++
+		return store.runTx(ctx, name, span, fn)
+	}, func(retry int, err error) {
+		store.metrics.IncTxRetry(name)
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("db.tx.retry", retry),
+			attribute.String("db.tx.error", err.Error()),
+		))
+		logging.FromContext(ctx).Warn("retrying transaction", "tx", name, "retry", retry, "error", err)
+	})
+}
+
+// 执行一次事务
+func (store *SQLStore) runTx(ctx context.Context, name string, span trace.Span, fn func(TxQuerier) error) error {
 	//开启事物
-	tx, err := store.db.BeginTx(ctx, nil)
+	tx, err := store.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: store.isolation[name]})
 	if err != nil {
 		return err
 	}
 
 	//执行具体操作
-	q := New(tx)
+	q := txQueries{New(newTracedTx(tx, span))}
 	err = fn(q)
 	if err != nil {
 		//出错了要回滚事物
-		rbErr := tx.Rollback()
+		span.SetAttributes(attribute.String("db.tx.outcome", "rollback"))
+		rbErr := tx.Rollback(ctx)
 		if rbErr != nil {
-			return fmt.Errorf("txErr: %v , rbErr: %v", err, rbErr)
+			span.SetAttributes(attribute.String("db.tx.rollback_error", rbErr.Error()))
+			logging.FromContext(ctx).Error("cannot rollback transaction", "error", err, "rollback_error", rbErr)
+			return fmt.Errorf("txErr: %w , rbErr: %v", err, rbErr)
 		}
+		logging.FromContext(ctx).Debug("transaction rolled back", "error", err)
 		return err
 	}
 
-	//提交事物
-	return tx.Commit()
+	//提交事物，可串行化隔离级别下冲突可能在提交时才被发现
+	span.SetAttributes(attribute.String("db.tx.outcome", "commit"))
+	return tx.Commit(ctx)
 }
 
 /**
@@ -60,73 +123,93 @@ type TransferTxParams struct {
 
 // 转让记录VO
 type TransferTxResult struct {
-	Transfer    Transfer `json:"transfer"`
-	FromAccount Account  `json:"from_account"`
-	ToAccount   Account  `json:"to_account"`
-	FromEntry   Entry    `json:"from_entry"`
-	ToEntry     Entry    `json:"to_entry"`
+	Transfer    Transfer           `json:"transfer"`
+	Journal     JournalTransaction `json:"journal"`
+	FromAccount Account            `json:"from_account"`
+	ToAccount   Account            `json:"to_account"`
+	FromEntry   Entry              `json:"from_entry"`
+	ToEntry     Entry              `json:"to_entry"`
 }
 
-func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
+func (transactions Transactions) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
-	err := store.execTx(ctx, func(q *Queries) error {
+	err := transactions.runTx(ctx, "TransferTx", func(q TxQuerier) error {
 		var err error
-
-		// 创建转让记录
-		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
-		})
+		result, err = transfer(ctx, q, arg)
 		if err != nil {
 			return err
 		}
 
-		// 为出钱方创建账户条目
-		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+		// 转账时已锁住出钱方，预授权占用的金额不能转出
+		return checkAvailableBalance(ctx, q, result.FromAccount, 0)
+	})
+	if err == nil {
+		transactions.metrics.TransferCompleted(TransferKindTransfer, result.FromAccount.Currency, arg.Amount)
+	}
 
-		// 为收钱方创建账户条目
-		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
-		})
-		if err != nil {
-			return err
-		}
+	return result, err
+}
 
-		// 让id大的用户现更新余额，避免在用户1更用户2同时互相转账时因顺序问题导致死锁
-		if arg.FromAccountID > arg.ToAccountID {
-			AddMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
-		} else {
-			AddMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
-		}
+// 在事务内完成一次转账（转账记录+双方账目+余额），供转账、预授权扣款等事务复用
+func transfer(ctx context.Context, q TxQuerier, arg TransferTxParams) (TransferTxResult, error) {
+	return transferWithDescription(ctx, q, arg, "transfer")
+}
 
-		result.FromAccount, err = q.GetAccount(ctx, arg.FromAccountID)
-		if err != nil {
-			return err
-		}
+// 同transfer，description写入记账凭证，用于区分结息等内部转账
+func transferWithDescription(ctx context.Context, q TxQuerier, arg TransferTxParams, description string) (TransferTxResult, error) {
+	var result TransferTxResult
+	var err error
 
-		result.ToAccount, err = q.GetAccount(ctx, arg.ToAccountID)
-		if err != nil {
-			return err
-		}
+	// 创建转让记录
+	result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+	})
+	if err != nil {
+		return result, err
+	}
 
-		return nil
+	// 创建记账凭证，本次转账的两条账目都挂在它下面，提交时数据库会校验凭证借贷平衡
+	result.Journal, err = q.CreateJournalTransaction(ctx, CreateJournalTransactionParams{
+		TransferID:  sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
+		Description: description,
 	})
+	if err != nil {
+		return result, err
+	}
+
+	// 让id大的用户现更新余额，避免在用户1更用户2同时互相转账时因顺序问题导致死锁
+	// 先更新余额再写账目：更新余额会锁住两个账户，保证各账户的账目哈希链按顺序追加
+	if arg.FromAccountID > arg.ToAccountID {
+		result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+	} else {
+		result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// 余额更新会锁住账户，锁住后再检查状态，避免与冻结操作并发时漏检
+	if result.FromAccount.Status == AccountStatusFrozen {
+		return result, ErrAccountFrozen
+	}
+
+	// 为出钱方创建账户条目
+	result.FromEntry, err = appendEntry(ctx, q, arg.FromAccountID, -arg.Amount, result.Journal.ID)
+	if err != nil {
+		return result, err
+	}
 
+	// 为收钱方创建账户条目
+	result.ToEntry, err = appendEntry(ctx, q, arg.ToAccountID, arg.Amount, result.Journal.ID)
 	return result, err
 }
 
-func AddMoney(
+func addMoney(
 	ctx context.Context,
-	q *Queries,
+	q TxQuerier,
 	accountID1 int64,
 	amount1 int64,
 	accountID2 int64,
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// GetBalanceAt 快照和账目查询都可以走从库
+func (store *SQLStore) GetBalanceAt(ctx context.Context, accountID int64, at time.Time) (int64, error) {
+	return BalanceAt(ctx, store, accountID, at)
+}
+
+// BalanceAt 计算账户在at时刻（不含）的余额：最近一份日终快照（没有快照时为期初余额）+ 快照之后到at之间的账目，PostgreSQL实现和memstore共用
+func BalanceAt(ctx context.Context, q Querier, accountID int64, at time.Time) (int64, error) {
+	at = at.UTC()
+	from := time.Time{}
+	var balance int64
+
+	snapshot, err := q.GetLatestBalanceSnapshot(ctx, GetLatestBalanceSnapshotParams{
+		AccountID:  accountID,
+		BeforeDate: SnapshotDate(at),
+	})
+	switch {
+	case err == nil:
+		balance = snapshot.Balance
+		from = SnapshotCutoff(snapshot.SnapshotDate)
+	case err == sql.ErrNoRows:
+		// 还没有快照时从期初余额开始累加，与生成第一份快照的方式一致
+		opening, err := q.GetOpeningBalance(ctx, accountID)
+		if err != nil {
+			return 0, err
+		}
+		if opening.CreatedAt.Before(at) {
+			balance = opening.OpeningBalance
+		}
+	default:
+		return 0, err
+	}
+
+	amount, err := q.SumEntriesBetween(ctx, SumEntriesBetweenParams{
+		AccountID: accountID,
+		FromTime:  from,
+		ToTime:    at,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return balance + amount, nil
+}
+
+// 快照按UTC自然日划分
+func SnapshotDate(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// 快照日的截止时间，即次日0点（UTC）
+func SnapshotCutoff(snapshotDate time.Time) time.Time {
+	return SnapshotDate(snapshotDate).AddDate(0, 0, 1)
+}
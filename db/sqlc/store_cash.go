@@ -0,0 +1,120 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// 银行内部清算用户，每种币种下有一个清算账户
+const SettlementOwner = "bank_settlement"
+
+// IsBankOwner 判断是否是银行内部用户，这些用户的账户由迁移创建，每种币种只有一个
+func IsBankOwner(owner string) bool {
+	return owner == SettlementOwner || owner == InterestOwner
+}
+
+var (
+	ErrSettlementAccount  = errors.New("operation is not allowed on a settlement account")
+	ErrBankAccountMissing = errors.New("bank account is missing")
+)
+
+/**
+存款/取款事物：与对应币种的清算账户做一笔转账，保证所有账户的余额之和始终为0
+*/
+
+// 存取款DTO
+type CashTxParams struct {
+	AccountID int64 `json:"account_id"`
+	Amount    int64 `json:"amount"`
+}
+
+// 存款：清算账户 -> 用户账户
+func (transactions Transactions) DepositTx(ctx context.Context, arg CashTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := transactions.runTx(ctx, "DepositTx", func(q TxQuerier) error {
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		settlement, err := getSettlementAccount(ctx, q, account)
+		if err != nil {
+			return err
+		}
+
+		result, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: settlement.ID,
+			ToAccountID:   account.ID,
+			Amount:        arg.Amount,
+		})
+		return err
+	})
+	if err == nil {
+		transactions.metrics.TransferCompleted(TransferKindDeposit, result.ToAccount.Currency, arg.Amount)
+	}
+
+	return result, err
+}
+
+// 取款：用户账户 -> 清算账户，取款金额不能超过可用余额
+func (transactions Transactions) WithdrawTx(ctx context.Context, arg CashTxParams) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := transactions.runTx(ctx, "WithdrawTx", func(q TxQuerier) error {
+		// 不在这里加锁：先锁用户账户会与存款（按id顺序锁清算账户和用户账户）形成死锁，
+		// 转账按id顺序锁住双方后再校验可用余额
+		account, err := q.GetAccount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		settlement, err := getSettlementAccount(ctx, q, account)
+		if err != nil {
+			return err
+		}
+
+		result, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: account.ID,
+			ToAccountID:   settlement.ID,
+			Amount:        arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		return checkAvailableBalance(ctx, q, result.FromAccount, 0)
+	})
+	if err == nil {
+		transactions.metrics.TransferCompleted(TransferKindWithdrawal, result.FromAccount.Currency, arg.Amount)
+	}
+
+	return result, err
+}
+
+func getSettlementAccount(ctx context.Context, q TxQuerier, account Account) (Account, error) {
+	if account.Owner == SettlementOwner {
+		return Account{}, ErrSettlementAccount
+	}
+
+	return getBankAccount(ctx, q, SettlementOwner, account.Currency)
+}
+
+// 获取银行内部用户在某个币种下的账户
+func getBankAccount(ctx context.Context, q TxQuerier, owner string, currency string) (Account, error) {
+	account, err := q.GetAccountByOwnerAndCurrency(ctx, GetAccountByOwnerAndCurrencyParams{
+		Owner:    owner,
+		Currency: currency,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// 银行内部账户由迁移创建，缺失说明数据有问题，不能当作用户账户不存在处理
+		return account, fmt.Errorf("%s %s: %w", currency, owner, ErrBankAccountMissing)
+	}
+	if err != nil {
+		return account, fmt.Errorf("cannot get %s %s account: %w", currency, owner, err)
+	}
+
+	return account, nil
+}
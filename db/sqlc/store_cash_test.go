@@ -0,0 +1,86 @@
+package db
+
+import (
+	"context"
+	"simplebank/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDepositAndWithdrawTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	settlement, err := store.GetAccountByOwnerAndCurrency(context.Background(), GetAccountByOwnerAndCurrencyParams{
+		Owner:    SettlementOwner,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+
+	amount := int64(100)
+
+	result, err := store.DepositTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: amount})
+	require.NoError(t, err)
+	require.Equal(t, settlement.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account.ID, result.Transfer.ToAccountID)
+	require.Equal(t, account.Balance+amount, result.ToAccount.Balance)
+	require.Equal(t, settlement.Balance-amount, result.FromAccount.Balance)
+
+	result, err = store.WithdrawTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: amount})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, result.Transfer.FromAccountID)
+	require.Equal(t, settlement.ID, result.Transfer.ToAccountID)
+	require.Equal(t, account.Balance, result.FromAccount.Balance)
+
+	// 取款不能超过可用余额
+	_, err = store.WithdrawTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: account.Balance + 1})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// 不能对清算账户本身存款
+	_, err = store.DepositTx(context.Background(), CashTxParams{AccountID: settlement.ID, Amount: amount})
+	require.ErrorIs(t, err, ErrSettlementAccount)
+}
+
+// 同一账户并发存取款不能因加锁顺序不同而死锁
+func TestDepositAndWithdrawTxConcurrent(t *testing.T) {
+	store := NewStore(testDB)
+	account := createRandomAccount(t)
+
+	n := 10
+	amount := int64(10)
+	errs := make(chan error)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			var err error
+			if i%2 == 0 {
+				_, err = store.DepositTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: amount})
+			} else {
+				_, err = store.WithdrawTx(context.Background(), CashTxParams{AccountID: account.ID, Amount: amount})
+			}
+			errs <- err
+		}(i)
+	}
+
+	for i := 0; i < n; i++ {
+		require.NoError(t, <-errs)
+	}
+
+	updated, err := store.GetAccount(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance, updated.Balance)
+}
+
+// 迁移000004和000008为每个支持的币种创建了清算账户和利息支出账户，新增币种时也要补上
+func TestBankAccountsForSupportedCurrencies(t *testing.T) {
+	store := NewStore(testDB)
+
+	for _, currency := range util.SupportedCurrencies() {
+		for _, owner := range []string{SettlementOwner, InterestOwner} {
+			account, err := getBankAccount(context.Background(), txQueries{store.Queries}, owner, currency)
+			require.NoError(t, err)
+			require.Equal(t, owner, account.Owner)
+			require.Equal(t, currency, account.Currency)
+		}
+	}
+}
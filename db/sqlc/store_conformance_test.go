@@ -0,0 +1,26 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	db "simplebank/db/sqlc"
+	"simplebank/db/storetest"
+	"simplebank/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+// 与memstore运行同一套一致性测试，两者的行为必须一致
+func TestStoreConformance(t *testing.T) {
+	config, err := util.LoadConfig("../..")
+	require.NoError(t, err)
+
+	pool, err := db.OpenDB(context.Background(), config.DBDriver, config.DBSource, db.PoolConfig{})
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+
+	storetest.Run(t, func(t *testing.T) db.Store {
+		return db.NewStore(pool)
+	})
+}
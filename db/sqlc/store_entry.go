@@ -0,0 +1,41 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// EntryHash 计算账目在所属账户哈希链上的哈希值，迁移000006中回填历史账目用的是同一算法
+func EntryHash(prevHash string, amount int64, accountID int64, createdAt time.Time, id int64) string {
+	data := fmt.Sprintf("%s|%d|%d|%d|%d", prevHash, amount, accountID, createdAt.UnixMicro(), id)
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
+
+// 创建一条账目并把它链接到账户哈希链的末尾。
+// 调用前必须已经锁住该账户（例如已更新过余额），否则并发写入会使链分叉
+func appendEntry(ctx context.Context, q TxQuerier, accountID int64, amount int64, journalID int64) (Entry, error) {
+	prevHash, err := q.GetLastEntryHash(ctx, accountID)
+	if err != nil && err != sql.ErrNoRows {
+		return Entry{}, err
+	}
+
+	next, err := q.NextEntryID(ctx)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return q.CreateEntry(ctx, CreateEntryParams{
+		ID:        next.ID,
+		AccountID: accountID,
+		Amount:    amount,
+		JournalID: journalID,
+		CreatedAt: next.CreatedAt,
+		PrevHash:  prevHash,
+		Hash:      EntryHash(prevHash, amount, accountID, next.CreatedAt, next.ID),
+	})
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntryHashChain(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1, account2 := createRandomAccountPair(t)
+
+	var entries []Entry
+	for i := 0; i < 3; i++ {
+		result, err := store.TransferTx(context.Background(), TransferTxParams{
+			FromAccountID: account1.ID,
+			ToAccountID:   account2.ID,
+			Amount:        10,
+		})
+		require.NoError(t, err)
+		entries = append(entries, result.FromEntry)
+	}
+
+	require.Empty(t, entries[0].PrevHash)
+	for i, entry := range entries {
+		require.Equal(t, EntryHash(entry.PrevHash, entry.Amount, entry.AccountID, entry.CreatedAt, entry.ID), entry.Hash)
+		if i > 0 {
+			require.Equal(t, entries[i-1].Hash, entry.PrevHash)
+		}
+	}
+
+	// 哈希值必须与数据库中保存的一致
+	stored, err := store.ListAccountEntriesAfter(context.Background(), ListAccountEntriesAfterParams{
+		AccountID: account1.ID,
+		AfterID:   0,
+		BatchSize: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, stored, 3)
+	for i := range stored {
+		require.Equal(t, entries[i].Hash, stored[i].Hash)
+	}
+}
+
+func TestEntriesAreAppendOnly(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1, account2 := createRandomAccountPair(t)
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	_, err = testDB.Exec(context.Background(), "UPDATE entries SET amount = 0 WHERE id = $1", result.FromEntry.ID)
+	require.Error(t, err)
+
+	_, err = testDB.Exec(context.Background(), "DELETE FROM entries WHERE id = $1", result.FromEntry.ID)
+	require.Error(t, err)
+}
+
+func TestListStatementEntries(t *testing.T) {
+	store := NewStore(testDB)
+
+	account1, account2 := createRandomAccountPair(t)
+	result, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	createdAt := result.FromEntry.CreatedAt
+	rows, err := store.ListStatementEntries(context.Background(), ListStatementEntriesParams{
+		AccountID: account1.ID,
+		FromTime:  createdAt.Add(-time.Minute),
+		ToTime:    createdAt.Add(time.Minute),
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, result.FromEntry.ID, rows[0].ID)
+	require.Equal(t, result.Transfer.ID, rows[0].TransferID.Int64)
+	// 对手方是转账的另一方
+	require.Equal(t, account2.ID, rows[0].CounterpartyAccountID.Int64)
+	require.Equal(t, account2.Owner, rows[0].CounterpartyOwner.String)
+}
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const (
+	HoldStatusActive   = "active"
+	HoldStatusCaptured = "captured"
+	HoldStatusReleased = "released"
+	HoldStatusExpired  = "expired"
+)
+
+var (
+	ErrInsufficientFunds  = errors.New("insufficient available balance")
+	ErrHoldNotActive      = errors.New("hold is not active")
+	ErrHoldAmountExceeded = errors.New("capture amount exceeds hold amount")
+)
+
+/**
+预授权（冻结资金）事物
+*/
+
+// 预授权DTO
+type PlaceHoldTxParams struct {
+	AccountID   int64     `json:"account_id"`
+	ToAccountID int64     `json:"to_account_id"`
+	Amount      int64     `json:"amount"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// 锁定账户后校验可用余额（余额-有效预授权），足够时才创建预授权
+func (transactions Transactions) PlaceHoldTx(ctx context.Context, arg PlaceHoldTxParams) (Hold, error) {
+	var hold Hold
+
+	err := transactions.runTx(ctx, "PlaceHoldTx", func(q TxQuerier) error {
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if account.Status == AccountStatusFrozen {
+			return ErrAccountFrozen
+		}
+
+		held, err := q.GetActiveHoldsAmount(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if account.Balance-held < arg.Amount {
+			return ErrInsufficientFunds
+		}
+
+		hold, err = q.CreateHold(ctx, CreateHoldParams{
+			AccountID:   arg.AccountID,
+			ToAccountID: arg.ToAccountID,
+			Amount:      arg.Amount,
+			ExpiresAt:   arg.ExpiresAt,
+		})
+		return err
+	})
+
+	return hold, err
+}
+
+// 预授权扣款DTO，Amount可以小于预授权金额（部分扣款），剩余部分随之释放
+type CaptureHoldTxParams struct {
+	HoldID int64 `json:"hold_id"`
+	Amount int64 `json:"amount"`
+}
+
+// 预授权扣款VO
+type CaptureHoldTxResult struct {
+	Hold     Hold             `json:"hold"`
+	Transfer TransferTxResult `json:"transfer"`
+}
+
+func (transactions Transactions) CaptureHoldTx(ctx context.Context, arg CaptureHoldTxParams) (CaptureHoldTxResult, error) {
+	var result CaptureHoldTxResult
+
+	err := transactions.runTx(ctx, "CaptureHoldTx", func(q TxQuerier) error {
+		hold, err := q.GetHoldForUpdate(ctx, arg.HoldID)
+		if err != nil {
+			return err
+		}
+
+		// 是否过期由CaptureHold按数据库时间判断
+		if hold.Status != HoldStatusActive {
+			return ErrHoldNotActive
+		}
+
+		if arg.Amount > hold.Amount {
+			return ErrHoldAmountExceeded
+		}
+
+		// 扣款即一次普通转账
+		result.Transfer, err = transfer(ctx, q, TransferTxParams{
+			FromAccountID: hold.AccountID,
+			ToAccountID:   hold.ToAccountID,
+			Amount:        arg.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 本次扣款的预授权随之结束，不再占用余额
+		err = checkAvailableBalance(ctx, q, result.Transfer.FromAccount, hold.Amount)
+		if err != nil {
+			return err
+		}
+
+		result.Hold, err = q.CaptureHold(ctx, CaptureHoldParams{
+			ID:             hold.ID,
+			CapturedAmount: arg.Amount,
+			TransferID:     sql.NullInt64{Int64: result.Transfer.Transfer.ID, Valid: true},
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// 预授权已经锁住，没有更新说明它已经过期，回滚本次转账
+			return ErrHoldNotActive
+		}
+		return err
+	})
+	if err == nil {
+		transactions.metrics.TransferCompleted(TransferKindHoldCapture, result.Transfer.FromAccount.Currency, arg.Amount)
+	}
+
+	return result, err
+}
+
+// 扣款后账户的可用余额（余额-有效预授权）不能为负，released为本次事务中结束的预授权金额。
+// account必须是已经锁住的最新状态
+func checkAvailableBalance(ctx context.Context, q TxQuerier, account Account, released int64) error {
+	held, err := q.GetActiveHoldsAmount(ctx, account.ID)
+	if err != nil {
+		return err
+	}
+
+	if account.Balance-(held-released) < 0 {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+// 银行利息支出用户，每种币种下有一个利息支出账户
+const InterestOwner = "bank_interest"
+
+var (
+	ErrNoAccruedInterest = errors.New("account has no accrued interest")
+)
+
+// 结息：把账户的应计利息从对应币种的利息支出账户转入该账户，并扣减应计利息
+func (transactions Transactions) CapitalizeInterestTx(ctx context.Context, accountID int64) (TransferTxResult, error) {
+	var result TransferTxResult
+
+	err := transactions.runTx(ctx, "CapitalizeInterestTx", func(q TxQuerier) error {
+		account, err := q.GetAccountForUpdate(ctx, accountID)
+		if err != nil {
+			return err
+		}
+
+		if account.AccruedInterest <= 0 {
+			return ErrNoAccruedInterest
+		}
+
+		if account.Owner == SettlementOwner || account.Owner == InterestOwner {
+			return ErrSettlementAccount
+		}
+
+		expense, err := getBankAccount(ctx, q, InterestOwner, account.Currency)
+		if err != nil {
+			return err
+		}
+
+		result, err = transferWithDescription(ctx, q, TransferTxParams{
+			FromAccountID: expense.ID,
+			ToAccountID:   account.ID,
+			Amount:        account.AccruedInterest,
+		}, "interest")
+		if err != nil {
+			return err
+		}
+
+		result.ToAccount, err = q.SubtractAccruedInterest(ctx, SubtractAccruedInterestParams{
+			ID:     account.ID,
+			Amount: account.AccruedInterest,
+		})
+		return err
+	})
+	if err == nil {
+		transactions.metrics.TransferCompleted(TransferKindInterest, result.ToAccount.Currency, result.Transfer.Amount)
+	}
+
+	return result, err
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccrueAndCapitalizeInterestTx(t *testing.T) {
+	store := NewStore(testDB)
+
+	account := createRandomAccount(t)
+	expense, err := store.GetAccountByOwnerAndCurrency(context.Background(), GetAccountByOwnerAndCurrencyParams{
+		Owner:    InterestOwner,
+		Currency: account.Currency,
+	})
+	require.NoError(t, err)
+
+	// 没有应计利息时不能结息
+	_, err = store.CapitalizeInterestTx(context.Background(), account.ID)
+	require.ErrorIs(t, err, ErrNoAccruedInterest)
+
+	day := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	n, err := store.AccrueInterest(context.Background(), AccrueInterestParams{ID: account.ID, Amount: 7, Day: day})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+
+	// 同一天重复计息不生效
+	n, err = store.AccrueInterest(context.Background(), AccrueInterestParams{ID: account.ID, Amount: 7, Day: day})
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	result, err := store.CapitalizeInterestTx(context.Background(), account.ID)
+	require.NoError(t, err)
+	require.Equal(t, expense.ID, result.Transfer.FromAccountID)
+	require.Equal(t, int64(7), result.Transfer.Amount)
+	require.Equal(t, "interest", result.Journal.Description)
+	require.Equal(t, account.Balance+7, result.ToAccount.Balance)
+	require.Zero(t, result.ToAccount.AccruedInterest)
+	require.Equal(t, expense.Balance-7, result.FromAccount.Balance)
+}
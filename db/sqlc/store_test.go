@@ -11,8 +11,7 @@ import (
 func TestTransferTx(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1, account2 := createRandomAccountPair(t)
 
 	//跑五个线程并发转账
 	n := 5
@@ -60,6 +59,13 @@ func TestTransferTx(t *testing.T) {
 		_, err = store.GetTransfer(context.Background(), transfer.ID)
 		require.NoError(t, err)
 
+		//校验记账凭证，两条账目都挂在同一个凭证下
+		journal := result.Journal
+		require.NotZero(t, journal.ID)
+		require.Equal(t, transfer.ID, journal.TransferID.Int64)
+		require.Equal(t, journal.ID, result.FromEntry.JournalID)
+		require.Equal(t, journal.ID, result.ToEntry.JournalID)
+
 		//校验用户1的账目记录
 		fromEntry := result.FromEntry
 		require.NotEmpty(t, fromEntry)
@@ -126,8 +132,7 @@ func TestTransferTx(t *testing.T) {
 func TestTransferTxDeadLoad(t *testing.T) {
 	store := NewStore(testDB)
 
-	account1 := createRandomAccount(t)
-	account2 := createRandomAccount(t)
+	account1, account2 := createRandomAccountPair(t)
 
 	n := 10
 	amount := int64(10)
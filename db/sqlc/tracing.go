@@ -0,0 +1,133 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "simplebank/db"
+
+// tracedDBTX 为每条SQL创建span，Querier的每个方法对应一个span；事务内外都使用它
+type tracedDBTX struct {
+	db DBTX
+	// 事务的span，事务内的SQL都作为它的子span
+	txSpan trace.Span
+}
+
+func newTracedDBTX(db DBTX) DBTX {
+	return &tracedDBTX{db: db}
+}
+
+// 事务函数中的SQL使用的是调用方传入的ctx，而不是事务span的ctx，所以在这里指定父span
+func newTracedTx(tx DBTX, txSpan trace.Span) DBTX {
+	return &tracedDBTX{db: tx, txSpan: txSpan}
+}
+
+func (t *tracedDBTX) Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := t.startQuerySpan(ctx, query)
+	tag, err := t.db.Exec(ctx, query, args...)
+	endSpan(span, err)
+	return tag, err
+}
+
+// Query 结果在读取时才从连接上接收，span在rows关闭时结束，读取过程中的错误也会记录
+func (t *tracedDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	ctx, span := t.startQuerySpan(ctx, query)
+	rows, err := t.db.Query(ctx, query, args...)
+	if err != nil {
+		endSpan(span, err)
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+type tracedRows struct {
+	pgx.Rows
+	span   trace.Span
+	closed bool
+}
+
+// Close 可能被调用多次，只在第一次结束span
+func (r *tracedRows) Close() {
+	r.Rows.Close()
+	if r.closed {
+		return
+	}
+	r.closed = true
+	endSpan(r.span, r.Rows.Err())
+}
+
+// QueryRow pgx在Scan时才读取结果，span在Scan后结束，没有结果不算作错误
+func (t *tracedDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	ctx, span := t.startQuerySpan(ctx, query)
+	return &tracedRow{row: t.db.QueryRow(ctx, query, args...), span: span}
+}
+
+// tracedRow 把pgx.ErrNoRows转换为sql.ErrNoRows，调用方统一用sql.ErrNoRows判断记录不存在
+type tracedRow struct {
+	row  pgx.Row
+	span trace.Span
+}
+
+func (r *tracedRow) Scan(dest ...any) error {
+	err := r.row.Scan(dest...)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = sql.ErrNoRows
+		r.span.End()
+		return err
+	}
+	endSpan(r.span, err)
+	return err
+}
+
+func (t *tracedDBTX) startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	if t.txSpan != nil {
+		ctx = trace.ContextWithSpan(ctx, t.txSpan)
+	}
+
+	name := queryName(query)
+	return otel.Tracer(tracerName).Start(ctx, "db."+name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", name),
+		),
+	)
+}
+
+// sqlc生成的SQL以"-- name: GetAccount :one"开头，取出其中的方法名
+func queryName(query string) string {
+	const prefix = "-- name: "
+	if strings.HasPrefix(query, prefix) {
+		fields := strings.Fields(query[len(prefix):])
+		if len(fields) > 0 {
+			return fields[0]
+		}
+	}
+	return "query"
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// 事务的span，结束时记录是提交还是回滚
+func startTxSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, "db.tx."+name,
+		trace.WithAttributes(attribute.String("db.tx.name", name)),
+	)
+}
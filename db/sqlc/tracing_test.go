@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// 测试期间把全局TracerProvider替换为内存中的exporter
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+	})
+	return exporter
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) (tracetest.SpanStub, bool) {
+	for _, span := range spans {
+		if span.Name == name {
+			return span, true
+		}
+	}
+	return tracetest.SpanStub{}, false
+}
+
+func TestQueryName(t *testing.T) {
+	require.Equal(t, "GetAccount", queryName(getAccount))
+	require.Equal(t, "CreateTransfer", queryName(createTransfer))
+	require.Equal(t, "query", queryName("SELECT 1"))
+	require.Equal(t, "query", queryName("-- name: "))
+}
+
+func TestTransferTxSpans(t *testing.T) {
+	exporter := recordSpans(t)
+
+	store := NewStore(testDB)
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	txSpan, ok := findSpan(spans, "db.tx.TransferTx")
+	require.True(t, ok)
+	require.Equal(t, codes.Unset, txSpan.Status.Code)
+	require.Contains(t, txSpan.Attributes, attributeOutcome("commit"))
+
+	// 事务内的SQL是事务span的子span
+	createTransferSpan, ok := findSpan(spans, "db.CreateTransfer")
+	require.True(t, ok)
+	require.Equal(t, txSpan.SpanContext.SpanID(), createTransferSpan.Parent.SpanID())
+}
+
+func TestExecTxRollbackSpan(t *testing.T) {
+	exporter := recordSpans(t)
+
+	store := NewStore(testDB)
+	_, err := store.TransferTx(context.Background(), TransferTxParams{
+		FromAccountID: -1,
+		ToAccountID:   -2,
+		Amount:        10,
+	})
+	require.Error(t, err)
+
+	txSpan, ok := findSpan(exporter.GetSpans(), "db.tx.TransferTx")
+	require.True(t, ok)
+	require.Equal(t, codes.Error, txSpan.Status.Code)
+	require.Contains(t, txSpan.Attributes, attributeOutcome("rollback"))
+}
+
+func attributeOutcome(outcome string) attribute.KeyValue {
+	return attribute.String("db.tx.outcome", outcome)
+}
+
+// 只实现QueryRow，用于不连接数据库测试tracedDBTX
+type rowDBTX struct {
+	DBTX
+	err error
+}
+
+func (db rowDBTX) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
+	return errRow{err: db.err}
+}
+
+type errRow struct {
+	err error
+}
+
+func (row errRow) Scan(dest ...any) error {
+	return row.err
+}
+
+func TestTracedRowNoRows(t *testing.T) {
+	exporter := recordSpans(t)
+
+	q := New(newTracedDBTX(rowDBTX{err: pgx.ErrNoRows}))
+	_, err := q.GetAccount(context.Background(), 1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// 没有结果不算作错误
+	span, ok := findSpan(exporter.GetSpans(), "db.GetAccount")
+	require.True(t, ok)
+	require.Equal(t, codes.Unset, span.Status.Code)
+
+	q = New(newTracedDBTX(rowDBTX{err: errors.New("conn closed")}))
+	_, err = q.GetAccount(context.Background(), 2)
+	require.EqualError(t, err, "conn closed")
+	require.Equal(t, codes.Error, exporter.GetSpans()[1].Status.Code)
+}
+
+// 只实现Query，读取完所有行后返回err
+type rowsDBTX struct {
+	DBTX
+	err error
+}
+
+func (db rowsDBTX) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	return &errRows{err: db.err}, nil
+}
+
+type errRows struct {
+	pgx.Rows
+	err error
+}
+
+func (rows *errRows) Next() bool { return false }
+func (rows *errRows) Err() error { return rows.err }
+func (rows *errRows) Close()     {}
+
+func TestTracedRowsEndSpanOnClose(t *testing.T) {
+	exporter := recordSpans(t)
+
+	rows, err := newTracedDBTX(rowsDBTX{}).Query(context.Background(), listAccounts)
+	require.NoError(t, err)
+	// 读取完成之前span还没有结束
+	require.Empty(t, exporter.GetSpans())
+
+	rows.Close()
+	rows.Close()
+	require.Len(t, exporter.GetSpans(), 1)
+	require.Equal(t, "db.ListAccounts", exporter.GetSpans()[0].Name)
+	require.Equal(t, codes.Unset, exporter.GetSpans()[0].Status.Code)
+
+	// 读取过程中出错时记录在span上
+	q := New(newTracedDBTX(rowsDBTX{err: errors.New("conn reset")}))
+	_, err = q.ListAccounts(context.Background(), ListAccountsParams{Limit: 5})
+	require.EqualError(t, err, "conn reset")
+	require.Len(t, exporter.GetSpans(), 2)
+	require.Equal(t, codes.Error, exporter.GetSpans()[1].Status.Code)
+}
@@ -0,0 +1,43 @@
+package db
+
+import "context"
+
+// TxQuerier 事务中使用的查询：Querier加上直接修改余额和写入账目的操作。
+// 后两者只在TxRunner传给事务函数的TxQuerier上提供，Store上没有
+type TxQuerier interface {
+	Querier
+	AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error)
+	CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error)
+}
+
+// TxRunner 在一个事务中执行fn，fn返回错误时回滚。name为事务方法名，用于监控和隔离级别配置。
+// 实现可以在序列化冲突时重新执行fn
+type TxRunner func(ctx context.Context, name string, fn func(q TxQuerier) error) error
+
+// Transactions 实现Store中的事务方法，PostgreSQL实现和memstore共用同一份事务逻辑，
+// 各自只提供查询和执行事务的TxRunner
+type Transactions struct {
+	runTx   TxRunner
+	metrics Metrics
+}
+
+// NewTransactions metrics为nil时不记录业务指标
+func NewTransactions(runTx TxRunner, metrics Metrics) Transactions {
+	if metrics == nil {
+		metrics = noopMetrics{}
+	}
+	return Transactions{runTx: runTx, metrics: metrics}
+}
+
+// txQueries 为*Queries提供TxQuerier中的记账操作，只在runTx中创建
+type txQueries struct {
+	*Queries
+}
+
+func (q txQueries) AddAccountBalance(ctx context.Context, arg AddAccountBalanceParams) (Account, error) {
+	return q.addAccountBalance(ctx, arg)
+}
+
+func (q txQueries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
+	return q.createEntry(ctx, arg)
+}
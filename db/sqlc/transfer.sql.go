@@ -26,7 +26,7 @@ type CreateTransferParams struct {
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRow(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -44,7 +44,7 @@ WHERE id = $1 LIMIT 1
 `
 
 func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, getTransfer, id)
+	row := q.db.QueryRow(ctx, getTransfer, id)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -74,7 +74,7 @@ type ListTransfersParams struct {
 }
 
 func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([]Transfer, error) {
-	rows, err := q.db.QueryContext(ctx, listTransfers,
+	rows, err := q.db.Query(ctx, listTransfers,
 		arg.FromAccountID,
 		arg.ToAccountID,
 		arg.Limit,
@@ -98,9 +98,67 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 		}
 		items = append(items, i)
 	}
-	if err := rows.Close(); err != nil {
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnbalancedTransfers = `-- name: ListUnbalancedTransfers :many
+SELECT
+  t.id,
+  t.from_account_id,
+  t.to_account_id,
+  t.amount,
+  COUNT(e.id) AS entry_count
+FROM transfers t
+LEFT JOIN journal_transactions j ON j.transfer_id = t.id
+LEFT JOIN entries e ON e.journal_id = j.id
+  AND (
+    (e.account_id = t.from_account_id AND e.amount = -t.amount) OR
+    (e.account_id = t.to_account_id AND e.amount = t.amount)
+  )
+WHERE t.id > $1
+GROUP BY t.id
+HAVING COUNT(e.id) <> 2
+ORDER BY t.id
+LIMIT $2
+`
+
+type ListUnbalancedTransfersParams struct {
+	AfterID   int64 `json:"after_id"`
+	BatchSize int32 `json:"batch_size"`
+}
+
+type ListUnbalancedTransfersRow struct {
+	ID            int64 `json:"id"`
+	FromAccountID int64 `json:"from_account_id"`
+	ToAccountID   int64 `json:"to_account_id"`
+	Amount        int64 `json:"amount"`
+	EntryCount    int64 `json:"entry_count"`
+}
+
+// 每笔转账必须恰好对应出钱方和收钱方两条账目
+func (q *Queries) ListUnbalancedTransfers(ctx context.Context, arg ListUnbalancedTransfersParams) ([]ListUnbalancedTransfersRow, error) {
+	rows, err := q.db.Query(ctx, listUnbalancedTransfers, arg.AfterID, arg.BatchSize)
+	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
+	items := []ListUnbalancedTransfersRow{}
+	for rows.Next() {
+		var i ListUnbalancedTransfersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.EntryCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
 	if err := rows.Err(); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,93 @@
+package db
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WriteTracker 记录每个用户最近一次写操作的时间，配合WithPrimary实现read-your-writes：
+// 用户的数据刚被写过时，该用户的读请求走主库。HTTP和gRPC接口共用同一个实例；
+// 只在当前进程内有效，多副本部署时同一用户的请求落到其他副本上仍可能读到从库的旧数据
+type WriteTracker struct {
+	window    time.Duration
+	mu        sync.Mutex
+	lastWrite map[string]time.Time
+}
+
+// NewWriteTracker window应大于从库的复制延迟，为0时不记录
+func NewWriteTracker(window time.Duration) *WriteTracker {
+	return &WriteTracker{
+		window:    window,
+		lastWrite: make(map[string]time.Time),
+	}
+}
+
+// Record 记录这些用户的数据刚被写过
+func (tracker *WriteTracker) Record(usernames ...string) {
+	if tracker.window <= 0 {
+		return
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	now := time.Now()
+	// 顺便清理已经过期的记录，避免map无限增长
+	for name, at := range tracker.lastWrite {
+		if now.Sub(at) >= tracker.window {
+			delete(tracker.lastWrite, name)
+		}
+	}
+	for _, username := range usernames {
+		if username != "" {
+			tracker.lastWrite[username] = now
+		}
+	}
+}
+
+// RecentlyWrote 用户的数据是否在window内被写过
+func (tracker *WriteTracker) RecentlyWrote(username string) bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	at, ok := tracker.lastWrite[username]
+	return ok && time.Since(at) < tracker.window
+}
+
+type writtenOwnersKey struct{}
+
+type writtenOwners struct {
+	mu     sync.Mutex
+	owners []string
+}
+
+// WithWrittenOwners 在写请求的ctx中收集被修改账户的所有者，例如银行柜员为客户存款时的客户，
+// 请求成功后与操作者一起交给WriteTracker记录
+func WithWrittenOwners(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writtenOwnersKey{}, &writtenOwners{})
+}
+
+// MarkWritten 由处理函数在写操作成功后调用，ctx没有经过WithWrittenOwners时什么都不做
+func MarkWritten(ctx context.Context, owners ...string) {
+	written, ok := ctx.Value(writtenOwnersKey{}).(*writtenOwners)
+	if !ok {
+		return
+	}
+
+	written.mu.Lock()
+	defer written.mu.Unlock()
+	written.owners = append(written.owners, owners...)
+}
+
+// WrittenOwners 返回MarkWritten记录的所有者
+func WrittenOwners(ctx context.Context) []string {
+	written, ok := ctx.Value(writtenOwnersKey{}).(*writtenOwners)
+	if !ok {
+		return nil
+	}
+
+	written.mu.Lock()
+	defer written.mu.Unlock()
+	return append([]string(nil), written.owners...)
+}
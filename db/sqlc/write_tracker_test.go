@@ -0,0 +1,38 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTrackerWindow(t *testing.T) {
+	tracker := NewWriteTracker(50 * time.Millisecond)
+	tracker.Record("alice")
+	require.True(t, tracker.RecentlyWrote("alice"))
+
+	time.Sleep(60 * time.Millisecond)
+	require.False(t, tracker.RecentlyWrote("alice"))
+
+	// 过期的记录在下一次写入时被清理
+	tracker.Record("bob")
+	require.Len(t, tracker.lastWrite, 1)
+
+	// window为0时不记录
+	disabled := NewWriteTracker(0)
+	disabled.Record("alice")
+	require.False(t, disabled.RecentlyWrote("alice"))
+}
+
+func TestWrittenOwners(t *testing.T) {
+	// 没有经过WithWrittenOwners的ctx不记录
+	MarkWritten(context.Background(), "alice")
+	require.Empty(t, WrittenOwners(context.Background()))
+
+	ctx := WithWrittenOwners(context.Background())
+	MarkWritten(ctx, "alice")
+	MarkWritten(ctx, "bob", "carol")
+	require.Equal(t, []string{"alice", "bob", "carol"}, WrittenOwners(ctx))
+}
@@ -0,0 +1,818 @@
+// Package storetest 是db.Store的一致性测试，PostgreSQL实现和memstore运行同一套用例，
+// 保证两者的约束、错误和事务语义一致。
+// 用例不假设数据库为空，所有数据都通过随机用户和账户隔离
+package storetest
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	db "simplebank/db/sqlc"
+	"simplebank/reconcile"
+	"simplebank/token"
+	"simplebank/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Run 对newStore创建的存储运行全部用例，每个用例调用一次newStore
+func Run(t *testing.T, newStore func(t *testing.T) db.Store) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s *suite)
+	}{
+		{"Users", testUsers},
+		{"Accounts", testAccounts},
+		{"AccountNickname", testAccountNickname},
+		{"DeleteAccount", testDeleteAccount},
+		{"AccountHolders", testAccountHolders},
+		{"TransferTx", testTransferTx},
+		{"TransferTxConcurrent", testTransferTxConcurrent},
+		{"TransferTxFrozenAccount", testTransferTxFrozenAccount},
+		{"CashTx", testCashTx},
+		{"Holds", testHolds},
+		{"HeldFundsNotTransferable", testHeldFundsNotTransferable},
+		{"Interest", testInterest},
+		{"GetBalanceAt", testGetBalanceAt},
+		{"LedgerWritesNotExposed", testLedgerWritesNotExposed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.test(t, &suite{store: newStore(t)})
+		})
+	}
+}
+
+type suite struct {
+	store db.Store
+}
+
+func (s *suite) createUser(t *testing.T) db.User {
+	arg := db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       util.RandomOwner(),
+		Email:          util.RandomEmail(),
+	}
+
+	user, err := s.store.CreateUser(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, user.Username)
+	return user
+}
+
+// 创建一个0余额的账户，需要资金时通过deposit存入，保证账目之和等于余额
+func (s *suite) createAccount(t *testing.T, owner string, currency string) db.Account {
+	account, err := s.store.CreateAccount(context.Background(), db.CreateAccountParams{
+		Owner:    owner,
+		Currency: currency,
+		Type:     util.Checking,
+	})
+	require.NoError(t, err)
+	return account
+}
+
+// 两个不同用户的相同币种账户，并各自存入amount
+func (s *suite) createFundedPair(t *testing.T, amount int64) (db.Account, db.Account) {
+	currency := util.RandomCurrency()
+	account1 := s.deposit(t, s.createAccount(t, s.createUser(t).Username, currency), amount)
+	account2 := s.deposit(t, s.createAccount(t, s.createUser(t).Username, currency), amount)
+	return account1, account2
+}
+
+func (s *suite) deposit(t *testing.T, account db.Account, amount int64) db.Account {
+	result, err := s.store.DepositTx(context.Background(), db.CashTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	require.NoError(t, err)
+	return result.ToAccount
+}
+
+func (s *suite) getAccount(t *testing.T, id int64) db.Account {
+	account, err := s.store.GetAccount(context.Background(), id)
+	require.NoError(t, err)
+	return account
+}
+
+// 账户余额等于其账目之和，且账目哈希链完整
+func (s *suite) requireLedgerConsistent(t *testing.T, accountID int64) {
+	rows, err := s.store.ListAccountEntrySums(context.Background(), db.ListAccountEntrySumsParams{
+		AfterID:   accountID - 1,
+		BatchSize: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, accountID, rows[0].AccountID)
+	require.Equal(t, rows[0].Balance, rows[0].EntriesTotal)
+
+	report, err := reconcile.NewReconciler(s.store, 2).VerifyEntryChain(context.Background(), accountID)
+	require.NoError(t, err)
+	require.True(t, report.OK, report.Reason)
+}
+
+func testUsers(t *testing.T, s *suite) {
+	ctx := context.Background()
+	user := s.createUser(t)
+	require.Equal(t, token.DepositorRole, user.Role)
+	require.True(t, user.PasswordChangedAt.IsZero())
+	require.NotZero(t, user.CreatedAt)
+
+	got, err := s.store.GetUser(ctx, user.Username)
+	require.NoError(t, err)
+	require.Equal(t, user.Email, got.Email)
+	require.WithinDuration(t, user.CreatedAt, got.CreatedAt, time.Microsecond)
+
+	_, err = s.store.CreateUser(ctx, db.CreateUserParams{
+		Username:       user.Username,
+		HashedPassword: "secret",
+		FullName:       user.FullName,
+		Email:          util.RandomEmail(),
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrorCode(err))
+
+	_, err = s.store.CreateUser(ctx, db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       user.FullName,
+		Email:          user.Email,
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrorCode(err))
+
+	_, err = s.store.GetUser(ctx, util.RandomString(12))
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	banker, err := s.store.UpdateUserRole(ctx, db.UpdateUserRoleParams{Username: user.Username, Role: token.BankerRole})
+	require.NoError(t, err)
+	require.Equal(t, token.BankerRole, banker.Role)
+
+	_, err = s.store.UpdateUserRole(ctx, db.UpdateUserRoleParams{Username: util.RandomString(12), Role: token.BankerRole})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// 创建时直接指定角色
+	banker, err = s.store.CreateUser(ctx, db.CreateUserParams{
+		Username:       util.RandomOwner(),
+		HashedPassword: "secret",
+		FullName:       user.FullName,
+		Email:          util.RandomEmail(),
+		Role:           sql.NullString{String: token.BankerRole, Valid: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, token.BankerRole, banker.Role)
+}
+
+func testAccounts(t *testing.T, s *suite) {
+	ctx := context.Background()
+	user := s.createUser(t)
+
+	account := s.createAccount(t, user.Username, util.USD)
+	require.NotZero(t, account.ID)
+	require.Zero(t, account.Balance)
+	require.Equal(t, db.AccountStatusActive, account.Status)
+	require.False(t, account.Nickname.Valid)
+	require.False(t, account.InterestAccruedOn.Valid)
+
+	got, err := s.store.GetAccount(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Owner, got.Owner)
+	require.Equal(t, account.Currency, got.Currency)
+
+	// 同一用户可以有多个相同币种的账户
+	second := s.createAccount(t, user.Username, util.USD)
+	require.Greater(t, second.ID, account.ID)
+
+	byCurrency, err := s.store.GetAccountByOwnerAndCurrency(ctx, db.GetAccountByOwnerAndCurrencyParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.ID, byCurrency.ID)
+
+	accounts, err := s.store.ListAccounts(ctx, db.ListAccountsParams{Owner: user.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, accounts, 2)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	accounts, err = s.store.ListAccounts(ctx, db.ListAccountsParams{Owner: user.Username, Limit: 5, Offset: 2})
+	require.NoError(t, err)
+	require.NotNil(t, accounts)
+	require.Empty(t, accounts)
+
+	_, err = s.store.GetAccount(ctx, -1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = s.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    util.RandomString(12),
+		Currency: util.USD,
+		Type:     util.Checking,
+	})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrorCode(err))
+
+	_, err = s.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    user.Username,
+		Currency: util.USD,
+		Type:     "brokerage",
+	})
+	require.Equal(t, db.CheckViolation, db.ErrorCode(err))
+
+	// 银行内部用户每种币种只能有一个账户
+	_, err = s.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    db.SettlementOwner,
+		Currency: util.USD,
+		Type:     util.Checking,
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrorCode(err))
+
+	frozen, err := s.store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{ID: account.ID, Status: db.AccountStatusFrozen})
+	require.NoError(t, err)
+	require.Equal(t, db.AccountStatusFrozen, frozen.Status)
+
+	_, err = s.store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{ID: account.ID, Status: "closed"})
+	require.Equal(t, db.CheckViolation, db.ErrorCode(err))
+
+	_, err = s.store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{ID: -1, Status: db.AccountStatusFrozen})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testAccountNickname(t *testing.T, s *suite) {
+	ctx := context.Background()
+	user := s.createUser(t)
+	nickname := sql.NullString{String: "rent", Valid: true}
+
+	account1 := s.createAccount(t, user.Username, util.USD)
+	account2 := s.createAccount(t, user.Username, util.EUR)
+
+	updated, err := s.store.UpdateAccountNickname(ctx, db.UpdateAccountNicknameParams{ID: account1.ID, Nickname: nickname})
+	require.NoError(t, err)
+	require.Equal(t, nickname, updated.Nickname)
+
+	// 昵称在同一用户下唯一
+	_, err = s.store.UpdateAccountNickname(ctx, db.UpdateAccountNicknameParams{ID: account2.ID, Nickname: nickname})
+	require.Equal(t, db.UniqueViolation, db.ErrorCode(err))
+
+	_, err = s.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    user.Username,
+		Currency: util.CAD,
+		Type:     util.Savings,
+		Nickname: nickname,
+	})
+	require.Equal(t, db.UniqueViolation, db.ErrorCode(err))
+
+	// 其他用户可以使用相同的昵称，没有昵称的账户不受限制
+	other := s.createUser(t)
+	_, err = s.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    other.Username,
+		Currency: util.USD,
+		Type:     util.Checking,
+		Nickname: nickname,
+	})
+	require.NoError(t, err)
+
+	updated, err = s.store.UpdateAccountNickname(ctx, db.UpdateAccountNicknameParams{ID: account1.ID})
+	require.NoError(t, err)
+	require.False(t, updated.Nickname.Valid)
+
+	_, err = s.store.UpdateAccountNickname(ctx, db.UpdateAccountNicknameParams{ID: -1, Nickname: nickname})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testDeleteAccount(t *testing.T, s *suite) {
+	ctx := context.Background()
+	user := s.createUser(t)
+
+	unused := s.createAccount(t, user.Username, util.USD)
+	require.NoError(t, s.store.DeleteAccount(ctx, unused.ID))
+
+	_, err := s.store.GetAccount(ctx, unused.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	// 删除不存在的账户不报错
+	require.NoError(t, s.store.DeleteAccount(ctx, unused.ID))
+
+	// 有账目的账户不能删除
+	funded := s.deposit(t, s.createAccount(t, user.Username, util.USD), 100)
+	err = s.store.DeleteAccount(ctx, funded.ID)
+	require.Equal(t, db.ForeignKeyViolation, db.ErrorCode(err))
+	s.getAccount(t, funded.ID)
+}
+
+func testAccountHolders(t *testing.T, s *suite) {
+	ctx := context.Background()
+	owner := s.createUser(t)
+	holder := s.createUser(t)
+	account := s.createAccount(t, owner.Username, util.USD)
+
+	invite := db.CreateAccountHolderParams{
+		AccountID:  account.ID,
+		Username:   holder.Username,
+		Permission: db.PermissionView,
+		InvitedBy:  owner.Username,
+	}
+	invited, err := s.store.CreateAccountHolder(ctx, invite)
+	require.NoError(t, err)
+	require.Equal(t, db.HolderStatusInvited, invited.Status)
+	require.False(t, invited.AcceptedAt.Valid)
+
+	_, err = s.store.CreateAccountHolder(ctx, invite)
+	require.Equal(t, db.UniqueViolation, db.ErrorCode(err))
+
+	badPermission := invite
+	badPermission.Username = s.createUser(t).Username
+	badPermission.Permission = db.PermissionManage
+	_, err = s.store.CreateAccountHolder(ctx, badPermission)
+	require.Equal(t, db.CheckViolation, db.ErrorCode(err))
+
+	unknownUser := invite
+	unknownUser.Username = util.RandomString(12)
+	_, err = s.store.CreateAccountHolder(ctx, unknownUser)
+	require.Equal(t, db.ForeignKeyViolation, db.ErrorCode(err))
+
+	// 接受邀请前联名账户不可见，也没有权限
+	accounts, err := s.store.ListAccounts(ctx, db.ListAccountsParams{Owner: holder.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Empty(t, accounts)
+
+	ok, err := db.CanAccess(ctx, s.store, holder.Username, account, db.PermissionView)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	accepted, err := s.store.AcceptAccountHolder(ctx, db.AcceptAccountHolderParams{AccountID: account.ID, Username: holder.Username})
+	require.NoError(t, err)
+	require.Equal(t, db.HolderStatusAccepted, accepted.Status)
+	require.True(t, accepted.AcceptedAt.Valid)
+
+	_, err = s.store.AcceptAccountHolder(ctx, db.AcceptAccountHolderParams{AccountID: account.ID, Username: holder.Username})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	accounts, err = s.store.ListAccounts(ctx, db.ListAccountsParams{Owner: holder.Username, Limit: 5})
+	require.NoError(t, err)
+	require.Len(t, accounts, 1)
+	require.Equal(t, account.ID, accounts[0].ID)
+
+	ok, err = db.CanAccess(ctx, s.store, holder.Username, account, db.PermissionView)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	ok, err = db.CanAccess(ctx, s.store, holder.Username, account, db.PermissionTransfer)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	holders, err := s.store.ListAccountHolders(ctx, account.ID)
+	require.NoError(t, err)
+	require.Len(t, holders, 1)
+	require.Equal(t, holder.Username, holders[0].Username)
+
+	_, err = s.store.GetAccountHolder(ctx, db.GetAccountHolderParams{AccountID: account.ID, Username: owner.Username})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func testTransferTx(t *testing.T, s *suite) {
+	ctx := context.Background()
+	account1, account2 := s.createFundedPair(t, 100)
+
+	result, err := s.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        30,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, account1.ID, result.Transfer.FromAccountID)
+	require.Equal(t, account2.ID, result.Transfer.ToAccountID)
+	require.Equal(t, int64(30), result.Transfer.Amount)
+	require.Equal(t, result.Transfer.ID, result.Journal.TransferID.Int64)
+	require.Equal(t, "transfer", result.Journal.Description)
+
+	require.Equal(t, int64(70), result.FromAccount.Balance)
+	require.Equal(t, int64(130), result.ToAccount.Balance)
+	require.Equal(t, int64(-30), result.FromEntry.Amount)
+	require.Equal(t, int64(30), result.ToEntry.Amount)
+	require.Greater(t, result.ToEntry.ID, result.FromEntry.ID)
+
+	transfer, err := s.store.GetTransfer(ctx, result.Transfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.Amount, transfer.Amount)
+
+	entries, err := s.store.ListJournalEntries(ctx, result.Journal.ID)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Zero(t, entries[0].Amount+entries[1].Amount)
+
+	transfers, err := s.store.ListTransfers(ctx, db.ListTransfersParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account2.ID,
+		Limit:         5,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 2)
+	require.Equal(t, result.Transfer.ID, transfers[1].ID)
+
+	// 对账单中转账账目的对手方是另一个账户
+	statement, err := s.store.ListStatementEntries(ctx, db.ListStatementEntriesParams{
+		AccountID: account2.ID,
+		FromTime:  result.ToEntry.CreatedAt,
+		ToTime:    result.ToEntry.CreatedAt.Add(time.Microsecond),
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, statement)
+	last := statement[len(statement)-1]
+	require.Equal(t, result.ToEntry.ID, last.ID)
+	require.Equal(t, account1.ID, last.CounterpartyAccountID.Int64)
+	require.Equal(t, account1.Owner, last.CounterpartyOwner.String)
+
+	_, err = s.store.GetTransfer(ctx, -1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = s.store.TransferTx(ctx, db.TransferTxParams{FromAccountID: account1.ID, ToAccountID: -1, Amount: 10})
+	require.Equal(t, db.ForeignKeyViolation, db.ErrorCode(err))
+	require.Equal(t, int64(70), s.getAccount(t, account1.ID).Balance)
+
+	s.requireLedgerConsistent(t, account1.ID)
+	s.requireLedgerConsistent(t, account2.ID)
+}
+
+// 双向并发转账不会死锁，结束后余额和账目哈希链都正确
+func testTransferTxConcurrent(t *testing.T, s *suite) {
+	account1, account2 := s.createFundedPair(t, 1000)
+
+	n := 10
+	amount := int64(10)
+	errs := make(chan error, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		from, to := account1.ID, account2.ID
+		if i%2 == 1 {
+			from, to = to, from
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.store.TransferTx(context.Background(), db.TransferTxParams{
+				FromAccountID: from,
+				ToAccountID:   to,
+				Amount:        amount,
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, account1.Balance, s.getAccount(t, account1.ID).Balance)
+	require.Equal(t, account2.Balance, s.getAccount(t, account2.ID).Balance)
+
+	s.requireLedgerConsistent(t, account1.ID)
+	s.requireLedgerConsistent(t, account2.ID)
+}
+
+// 转账中途失败时整个事务回滚，不会留下转账记录、凭证或余额变化
+func testTransferTxFrozenAccount(t *testing.T, s *suite) {
+	ctx := context.Background()
+	account1, account2 := s.createFundedPair(t, 100)
+
+	_, err := s.store.UpdateAccountStatus(ctx, db.UpdateAccountStatusParams{ID: account1.ID, Status: db.AccountStatusFrozen})
+	require.NoError(t, err)
+
+	_, err = s.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+	})
+	require.ErrorIs(t, err, db.ErrAccountFrozen)
+
+	require.Equal(t, account1.Balance, s.getAccount(t, account1.ID).Balance)
+	require.Equal(t, account2.Balance, s.getAccount(t, account2.ID).Balance)
+
+	// 只有存款时的一笔转账
+	transfers, err := s.store.ListTransfers(ctx, db.ListTransfersParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account1.ID,
+		Limit:         5,
+	})
+	require.NoError(t, err)
+	require.Len(t, transfers, 1)
+
+	_, err = s.store.WithdrawTx(ctx, db.CashTxParams{AccountID: account1.ID, Amount: 10})
+	require.ErrorIs(t, err, db.ErrAccountFrozen)
+
+	_, err = s.store.PlaceHoldTx(ctx, db.PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      10,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, db.ErrAccountFrozen)
+
+	// 冻结账户仍然可以收款
+	result, err := s.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        10,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance+10, result.ToAccount.Balance)
+
+	s.requireLedgerConsistent(t, account1.ID)
+	s.requireLedgerConsistent(t, account2.ID)
+}
+
+func testCashTx(t *testing.T, s *suite) {
+	ctx := context.Background()
+	account := s.createAccount(t, s.createUser(t).Username, util.RandomCurrency())
+
+	deposit, err := s.store.DepositTx(ctx, db.CashTxParams{AccountID: account.ID, Amount: 100})
+	require.NoError(t, err)
+	require.Equal(t, int64(100), deposit.ToAccount.Balance)
+	require.Equal(t, db.SettlementOwner, deposit.FromAccount.Owner)
+	require.Equal(t, account.Currency, deposit.FromAccount.Currency)
+
+	_, err = s.store.WithdrawTx(ctx, db.CashTxParams{AccountID: account.ID, Amount: 101})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+
+	withdraw, err := s.store.WithdrawTx(ctx, db.CashTxParams{AccountID: account.ID, Amount: 40})
+	require.NoError(t, err)
+	require.Equal(t, int64(60), withdraw.FromAccount.Balance)
+	require.Equal(t, deposit.FromAccount.ID, withdraw.ToAccount.ID)
+
+	_, err = s.store.DepositTx(ctx, db.CashTxParams{AccountID: deposit.FromAccount.ID, Amount: 10})
+	require.ErrorIs(t, err, db.ErrSettlementAccount)
+
+	_, err = s.store.DepositTx(ctx, db.CashTxParams{AccountID: -1, Amount: 10})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	s.requireLedgerConsistent(t, account.ID)
+}
+
+func testHolds(t *testing.T, s *suite) {
+	ctx := context.Background()
+	account1, account2 := s.createFundedPair(t, 100)
+
+	_, err := s.store.PlaceHoldTx(ctx, db.PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      101,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+
+	hold, err := s.store.PlaceHoldTx(ctx, db.PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      60,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	require.Equal(t, db.HoldStatusActive, hold.Status)
+	require.Zero(t, hold.CapturedAmount)
+
+	held, err := s.store.GetActiveHoldsAmount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(60), held)
+
+	// 预授权占用的金额不能取出
+	_, err = s.store.WithdrawTx(ctx, db.CashTxParams{AccountID: account1.ID, Amount: 50})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+
+	_, err = s.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{HoldID: hold.ID, Amount: 61})
+	require.ErrorIs(t, err, db.ErrHoldAmountExceeded)
+
+	captured, err := s.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{HoldID: hold.ID, Amount: 45})
+	require.NoError(t, err)
+	require.Equal(t, db.HoldStatusCaptured, captured.Hold.Status)
+	require.Equal(t, int64(45), captured.Hold.CapturedAmount)
+	require.Equal(t, captured.Transfer.Transfer.ID, captured.Hold.TransferID.Int64)
+	require.Equal(t, int64(55), captured.Transfer.FromAccount.Balance)
+
+	_, err = s.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{HoldID: hold.ID, Amount: 10})
+	require.ErrorIs(t, err, db.ErrHoldNotActive)
+
+	_, err = s.store.ReleaseHold(ctx, hold.ID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	held, err = s.store.GetActiveHoldsAmount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, held)
+
+	// 释放和过期
+	released, err := s.store.PlaceHoldTx(ctx, db.PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      10,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	released, err = s.store.ReleaseHold(ctx, released.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.HoldStatusReleased, released.Status)
+
+	expired, err := s.store.PlaceHoldTx(ctx, db.PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      10,
+		ExpiresAt:   time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	held, err = s.store.GetActiveHoldsAmount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Zero(t, held)
+
+	// 已过期但还没有被ReleaseExpiredHolds处理的预授权不能扣款，转账随之回滚
+	before := s.getAccount(t, account1.ID)
+	_, err = s.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{HoldID: expired.ID, Amount: 10})
+	require.ErrorIs(t, err, db.ErrHoldNotActive)
+	require.Equal(t, before.Balance, s.getAccount(t, account1.ID).Balance)
+
+	_, err = s.store.ReleaseExpiredHolds(ctx)
+	require.NoError(t, err)
+
+	expired, err = s.store.GetHold(ctx, expired.ID)
+	require.NoError(t, err)
+	require.Equal(t, db.HoldStatusExpired, expired.Status)
+
+	_, err = s.store.GetHold(ctx, -1)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	_, err = s.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{HoldID: -1, Amount: 10})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+
+	s.requireLedgerConsistent(t, account1.ID)
+	s.requireLedgerConsistent(t, account2.ID)
+}
+
+// 预授权占用的金额不能被普通转账转走，扣款时不会透支
+func testHeldFundsNotTransferable(t *testing.T, s *suite) {
+	ctx := context.Background()
+	account1, account2 := s.createFundedPair(t, 100)
+
+	hold, err := s.store.PlaceHoldTx(ctx, db.PlaceHoldTxParams{
+		AccountID:   account1.ID,
+		ToAccountID: account2.ID,
+		Amount:      60,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+
+	_, err = s.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        41,
+	})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+	require.Equal(t, int64(100), s.getAccount(t, account1.ID).Balance)
+
+	// 可用余额之内的部分可以转出
+	_, err = s.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        40,
+	})
+	require.NoError(t, err)
+
+	// 扣款时本次的预授权不计入占用金额
+	captured, err := s.store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{HoldID: hold.ID, Amount: 60})
+	require.NoError(t, err)
+	require.Zero(t, captured.Transfer.FromAccount.Balance)
+
+	// 没有预授权时也不能透支
+	_, err = s.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+	})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+
+	s.requireLedgerConsistent(t, account1.ID)
+	s.requireLedgerConsistent(t, account2.ID)
+}
+
+func testInterest(t *testing.T, s *suite) {
+	ctx := context.Background()
+	account := s.deposit(t, s.createAccount(t, s.createUser(t).Username, util.RandomCurrency()), 1000)
+	day := time.Now().UTC()
+
+	_, err := s.store.CapitalizeInterestTx(ctx, account.ID)
+	require.ErrorIs(t, err, db.ErrNoAccruedInterest)
+
+	// 每个账户每天只计一次息
+	rows, err := s.store.AccrueInterest(ctx, db.AccrueInterestParams{ID: account.ID, Amount: 7, Day: day})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	rows, err = s.store.AccrueInterest(ctx, db.AccrueInterestParams{ID: account.ID, Amount: 7, Day: day})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	accrued := s.getAccount(t, account.ID)
+	require.Equal(t, int64(7), accrued.AccruedInterest)
+	require.True(t, accrued.InterestAccruedOn.Valid)
+	require.True(t, db.SnapshotDate(day).Equal(db.SnapshotDate(accrued.InterestAccruedOn.Time)))
+
+	pending, err := s.store.ListAccountsForAccrual(ctx, db.ListAccountsForAccrualParams{
+		Type:      util.Checking,
+		Day:       day,
+		AfterID:   account.ID - 1,
+		BatchSize: 1,
+	})
+	require.NoError(t, err)
+	for _, other := range pending {
+		require.NotEqual(t, account.ID, other.ID)
+	}
+
+	withInterest, err := s.store.ListAccountsWithAccruedInterest(ctx, db.ListAccountsWithAccruedInterestParams{
+		AfterID:   account.ID - 1,
+		BatchSize: 1,
+	})
+	require.NoError(t, err)
+	require.Len(t, withInterest, 1)
+	require.Equal(t, account.ID, withInterest[0].ID)
+
+	result, err := s.store.CapitalizeInterestTx(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, int64(1007), result.ToAccount.Balance)
+	require.Zero(t, result.ToAccount.AccruedInterest)
+	require.Equal(t, db.InterestOwner, result.FromAccount.Owner)
+	require.Equal(t, "interest", result.Journal.Description)
+
+	_, err = s.store.CapitalizeInterestTx(ctx, account.ID)
+	require.ErrorIs(t, err, db.ErrNoAccruedInterest)
+
+	s.requireLedgerConsistent(t, account.ID)
+}
+
+// 不依赖日终快照（快照是全局的，不在共享数据库上生成），只验证按账目计算的历史余额
+func testGetBalanceAt(t *testing.T, s *suite) {
+	ctx := context.Background()
+	account1, account2 := s.createFundedPair(t, 100)
+
+	result, err := s.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        25,
+	})
+	require.NoError(t, err)
+
+	// at不含当时的账目
+	before, err := s.store.GetBalanceAt(ctx, account1.ID, result.FromEntry.CreatedAt)
+	require.NoError(t, err)
+	require.Equal(t, int64(100), before)
+
+	after, err := s.store.GetBalanceAt(ctx, account1.ID, result.FromEntry.CreatedAt.Add(time.Microsecond))
+	require.NoError(t, err)
+	require.Equal(t, int64(75), after)
+
+	initial, err := s.store.GetBalanceAt(ctx, account1.ID, account1.CreatedAt)
+	require.NoError(t, err)
+	require.Zero(t, initial)
+
+	// 开户时的初始余额没有对应账目，作为期初余额从开户时起计入
+	opened, err := s.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    s.createUser(t).Username,
+		Balance:  50,
+		Currency: account1.Currency,
+		Type:     util.Checking,
+	})
+	require.NoError(t, err)
+	opened = s.deposit(t, opened, 20)
+	require.Equal(t, int64(70), opened.Balance)
+
+	initial, err = s.store.GetBalanceAt(ctx, opened.ID, opened.CreatedAt)
+	require.NoError(t, err)
+	require.Zero(t, initial)
+
+	balance, err := s.store.GetBalanceAt(ctx, opened.ID, opened.CreatedAt.Add(time.Microsecond))
+	require.NoError(t, err)
+	require.Equal(t, int64(50), balance)
+
+	balance, err = s.store.GetBalanceAt(ctx, opened.ID, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, int64(70), balance)
+
+	_, err = s.store.GetLatestBalanceSnapshot(ctx, db.GetLatestBalanceSnapshotParams{
+		AccountID:  account1.ID,
+		BeforeDate: time.Now(),
+	})
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+// 余额和账目只能由事务方法随记账凭证一起修改，Store接口和具体实现上都不能有直接修改的方法
+func testLedgerWritesNotExposed(t *testing.T, s *suite) {
+	for _, typ := range []reflect.Type{reflect.TypeOf((*db.Store)(nil)).Elem(), reflect.TypeOf(s.store)} {
+		for _, name := range []string{"AddAccountBalance", "CreateEntry"} {
+			_, ok := typ.MethodByName(name)
+			require.False(t, ok, "%s exposes %s", typ, name)
+		}
+	}
+}
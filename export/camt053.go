@@ -0,0 +1,187 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"simplebank/statement"
+	"simplebank/util"
+	"strconv"
+	"time"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+type camtDocument struct {
+	XMLName   xml.Name      `xml:"Document"`
+	Namespace string        `xml:"xmlns,attr"`
+	Statement camtBkToCstmr `xml:"BkToCstmrStmt"`
+}
+
+type camtBkToCstmr struct {
+	GrpHdr camtGrpHdr `xml:"GrpHdr"`
+	Stmt   camtStmt   `xml:"Stmt"`
+}
+
+type camtGrpHdr struct {
+	MsgID   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type camtStmt struct {
+	ID      string        `xml:"Id"`
+	CreDtTm string        `xml:"CreDtTm"`
+	FrToDt  camtFrToDt    `xml:"FrToDt"`
+	Acct    camtAcct      `xml:"Acct"`
+	Bal     []camtBalance `xml:"Bal"`
+	Ntry    []camtEntry   `xml:"Ntry"`
+}
+
+type camtFrToDt struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type camtAcct struct {
+	ID   string `xml:"Id>Othr>Id"`
+	Ccy  string `xml:"Ccy"`
+	Ownr string `xml:"Ownr>Nm"`
+}
+
+type camtAmount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       camtAmount `xml:"Amt"`
+	CdtDbtInd string     `xml:"CdtDbtInd"`
+	Dt        string     `xml:"Dt>Dt"`
+}
+
+type camtEntry struct {
+	NtryRef   string        `xml:"NtryRef"`
+	Amt       camtAmount    `xml:"Amt"`
+	CdtDbtInd string        `xml:"CdtDbtInd"`
+	Sts       string        `xml:"Sts"`
+	BookgDt   string        `xml:"BookgDt>DtTm"`
+	ValDt     string        `xml:"ValDt>DtTm"`
+	BkTxCd    string        `xml:"BkTxCd>Prtry>Cd"`
+	TxDtls    camtTxDetails `xml:"NtryDtls>TxDtls"`
+}
+
+type camtTxDetails struct {
+	Refs      *camtRefs      `xml:"Refs,omitempty"`
+	RltdPties *camtRltdPties `xml:"RltdPties,omitempty"`
+	AddtlInf  string         `xml:"AddtlTxInf,omitempty"`
+}
+
+type camtRefs struct {
+	EndToEndID string `xml:"EndToEndId"`
+}
+
+type camtRltdPties struct {
+	Dbtr     *camtParty   `xml:"Dbtr,omitempty"`
+	DbtrAcct *camtAccount `xml:"DbtrAcct,omitempty"`
+	Cdtr     *camtParty   `xml:"Cdtr,omitempty"`
+	CdtrAcct *camtAccount `xml:"CdtrAcct,omitempty"`
+}
+
+type camtParty struct {
+	Nm string `xml:"Nm"`
+}
+
+type camtAccount struct {
+	ID string `xml:"Id>Othr>Id"`
+}
+
+// WriteCAMT053 输出ISO 20022 camt.053.001.02格式的银行对账单
+func WriteCAMT053(w io.Writer, stmt statement.Statement, generatedAt time.Time) error {
+	statementID := fmt.Sprintf("%d-%s-%s", stmt.AccountID, stmt.PeriodStart.Format("20060102"), stmt.PeriodEnd.Format("20060102"))
+	created := camtTime(generatedAt)
+
+	entries := make([]camtEntry, 0, len(stmt.Lines))
+	for _, line := range stmt.Lines {
+		amount, indicator := camtAmountOf(line.Amount, stmt.Currency)
+
+		details := camtTxDetails{AddtlInf: line.Description}
+		if line.TransferID != 0 {
+			details.Refs = &camtRefs{EndToEndID: strconv.FormatInt(line.TransferID, 10)}
+		}
+		if line.CounterpartyAccountID != 0 {
+			// 入账时对手方是付款人，出账时对手方是收款人
+			party := &camtParty{Nm: line.CounterpartyOwner}
+			account := &camtAccount{ID: strconv.FormatInt(line.CounterpartyAccountID, 10)}
+			if indicator == "CRDT" {
+				details.RltdPties = &camtRltdPties{Dbtr: party, DbtrAcct: account}
+			} else {
+				details.RltdPties = &camtRltdPties{Cdtr: party, CdtrAcct: account}
+			}
+		}
+
+		entries = append(entries, camtEntry{
+			NtryRef:   strconv.FormatInt(line.EntryID, 10),
+			Amt:       camtAmount{Ccy: stmt.Currency, Value: amount},
+			CdtDbtInd: indicator,
+			Sts:       "BOOK",
+			BookgDt:   camtTime(line.Date),
+			ValDt:     camtTime(line.Date),
+			BkTxCd:    line.Description,
+			TxDtls:    details,
+		})
+	}
+
+	doc := camtDocument{
+		Namespace: camt053Namespace,
+		Statement: camtBkToCstmr{
+			GrpHdr: camtGrpHdr{
+				MsgID:   statementID,
+				CreDtTm: created,
+			},
+			Stmt: camtStmt{
+				ID:      statementID,
+				CreDtTm: created,
+				FrToDt: camtFrToDt{
+					FrDtTm: camtTime(stmt.PeriodStart),
+					// 区间右开，ToDtTm取最后一秒
+					ToDtTm: camtTime(stmt.PeriodEnd.Add(-time.Second)),
+				},
+				Acct: camtAcct{
+					ID:   strconv.FormatInt(stmt.AccountID, 10),
+					Ccy:  stmt.Currency,
+					Ownr: stmt.Owner,
+				},
+				Bal: []camtBalance{
+					camtBalanceOf("OPBD", stmt.Currency, stmt.OpeningBalance, stmt.PeriodStart),
+					camtBalanceOf("CLBD", stmt.Currency, stmt.ClosingBalance, stmt.PeriodEnd.AddDate(0, 0, -1)),
+				},
+				Ntry: entries,
+			},
+		},
+	}
+
+	return writeXML(w, xml.Header, doc)
+}
+
+// camt中金额总是非负数，方向由CdtDbtInd表示
+func camtAmountOf(amount int64, currency string) (string, string) {
+	if amount < 0 {
+		return util.FormatAmount(-amount, currency), "DBIT"
+	}
+	return util.FormatAmount(amount, currency), "CRDT"
+}
+
+func camtBalanceOf(code string, currency string, amount int64, date time.Time) camtBalance {
+	value, indicator := camtAmountOf(amount, currency)
+	return camtBalance{
+		Code:      code,
+		Amt:       camtAmount{Ccy: currency, Value: value},
+		CdtDbtInd: indicator,
+		Dt:        date.UTC().Format(DateLayout),
+	}
+}
+
+func camtTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05Z")
+}
@@ -0,0 +1,74 @@
+package export
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"simplebank/statement"
+	"time"
+)
+
+const (
+	FormatOFX     = "ofx"
+	FormatCAMT053 = "camt053"
+)
+
+// 导出文件中的银行标识
+const BankID = "SIMPLEBANK"
+
+const DateLayout = "2006-01-02"
+
+var ErrInvalidRange = errors.New("from and to must be YYYY-MM-DD dates with from <= to")
+
+// ParseRange 解析起止日期（均包含在内），返回左闭右开的UTC时间区间
+func ParseRange(from string, to string) (start time.Time, end time.Time, err error) {
+	start, err = time.Parse(DateLayout, from)
+	if err != nil {
+		return start, end, ErrInvalidRange
+	}
+
+	last, err := time.Parse(DateLayout, to)
+	if err != nil || last.Before(start) {
+		return start, end, ErrInvalidRange
+	}
+
+	end = last.AddDate(0, 0, 1)
+	return start, end, nil
+}
+
+// Write 按格式输出，generatedAt是文件的生成时间
+func Write(w io.Writer, format string, stmt statement.Statement, generatedAt time.Time) error {
+	switch format {
+	case FormatCAMT053:
+		return WriteCAMT053(w, stmt, generatedAt)
+	default:
+		return WriteOFX(w, stmt, generatedAt)
+	}
+}
+
+// ContentType 返回格式对应的MIME类型和文件扩展名
+func ContentType(format string) (string, string) {
+	switch format {
+	case FormatCAMT053:
+		return "application/xml", "xml"
+	default:
+		return "application/x-ofx", "ofx"
+	}
+}
+
+func writeXML(w io.Writer, header string, v interface{}) error {
+	_, err := io.WriteString(w, header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(v)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
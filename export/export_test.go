@@ -0,0 +1,101 @@
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"flag"
+	"os"
+	"path/filepath"
+	db "simplebank/db/sqlc"
+	"simplebank/statement"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// go test ./export -update 重新生成golden文件
+var update = flag.Bool("update", false, "update golden files")
+
+func testStatement(t *testing.T) statement.Statement {
+	account := db.Account{ID: 42, Owner: "bob", Currency: "USD"}
+	start, end, err := ParseRange("2026-03-01", "2026-03-31")
+	require.NoError(t, err)
+
+	entries := []db.ListStatementEntriesRow{
+		{
+			ID:                    101,
+			AccountID:             account.ID,
+			Amount:                250,
+			CreatedAt:             time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC),
+			Description:           "transfer",
+			TransferID:            sql.NullInt64{Int64: 7, Valid: true},
+			CounterpartyAccountID: sql.NullInt64{Int64: 43, Valid: true},
+			CounterpartyOwner:     sql.NullString{String: "alice", Valid: true},
+		},
+		{
+			ID:                    102,
+			AccountID:             account.ID,
+			Amount:                -100,
+			CreatedAt:             time.Date(2026, 3, 15, 18, 5, 12, 0, time.UTC),
+			Description:           "transfer",
+			TransferID:            sql.NullInt64{Int64: 8, Valid: true},
+			CounterpartyAccountID: sql.NullInt64{Int64: 44, Valid: true},
+			CounterpartyOwner:     sql.NullString{String: "carol", Valid: true},
+		},
+		{
+			ID:          103,
+			AccountID:   account.ID,
+			Amount:      -30,
+			CreatedAt:   time.Date(2026, 3, 31, 23, 59, 59, 0, time.UTC),
+			Description: "withdraw",
+		},
+	}
+
+	return statement.Build(account, start, end, 1000, entries)
+}
+
+func TestParseRange(t *testing.T) {
+	start, end, err := ParseRange("2026-03-01", "2026-03-01")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), start)
+	require.Equal(t, time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC), end)
+
+	_, _, err = ParseRange("2026-03-02", "2026-03-01")
+	require.ErrorIs(t, err, ErrInvalidRange)
+
+	_, _, err = ParseRange("2026/03/01", "2026-03-01")
+	require.ErrorIs(t, err, ErrInvalidRange)
+}
+
+func TestWriteGolden(t *testing.T) {
+	generatedAt := time.Date(2026, 4, 1, 6, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		format string
+		golden string
+	}{
+		{format: FormatOFX, golden: "statement.ofx.golden"},
+		{format: FormatCAMT053, golden: "statement.camt053.golden"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.format, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := Write(&buf, tc.format, testStatement(t), generatedAt)
+			require.NoError(t, err)
+
+			path := filepath.Join("testdata", tc.golden)
+			if *update {
+				err = os.WriteFile(path, buf.Bytes(), 0644)
+				require.NoError(t, err)
+			}
+
+			want, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, string(want), buf.String())
+		})
+	}
+}
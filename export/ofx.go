@@ -0,0 +1,140 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"simplebank/statement"
+	"simplebank/util"
+	"strconv"
+	"time"
+)
+
+const ofxHeader = xml.Header + `<?OFX OFXHEADER="200" VERSION="220" SECURITY="NONE" OLDFILEUID="NONE" NEWFILEUID="NONE"?>` + "\n"
+
+type ofxDocument struct {
+	XMLName xml.Name      `xml:"OFX"`
+	SignOn  ofxSignOn     `xml:"SIGNONMSGSRSV1>SONRS"`
+	Bank    ofxStmtTrnRes `xml:"BANKMSGSRSV1>STMTTRNRS"`
+}
+
+type ofxStatus struct {
+	Code     int    `xml:"CODE"`
+	Severity string `xml:"SEVERITY"`
+}
+
+type ofxSignOn struct {
+	Status   ofxStatus `xml:"STATUS"`
+	DTServer string    `xml:"DTSERVER"`
+	Language string    `xml:"LANGUAGE"`
+}
+
+type ofxStmtTrnRes struct {
+	TrnUID string    `xml:"TRNUID"`
+	Status ofxStatus `xml:"STATUS"`
+	StmtRs ofxStmtRs `xml:"STMTRS"`
+}
+
+type ofxStmtRs struct {
+	CurDef       string      `xml:"CURDEF"`
+	BankAcctFrom ofxBankAcct `xml:"BANKACCTFROM"`
+	TranList     ofxTranList `xml:"BANKTRANLIST"`
+	LedgerBal    ofxBalance  `xml:"LEDGERBAL"`
+}
+
+type ofxBankAcct struct {
+	BankID   string `xml:"BANKID"`
+	AcctID   string `xml:"ACCTID"`
+	AcctType string `xml:"ACCTTYPE"`
+}
+
+type ofxTranList struct {
+	DTStart      string       `xml:"DTSTART"`
+	DTEnd        string       `xml:"DTEND"`
+	Transactions []ofxStmtTrn `xml:"STMTTRN"`
+}
+
+type ofxStmtTrn struct {
+	TrnType  string `xml:"TRNTYPE"`
+	DTPosted string `xml:"DTPOSTED"`
+	TrnAmt   string `xml:"TRNAMT"`
+	FitID    string `xml:"FITID"`
+	Name     string `xml:"NAME,omitempty"`
+	Memo     string `xml:"MEMO,omitempty"`
+}
+
+type ofxBalance struct {
+	BalAmt string `xml:"BALAMT"`
+	DTAsOf string `xml:"DTASOF"`
+}
+
+// OFX的NAME字段最长32个字符
+const ofxNameMaxLen = 32
+
+// WriteOFX 输出OFX 2.2格式的银行对账单
+func WriteOFX(w io.Writer, stmt statement.Statement, generatedAt time.Time) error {
+	transactions := make([]ofxStmtTrn, 0, len(stmt.Lines))
+	for _, line := range stmt.Lines {
+		trnType := "CREDIT"
+		if line.Amount < 0 {
+			trnType = "DEBIT"
+		}
+
+		name := line.CounterpartyOwner
+		if len(name) > ofxNameMaxLen {
+			name = name[:ofxNameMaxLen]
+		}
+
+		transactions = append(transactions, ofxStmtTrn{
+			TrnType:  trnType,
+			DTPosted: ofxTime(line.Date),
+			TrnAmt:   util.FormatAmount(line.Amount, stmt.Currency),
+			FitID:    strconv.FormatInt(line.EntryID, 10),
+			Name:     name,
+			Memo:     line.Description,
+		})
+	}
+
+	doc := ofxDocument{
+		SignOn: ofxSignOn{
+			Status:   ofxStatus{Code: 0, Severity: "INFO"},
+			DTServer: ofxTime(generatedAt),
+			Language: "ENG",
+		},
+		Bank: ofxStmtTrnRes{
+			TrnUID: fmt.Sprintf("%d-%s", stmt.AccountID, stmt.PeriodStart.Format("20060102")),
+			Status: ofxStatus{Code: 0, Severity: "INFO"},
+			StmtRs: ofxStmtRs{
+				CurDef: stmt.Currency,
+				BankAcctFrom: ofxBankAcct{
+					BankID:   BankID,
+					AcctID:   strconv.FormatInt(stmt.AccountID, 10),
+					AcctType: ofxAccountType(stmt.AccountType),
+				},
+				TranList: ofxTranList{
+					DTStart:      ofxTime(stmt.PeriodStart),
+					DTEnd:        ofxTime(stmt.PeriodEnd),
+					Transactions: transactions,
+				},
+				LedgerBal: ofxBalance{
+					BalAmt: util.FormatAmount(stmt.ClosingBalance, stmt.Currency),
+					DTAsOf: ofxTime(stmt.PeriodEnd),
+				},
+			},
+		},
+	}
+
+	return writeXML(w, ofxHeader, doc)
+}
+
+func ofxAccountType(accountType string) string {
+	if accountType == util.Savings {
+		return "SAVINGS"
+	}
+	return "CHECKING"
+}
+
+// OFX的时间格式：YYYYMMDDHHMMSS.XXX[偏移:时区]，统一使用UTC
+func ofxTime(t time.Time) string {
+	return t.UTC().Format("20060102150405.000") + "[0:UTC]"
+}
@@ -0,0 +1,45 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"simplebank/token"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	authorizationHeader     = "authorization"
+	authorizationTypeBearer = "bearer"
+)
+
+// 从gRPC metadata中解析并校验access token，格式与HTTP接口相同：Bearer <token>
+func (server *Server) authorizeUser(ctx context.Context) (*token.Payload, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("missing metadata")
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) == 0 {
+		return nil, fmt.Errorf("missing authorization header")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("invalid authorization header format")
+	}
+
+	authType := strings.ToLower(fields[0])
+	if authType != authorizationTypeBearer {
+		return nil, fmt.Errorf("unsupported authorization type: %s", authType)
+	}
+
+	payload, err := server.tokenMaker.VerifyToken(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid access token: %w", err)
+	}
+
+	return payload, nil
+}
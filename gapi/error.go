@@ -0,0 +1,45 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func unauthenticatedError(err error) error {
+	return status.Errorf(codes.Unauthenticated, "unauthorized: %s", err)
+}
+
+func invalidArgumentError(format string, args ...interface{}) error {
+	return status.Errorf(codes.InvalidArgument, format, args...)
+}
+
+// 内部错误只在服务端记录原因，返回给调用方的消息不包含数据库或驱动的错误信息
+func internalError(ctx context.Context, action string, err error) error {
+	logging.FromContext(ctx).Error("request failed", "action", action, "error", err)
+	return status.Errorf(codes.Internal, "%s: internal server error", action)
+}
+
+// 存储层的业务错误转换为对应的gRPC状态码，分类与HTTP接口的apierror.FromStore一致，其他错误按内部错误处理
+func storeError(ctx context.Context, action string, err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return status.Errorf(codes.NotFound, "%s: not found", action)
+	case errors.Is(err, db.ErrInsufficientFunds):
+		return status.Errorf(codes.FailedPrecondition, "%s: %s", action, db.ErrInsufficientFunds)
+	case errors.Is(err, db.ErrAccountFrozen):
+		return status.Errorf(codes.FailedPrecondition, "%s: %s", action, db.ErrAccountFrozen)
+	case errors.Is(err, db.ErrHoldNotActive):
+		return status.Errorf(codes.FailedPrecondition, "%s: %s", action, db.ErrHoldNotActive)
+	case errors.Is(err, db.ErrSettlementAccount):
+		return status.Errorf(codes.InvalidArgument, "%s: %s", action, db.ErrSettlementAccount)
+	case errors.Is(err, db.ErrHoldAmountExceeded):
+		return status.Errorf(codes.InvalidArgument, "%s: %s", action, db.ErrHoldAmountExceeded)
+	}
+	return internalError(ctx, action, err)
+}
@@ -0,0 +1,58 @@
+package gapi
+
+import (
+	"context"
+	"log/slog"
+	"simplebank/logging"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const requestIDHeader = "x-request-id"
+
+// GrpcLogger 为每个调用分配请求ID并记录访问日志，与HTTP接口的日志格式一致
+func GrpcLogger(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDHeader); len(values) > 0 && logging.ValidRequestID(values[0]) {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+
+	logger := slog.Default().With("request_id", requestID)
+	ctx = logging.WithContext(ctx, logger)
+
+	start := time.Now()
+	result, err := handler(ctx, req)
+
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.OK:
+	case codes.Internal, codes.Unknown, codes.DataLoss, codes.Unavailable:
+		level = slog.LevelError
+	default:
+		level = slog.LevelWarn
+	}
+
+	attrs := []any{
+		"method", info.FullMethod,
+		"status_code", code.String(),
+		"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		attrs = append(attrs, "error", status.Convert(err).Message())
+	}
+	logger.Log(ctx, level, "grpc request", attrs...)
+
+	return result, err
+}
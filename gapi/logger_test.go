@@ -0,0 +1,52 @@
+package gapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"simplebank/logging"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// 与HTTP接口一致，不合法的请求ID会被替换，避免注入日志
+func TestGrpcLoggerRequestID(t *testing.T) {
+	defaultLogger := slog.Default()
+	defer slog.SetDefault(defaultLogger)
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/pb.SimpleBank/GetAccount"}
+	handler := func(ctx context.Context, req any) (any, error) {
+		return nil, nil
+	}
+
+	for _, tc := range []struct {
+		requestID string
+		valid     bool
+	}{
+		{requestID: "grpc-request-1", valid: true},
+		{requestID: "bad\n{\"level\":\"ERROR\"}", valid: false},
+	} {
+		var buf bytes.Buffer
+		slog.SetDefault(logging.New(&buf, "info"))
+
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDHeader, tc.requestID))
+		_, err := GrpcLogger(ctx, nil, info, handler)
+		require.NoError(t, err)
+
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		if tc.valid {
+			require.Equal(t, tc.requestID, record["request_id"])
+		} else {
+			requestID, ok := record["request_id"].(string)
+			require.True(t, ok)
+			_, err = uuid.Parse(requestID)
+			require.NoError(t, err)
+		}
+	}
+}
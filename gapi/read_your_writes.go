@@ -0,0 +1,37 @@
+package gapi
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+
+	"google.golang.org/grpc"
+)
+
+// 会修改数据的RPC
+var writeMethods = map[string]bool{
+	pb.SimpleBank_CreateAccount_FullMethodName:  true,
+	pb.SimpleBank_CreateTransfer_FullMethodName: true,
+}
+
+// ReadYourWrites 与HTTP接口的readYourWritesMiddleware逻辑一致：写请求中的查询以及数据刚被写过的用户的读请求走主库，
+// 写成功后记录操作者和处理函数用db.MarkWritten标记的账户所有者。未登录的调用直接交给处理函数
+func (server *Server) ReadYourWrites(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return handler(ctx, req)
+	}
+
+	write := writeMethods[info.FullMethod]
+	if write {
+		ctx = db.WithWrittenOwners(db.WithPrimary(ctx))
+	} else if server.writes.RecentlyWrote(payload.Username) {
+		ctx = db.WithPrimary(ctx)
+	}
+
+	result, err := handler(ctx, req)
+	if write && err == nil {
+		server.writes.Record(append(db.WrittenOwners(ctx), payload.Username)...)
+	}
+	return result, err
+}
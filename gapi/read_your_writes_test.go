@@ -0,0 +1,52 @@
+package gapi
+
+import (
+	"context"
+	"errors"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+func TestReadYourWritesInterceptor(t *testing.T) {
+	server := newTestServer(t, nil)
+	server.writes = db.NewWriteTracker(time.Minute)
+
+	call := func(method string, username string, owner string, fail bool) bool {
+		ctx := context.Background()
+		if username != "" {
+			ctx = newContextWithBearerToken(t, server.tokenMaker, username, time.Minute)
+		}
+
+		var primary bool
+		handler := func(ctx context.Context, req any) (any, error) {
+			primary = db.IsPrimary(ctx)
+			if fail {
+				return nil, errors.New("fail")
+			}
+			db.MarkWritten(ctx, owner)
+			return nil, nil
+		}
+
+		_, _ = server.ReadYourWrites(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return primary
+	}
+
+	// 没有写过数据的用户读从库，未登录的调用不受影响
+	require.False(t, call(pb.SimpleBank_GetAccount_FullMethodName, "alice", "", false))
+	require.False(t, call(pb.SimpleBank_GetAccount_FullMethodName, "", "", false))
+
+	// 写请求中的查询读主库，失败的写请求不影响之后的读请求
+	require.True(t, call(pb.SimpleBank_CreateTransfer_FullMethodName, "alice", "", true))
+	require.False(t, call(pb.SimpleBank_GetAccount_FullMethodName, "alice", "", false))
+
+	// 写成功后操作者和被修改账户的所有者读主库，其他用户不受影响
+	require.True(t, call(pb.SimpleBank_CreateTransfer_FullMethodName, "alice", "carol", false))
+	require.True(t, call(pb.SimpleBank_GetAccount_FullMethodName, "alice", "", false))
+	require.True(t, call(pb.SimpleBank_ListAccounts_FullMethodName, "carol", "", false))
+	require.False(t, call(pb.SimpleBank_GetAccount_FullMethodName, "bob", "", false))
+}
@@ -0,0 +1,123 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		return nil, invalidArgumentError("unsupported currency: %s", req.GetCurrency())
+	}
+
+	accountType := req.GetType()
+	if accountType == "" {
+		accountType = util.Checking
+	}
+	if !util.IsSupportedAccountType(accountType) {
+		return nil, invalidArgumentError("unsupported account type: %s", accountType)
+	}
+
+	if len(req.GetNickname()) > 64 {
+		return nil, invalidArgumentError("nickname must be at most 64 characters")
+	}
+
+	account, err := server.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    payload.Username,
+		Currency: req.GetCurrency(),
+		Balance:  0,
+		Type:     accountType,
+		Nickname: sql.NullString{String: req.GetNickname(), Valid: req.GetNickname() != ""},
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			return nil, status.Errorf(codes.AlreadyExists, "nickname is already used by another account of this owner")
+		}
+		return nil, internalError(ctx, "failed to create account", err)
+	}
+
+	return &pb.CreateAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	if req.GetId() < 1 {
+		return nil, invalidArgumentError("id must be positive")
+	}
+
+	account, err := server.getAuthorizedAccount(ctx, payload.Username, req.GetId(), db.PermissionView)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (server *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	// 与HTTP接口的分页限制保持一致
+	if req.GetPageId() < 1 {
+		return nil, invalidArgumentError("page_id must be positive")
+	}
+	if req.GetPageSize() < 5 || req.GetPageSize() > 10 {
+		return nil, invalidArgumentError("page_size must be between 5 and 10")
+	}
+
+	accounts, err := server.store.ListAccounts(ctx, db.ListAccountsParams{
+		Owner:  payload.Username,
+		Limit:  req.GetPageSize(),
+		Offset: (req.GetPageId() - 1) * req.GetPageSize(),
+	})
+	if err != nil {
+		return nil, internalError(ctx, "failed to list accounts", err)
+	}
+
+	rsp := &pb.ListAccountsResponse{Accounts: make([]*pb.Account, 0, len(accounts))}
+	for _, account := range accounts {
+		rsp.Accounts = append(rsp.Accounts, convertAccount(account))
+	}
+
+	return rsp, nil
+}
+
+// 获取账户并校验当前用户对账户是否有指定权限，返回的错误已经是gRPC status
+// 权限校验读主库，避免从库延迟时沿用已失效的状态
+func (server *Server) getAuthorizedAccount(ctx context.Context, username string, accountID int64, permission string) (db.Account, error) {
+	ctx = db.WithPrimary(ctx)
+	account, err := server.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return account, status.Errorf(codes.NotFound, "account [%d] not found", accountID)
+		}
+		return account, internalError(ctx, "failed to get account", err)
+	}
+
+	ok, err := db.CanAccess(ctx, server.store, username, account, permission)
+	if err != nil {
+		return account, internalError(ctx, "failed to check account permission", err)
+	}
+	if !ok {
+		return account, status.Errorf(codes.PermissionDenied, "don't have power to access account [%d]", accountID)
+	}
+
+	return account, nil
+}
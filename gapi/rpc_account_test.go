@@ -0,0 +1,139 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetAccountRPC(t *testing.T) {
+	owner := util.RandomOwner()
+	account := randomAccount(owner)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		buildContext  func(t *testing.T, server *Server) context.Context
+		checkResponse func(t *testing.T, res *pb.GetAccountResponse, err error)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(mockdb.PrimaryContext(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server.tokenMaker, owner, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, account.ID, res.GetAccount().GetId())
+				require.Equal(t, account.Balance, res.GetAccount().GetBalance())
+			},
+		},
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(db.Account{}, sql.ErrNoRows)
+			},
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server.tokenMaker, owner, time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name: "PermissionDenied",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().GetAccountHolder(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountHolder{}, sql.ErrNoRows)
+			},
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server.tokenMaker, "other", time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name: "NoAuthorization",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return context.Background()
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+		{
+			name: "ExpiredToken",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			buildContext: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server.tokenMaker, owner, -time.Minute)
+			},
+			checkResponse: func(t *testing.T, res *pb.GetAccountResponse, err error) {
+				require.Equal(t, codes.Unauthenticated, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			res, err := server.GetAccount(tc.buildContext(t, server), &pb.GetAccountRequest{Id: account.ID})
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
+
+func TestCreateAccountRPC(t *testing.T) {
+	owner := util.RandomOwner()
+	account := randomAccount(owner)
+	account.Balance = 0
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	// 账户所有者取自access token
+	store.EXPECT().
+		CreateAccount(gomock.Any(), gomock.Eq(db.CreateAccountParams{
+			Owner:    owner,
+			Currency: util.USD,
+			Type:     util.Checking,
+		})).
+		Times(1).
+		Return(account, nil)
+
+	server := newTestServer(t, store)
+	ctx := newContextWithBearerToken(t, server.tokenMaker, owner, time.Minute)
+
+	res, err := server.CreateAccount(ctx, &pb.CreateAccountRequest{Currency: util.USD})
+	require.NoError(t, err)
+	require.Equal(t, owner, res.GetAccount().GetOwner())
+
+	_, err = server.CreateAccount(ctx, &pb.CreateAccountRequest{Currency: "JPY"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,64 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (server *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	payload, err := server.authorizeUser(ctx)
+	if err != nil {
+		return nil, unauthenticatedError(err)
+	}
+
+	if req.GetFromAccountId() < 1 || req.GetToAccountId() < 1 {
+		return nil, invalidArgumentError("account ids must be positive")
+	}
+	if req.GetAmount() <= 0 {
+		return nil, invalidArgumentError("amount must be positive")
+	}
+	if !util.IsSupportedCurrency(req.GetCurrency()) {
+		return nil, invalidArgumentError("unsupported currency: %s", req.GetCurrency())
+	}
+
+	fromAccount, err := server.getAuthorizedAccount(ctx, payload.Username, req.GetFromAccountId(), db.PermissionTransfer)
+	if err != nil {
+		return nil, err
+	}
+	if fromAccount.Currency != req.GetCurrency() {
+		return nil, invalidArgumentError("account [%d] currency is not matched", fromAccount.ID)
+	}
+
+	toAccount, err := server.store.GetAccount(db.WithPrimary(ctx), req.GetToAccountId())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, status.Errorf(codes.NotFound, "account [%d] not found", req.GetToAccountId())
+		}
+		return nil, internalError(ctx, "failed to get account", err)
+	}
+	if toAccount.Currency != req.GetCurrency() {
+		return nil, invalidArgumentError("account [%d] currency is not matched", toAccount.ID)
+	}
+
+	result, err := server.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        req.GetAmount(),
+	})
+	if err != nil {
+		return nil, storeError(ctx, "failed to transfer", err)
+	}
+	db.MarkWritten(ctx, result.FromAccount.Owner, result.ToAccount.Owner)
+
+	return &pb.CreateTransferResponse{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+	}, nil
+}
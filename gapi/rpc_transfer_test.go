@@ -0,0 +1,163 @@
+package gapi
+
+import (
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateTransferRPC(t *testing.T) {
+	owner := util.RandomOwner()
+	account1 := randomAccount(owner)
+	account2 := randomAccount(util.RandomOwner())
+	account2.ID = account1.ID + 1
+
+	amount := int64(10)
+
+	testCases := []struct {
+		name          string
+		username      string
+		req           *pb.CreateTransferRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, res *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name:     "OK",
+			username: owner,
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Eq(db.TransferTxParams{FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount})).
+					Times(1).
+					Return(db.TransferTxResult{
+						Transfer:    db.Transfer{ID: 1, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount},
+						FromAccount: account1,
+						ToAccount:   account2,
+					}, nil)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, amount, res.GetTransfer().GetAmount())
+				require.Equal(t, account1.ID, res.GetFromAccount().GetId())
+			},
+		},
+		{
+			name:     "NotOwner",
+			username: "other",
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccountHolder(gomock.Any(), gomock.Any()).Times(1).Return(db.AccountHolder{}, sql.ErrNoRows)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.PermissionDenied, status.Code(err))
+			},
+		},
+		{
+			name:     "CurrencyMismatch",
+			username: owner,
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+		{
+			name:     "AccountFrozen",
+			username: owner,
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrAccountFrozen)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name:     "InsufficientFunds",
+			username: owner,
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, db.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.FailedPrecondition, status.Code(err))
+			},
+		},
+		{
+			name:     "AccountDeleted",
+			username: owner,
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, sql.ErrNoRows)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.NotFound, status.Code(err))
+			},
+		},
+		{
+			name:     "InternalError",
+			username: owner,
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: amount, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(db.TransferTxResult{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.Internal, status.Code(err))
+				// 数据库错误只记录在服务端日志中
+				require.NotContains(t, status.Convert(err).Message(), sql.ErrConnDone.Error())
+			},
+		},
+		{
+			name:     "InvalidAmount",
+			username: owner,
+			req:      &pb.CreateTransferRequest{FromAccountId: account1.ID, ToAccountId: account2.ID, Amount: -1, Currency: util.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, res *pb.CreateTransferResponse, err error) {
+				require.Equal(t, codes.InvalidArgument, status.Code(err))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			ctx := newContextWithBearerToken(t, server.tokenMaker, tc.username, time.Minute)
+			res, err := server.CreateTransfer(ctx, tc.req)
+			tc.checkResponse(t, res, err)
+		})
+	}
+}
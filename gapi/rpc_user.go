@@ -0,0 +1,75 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/util"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func (server *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	// 规则与HTTP接口的createUserRequest一致
+	for _, field := range []struct{ name, value, rule string }{
+		{"username", req.GetUsername(), "required,alphanum"},
+		{"password", req.GetPassword(), "required,min=6"},
+		{"full_name", req.GetFullName(), "required"},
+		{"email", req.GetEmail(), "required,email"},
+	} {
+		err := validateField(field.name, field.value, field.rule)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	hashedPassword, err := util.HashedPassword(req.GetPassword())
+	if err != nil {
+		return nil, internalError(ctx, "failed to hash password", err)
+	}
+
+	user, err := server.store.CreateUser(ctx, db.CreateUserParams{
+		Username:       req.GetUsername(),
+		HashedPassword: hashedPassword,
+		FullName:       req.GetFullName(),
+		Email:          req.GetEmail(),
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			return nil, status.Errorf(codes.AlreadyExists, "username or email already exists")
+		}
+		return nil, internalError(ctx, "failed to create user", err)
+	}
+
+	return &pb.CreateUserResponse{User: convertUser(user)}, nil
+}
+
+func (server *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	user, err := server.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			server.metrics.LoginFailed()
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, internalError(ctx, "failed to find user", err)
+	}
+
+	if !util.CheckPassword(req.GetPassword(), user.HashedPassword) {
+		server.metrics.LoginFailed()
+		return nil, status.Errorf(codes.Unauthenticated, "incorrect password")
+	}
+
+	accessToken, payload, err := server.tokenMaker.CreateToken(user.Username, user.Role, server.config.AccessTokenDuration)
+	if err != nil {
+		return nil, internalError(ctx, "failed to create access token", err)
+	}
+
+	return &pb.LoginUserResponse{
+		User:                 convertUser(user),
+		AccessToken:          accessToken,
+		AccessTokenExpiresAt: timestamppb.New(payload.ExpiredAt),
+	}, nil
+}
@@ -0,0 +1,86 @@
+package gapi
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/pb"
+	"simplebank/util"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoginUserRPC(t *testing.T) {
+	password := util.RandomString(6)
+	hashedPassword, err := util.HashedPassword(password)
+	require.NoError(t, err)
+
+	user := db.User{Username: util.RandomOwner(), HashedPassword: hashedPassword}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(2).Return(user, nil)
+
+	server := newTestServer(t, store)
+
+	res, err := server.LoginUser(context.Background(), &pb.LoginUserRequest{Username: user.Username, Password: password})
+	require.NoError(t, err)
+	require.NotEmpty(t, res.GetAccessToken())
+
+	_, err = server.LoginUser(context.Background(), &pb.LoginUserRequest{Username: user.Username, Password: "wrong-password"})
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+	// 与HTTP登录一样记录失败次数
+	recorder := httptest.NewRecorder()
+	server.metrics.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Contains(t, recorder.Body.String(), "simple_bank_failed_logins_total 1")
+}
+
+func TestCreateUserRPCValidation(t *testing.T) {
+	valid := &pb.CreateUserRequest{
+		Username: util.RandomOwner(),
+		Password: util.RandomString(6),
+		FullName: util.RandomOwner(),
+		Email:    util.RandomEmail(),
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(req *pb.CreateUserRequest)
+	}{
+		{name: "InvalidUsername", modify: func(req *pb.CreateUserRequest) { req.Username = "bad user!" }},
+		{name: "ShortPassword", modify: func(req *pb.CreateUserRequest) { req.Password = "12345" }},
+		{name: "MissingFullName", modify: func(req *pb.CreateUserRequest) { req.FullName = "" }},
+		{name: "InvalidEmail", modify: func(req *pb.CreateUserRequest) { req.Email = "not-an-email" }},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+
+			req := &pb.CreateUserRequest{
+				Username: valid.Username,
+				Password: valid.Password,
+				FullName: valid.FullName,
+				Email:    valid.Email,
+			}
+			tc.modify(req)
+
+			_, err := newTestServer(t, store).CreateUser(context.Background(), req)
+			require.Equal(t, codes.InvalidArgument, status.Code(err))
+		})
+	}
+}
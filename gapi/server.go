@@ -0,0 +1,57 @@
+package gapi
+
+import (
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/metrics"
+	"simplebank/pb"
+	"simplebank/token"
+	"simplebank/util"
+)
+
+// Server 提供gRPC接口，与HTTP接口共用同一个db.Store和token.Maker的实现
+type Server struct {
+	pb.UnimplementedSimpleBankServer
+	config     util.Config
+	store      db.Store
+	tokenMaker token.Maker
+	metrics    *metrics.Metrics
+	writes     *db.WriteTracker
+}
+
+type ServerOption func(server *Server)
+
+// WithMetrics 与HTTP接口共用同一组监控指标
+func WithMetrics(metrics *metrics.Metrics) ServerOption {
+	return func(server *Server) {
+		server.metrics = metrics
+	}
+}
+
+// WithWriteTracker 与HTTP接口共用同一个WriteTracker
+func WithWriteTracker(writes *db.WriteTracker) ServerOption {
+	return func(server *Server) {
+		server.writes = writes
+	}
+}
+
+func NewServer(config util.Config, store db.Store, options ...ServerOption) (*Server, error) {
+	tokenMaker, err := token.NewPasetoMaker(config.TokenSymmetricKey, config.TokenPreviousSymmetricKeys...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create token maker: %w", err)
+	}
+
+	server := &Server{
+		config:     config,
+		store:      store,
+		tokenMaker: tokenMaker,
+		metrics:    metrics.New(),
+		writes:     db.NewWriteTracker(config.ReadYourWritesWindow),
+	}
+
+	for _, option := range options {
+		option(server)
+	}
+
+	return server, nil
+}
@@ -0,0 +1,17 @@
+package gapi
+
+import (
+	"github.com/go-playground/validator/v10"
+)
+
+// 与HTTP接口使用同一个校验库和相同的规则，两边对同一请求的校验结果一致
+var validate = validator.New()
+
+// 按validator的规则校验单个字段，不通过时返回InvalidArgument
+func validateField(field string, value string, rule string) error {
+	err := validate.Var(value, rule)
+	if err != nil {
+		return invalidArgumentError("%s is invalid: must satisfy %s", field, rule)
+	}
+	return nil
+}
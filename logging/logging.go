@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted 替换敏感字段的值
+const Redacted = "[REDACTED]"
+
+// 这些字段无论出现在哪一层都不能写入日志
+var sensitiveKeys = map[string]bool{
+	"password":            true,
+	"hashed_password":     true,
+	"token":               true,
+	"access_token":        true,
+	"refresh_token":       true,
+	"authorization":       true,
+	"token_symmetric_key": true,
+	"secret":              true,
+}
+
+// 客户端传入的请求ID只接受安全字符，避免写入日志时被注入
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+type contextKey struct{}
+
+// New 创建输出JSON的logger，level为debug/info/warn/error，无法识别时使用info
+func New(w io.Writer, level string) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       ParseLevel(level),
+		ReplaceAttr: redactAttr,
+	})
+	return slog.New(handler)
+}
+
+func ParseLevel(level string) slog.Level {
+	var l slog.Level
+	err := l.UnmarshalText([]byte(level))
+	if err != nil {
+		return slog.LevelInfo
+	}
+	return l
+}
+
+// ValidRequestID 判断客户端传入的请求ID能否直接使用，HTTP和gRPC接口共用
+func ValidRequestID(requestID string) bool {
+	return validRequestID.MatchString(requestID)
+}
+
+// IsSensitive 判断字段名是否需要脱敏，不区分大小写
+func IsSensitive(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if IsSensitive(attr.Key) {
+		return slog.String(attr.Key, Redacted)
+	}
+	return attr
+}
+
+// RedactQuery 返回脱敏后的查询字符串，用于访问日志
+func RedactQuery(query url.Values) string {
+	if len(query) == 0 {
+		return ""
+	}
+
+	redacted := url.Values{}
+	for key, values := range query {
+		if IsSensitive(key) {
+			redacted[key] = []string{Redacted}
+			continue
+		}
+		redacted[key] = values
+	}
+	return redacted.Encode()
+}
+
+// WithContext 把logger放入ctx，之后的处理函数和store通过FromContext取出，日志中会带上请求ID等字段
+func WithContext(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, logger)
+}
+
+// FromContext 取出ctx中的logger，没有时返回默认logger
+func FromContext(ctx context.Context) *slog.Logger {
+	logger, ok := ctx.Value(contextKey{}).(*slog.Logger)
+	if ok {
+		return logger
+	}
+	return slog.Default()
+}
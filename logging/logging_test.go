@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "info")
+
+	logger.Info("login",
+		"username", "alice",
+		"password", "secret123",
+		slog.Group("headers", "Authorization", "Bearer v2.local.abc"),
+		"token", "v2.local.xyz",
+	)
+
+	var record map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &record)
+	require.NoError(t, err)
+
+	require.Equal(t, "alice", record["username"])
+	require.Equal(t, Redacted, record["password"])
+	require.Equal(t, Redacted, record["token"])
+	require.Equal(t, Redacted, record["headers"].(map[string]interface{})["Authorization"])
+	require.NotContains(t, buf.String(), "secret123")
+	require.NotContains(t, buf.String(), "v2.local")
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := New(&buf, "warn")
+
+	logger.Info("hidden")
+	require.Zero(t, buf.Len())
+
+	logger.Warn("shown")
+	require.Contains(t, buf.String(), "shown")
+
+	require.Equal(t, slog.LevelInfo, ParseLevel("verbose"))
+	require.Equal(t, slog.LevelDebug, ParseLevel("DEBUG"))
+}
+
+func TestRedactQuery(t *testing.T) {
+	query := url.Values{
+		"page_id": {"1"},
+		"token":   {"abc"},
+	}
+	require.Equal(t, "page_id=1&token=%5BREDACTED%5D", RedactQuery(query))
+	require.Empty(t, RedactQuery(nil))
+}
+
+func TestValidRequestID(t *testing.T) {
+	require.True(t, ValidRequestID("req-1.a_B"))
+	require.False(t, ValidRequestID(""))
+	require.False(t, ValidRequestID("bad\nid"))
+	require.False(t, ValidRequestID(strings.Repeat("a", 129)))
+}
+
+func TestContext(t *testing.T) {
+	require.Equal(t, slog.Default(), FromContext(context.Background()))
+
+	var buf bytes.Buffer
+	logger := New(&buf, "info").With("request_id", "abc")
+	ctx := WithContext(context.Background(), logger)
+
+	FromContext(ctx).Info("hello")
+	require.Contains(t, buf.String(), `"request_id":"abc"`)
+}
@@ -1,34 +1,445 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
 	"simplebank/api"
+	"simplebank/db/migration"
 	db "simplebank/db/sqlc"
+	"simplebank/gapi"
+	"simplebank/logging"
+	"simplebank/memstore"
+	"simplebank/metrics"
+	"simplebank/pb"
+	"simplebank/reconcile"
+	"simplebank/seed"
+	"simplebank/tracing"
 	"simplebank/util"
+	"simplebank/worker"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 
-	_ "github.com/lib/pq"
+	"github.com/jackc/pgx/v5/stdlib"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
+	err := run()
+	if err != nil {
+		slog.Error("simplebank exited", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run 出错时返回，由main退出进程，保证defer的清理（关闭连接池、导出剩余的trace）都能执行
+func run() error {
 	config, err := util.LoadConfig(".")
 	if err != nil {
-		log.Fatal("cannot load config: ", err)
+		return fmt.Errorf("cannot load config: %w", err)
+	}
+
+	// 日志写到标准错误，子命令的标准输出只有报告内容
+	slog.SetDefault(logging.New(os.Stderr, config.LogLevel))
+
+	shutdownTracing, err := tracing.Setup(context.Background(), config.TracingExporter, config.OTLPEndpoint)
+	if err != nil {
+		return fmt.Errorf("cannot set up tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// DB_DRIVER=memory时使用内存存储，不连接数据库，进程退出后数据丢失，只用于本地开发
+	if config.DBDriver == memstore.DriverName {
+		if len(os.Args) > 1 {
+			return fmt.Errorf("command requires a database: %s", os.Args[1])
+		}
+		slog.Warn("using in-memory store, data will be lost on exit")
+		serverMetrics := metrics.New()
+		return runServer(config, nil, memstore.New(memstore.WithMetrics(serverMetrics)), serverMetrics)
+	}
+
+	pool, err := db.OpenDB(context.Background(), config.DBDriver, config.DBSource, db.NewPoolConfig(config))
+	if err != nil {
+		return fmt.Errorf("cannot connect to db: %w", err)
+	}
+	// 服务停止、后台任务退出后才关闭连接池
+	defer pool.Close()
+
+	// 连接池按需建立连接，启动时确认数据库可用
+	err = pool.Ping(context.Background())
+	if err != nil {
+		return fmt.Errorf("cannot ping db: %w", err)
+	}
+
+	// 迁移使用database/sql，连接仍由pgxpool管理，关闭conn不会关闭连接池
+	conn := stdlib.OpenDBFromPool(pool)
+	defer conn.Close()
+
+	// HTTP接口、store和连接池共用同一组监控指标，通过METRICS_ADDRESS上单独的监听导出
+	serverMetrics := metrics.New()
+	serverMetrics.RegisterDB(pool, "simple_bank")
+	options, err := storeOptions(config)
+	if err != nil {
+		return fmt.Errorf("invalid transaction config: %w", err)
+	}
+
+	// 配置了从库时只读查询走从库，从库与主库使用相同的连接池配置
+	if config.DBReplicaSource != "" {
+		replica, err := db.OpenDB(context.Background(), config.DBDriver, config.DBReplicaSource, db.NewPoolConfig(config))
+		if err != nil {
+			return fmt.Errorf("cannot connect to replica: %w", err)
+		}
+		defer replica.Close()
+
+		err = replica.Ping(context.Background())
+		if err != nil {
+			return fmt.Errorf("cannot ping replica: %w", err)
+		}
+		serverMetrics.RegisterDB(replica, "simple_bank_replica")
+		options = append(options, db.WithReplica(replica))
+	}
+	store := db.NewStore(pool, append(options, db.WithMetrics(serverMetrics))...)
+
+	// 子命令，例如 simplebank reconcile
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "reconcile":
+			return runReconcile(store)
+		case "verify-chain":
+			return runVerifyChain(store, os.Args[2:])
+		case "migrate":
+			return runMigrate(config, conn, os.Args[2:])
+		case "seed":
+			return runSeed(store, os.Args[2:])
+		default:
+			return fmt.Errorf("unknown command: %s", os.Args[1])
+		}
+	}
+
+	return runServer(config, conn, store, serverMetrics)
+}
+
+// 根据配置设置事务的重试策略和隔离级别
+func storeOptions(config util.Config) ([]db.StoreOption, error) {
+	options := []db.StoreOption{
+		db.WithRetryPolicy(db.RetryPolicy{
+			MaxRetries: config.TxMaxRetries,
+			BaseDelay:  config.TxRetryBaseDelay,
+			MaxDelay:   config.TxRetryMaxDelay,
+		}),
+	}
+
+	for _, entry := range config.TxIsolation {
+		if entry == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid TX_ISOLATION entry: %s", entry)
+		}
+
+		level, err := db.ParseIsolationLevel(value)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, db.WithTxIsolation(strings.TrimSpace(name), level))
+	}
+
+	return options, nil
+}
+
+func runServer(config util.Config, conn *sql.DB, store db.Store, serverMetrics *metrics.Metrics) error {
+	// 内存存储没有数据库连接，conn为nil
+	if config.AutoMigrate && conn != nil {
+		err := migration.NewMigrator(conn, config.MigrationURL).Up(context.Background())
+		if err != nil {
+			return fmt.Errorf("cannot migrate db: %w", err)
+		}
+		slog.Info("db migrated")
+	}
+
+	// gRPC和HTTP监听不同端口，同时对外提供服务
+	// 两种接口共用写记录，通过任一接口写入后，两边的读请求都走主库
+	writes := db.NewWriteTracker(config.ReadYourWritesWindow)
+	grpcServer, err := newGrpcServer(config, store, serverMetrics, writes)
+	if err != nil {
+		return err
+	}
+	httpServer, err := api.NewServer(config, store, api.WithMetrics(serverMetrics), api.WithWriteTracker(writes))
+	if err != nil {
+		return fmt.Errorf("cannot create server: %w", err)
+	}
+
+	// 收到SIGINT/SIGTERM后取消ctx，依次停止接收请求、等待处理中的请求和后台任务，返回后由run关闭连接池
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var workers sync.WaitGroup
+	runWorker := func(run func(ctx context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(ctx)
+		}()
+	}
+	// 后台定时释放过期的预授权
+	runWorker(worker.NewHoldSweeper(store, config.HoldSweepInterval).Run)
+	// 每日生成账户日终余额快照
+	runWorker(worker.NewBalanceSnapshotJob(store).Run)
+	// 每日计息，月末结息
+	runWorker(worker.NewInterestJob(store, config.InterestRates()).Run)
+
+	// 任一服务启动失败时同样走下面的停机流程
+	serveErrs := make(chan error, 3)
+	go func() {
+		serveErrs <- runGrpcServer(config, grpcServer)
+	}()
+	go func() {
+		serveErrs <- runGinServer(config, httpServer)
+	}()
+	// 监控指标不对外暴露，只在单独的（通常是内网）地址上提供，未配置时不启动
+	var metricsServer *http.Server
+	if config.MetricsAddress != "" {
+		metricsServer = newMetricsServer(config, serverMetrics)
+		go func() {
+			serveErrs <- runMetricsServer(metricsServer)
+		}()
+	}
+
+	var serveErr error
+	select {
+	case <-ctx.Done():
+	case serveErr = <-serveErrs:
+		stop()
+	}
+	slog.Info("shutting down", "timeout", config.ShutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	err = httpServer.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Error("cannot shut down HTTP server gracefully", "error", err)
+	}
+	stopGrpcServer(shutdownCtx, grpcServer)
+	if metricsServer != nil {
+		err = metricsServer.Shutdown(shutdownCtx)
+		if err != nil {
+			slog.Error("cannot shut down metrics server gracefully", "error", err)
+		}
+	}
+
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		slog.Error("background jobs did not stop in time")
+	}
+	slog.Info("server stopped")
+	return serveErr
+}
+
+func runGinServer(config util.Config, server *api.Server) error {
+	slog.Info("start HTTP server", "address", config.ServerAddress)
+	err := server.Start(config.ServerAddress)
+	if err != nil {
+		return fmt.Errorf("cannot start server: %w", err)
+	}
+	return nil
+}
+
+func newMetricsServer(config util.Config, serverMetrics *metrics.Metrics) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", serverMetrics.Handler())
+	return &http.Server{Addr: config.MetricsAddress, Handler: mux}
+}
+
+func runMetricsServer(server *http.Server) error {
+	slog.Info("start metrics server", "address", server.Addr)
+	err := server.ListenAndServe()
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("cannot start metrics server: %w", err)
+	}
+	return nil
+}
+
+func newGrpcServer(config util.Config, store db.Store, serverMetrics *metrics.Metrics, writes *db.WriteTracker) (*grpc.Server, error) {
+	server, err := gapi.NewServer(config, store, gapi.WithMetrics(serverMetrics), gapi.WithWriteTracker(writes))
+	if err != nil {
+		return nil, fmt.Errorf("cannot create gRPC server: %w", err)
+	}
+
+	grpcServer := grpc.NewServer(grpc.ChainUnaryInterceptor(gapi.GrpcLogger, server.ReadYourWrites))
+	pb.RegisterSimpleBankServer(grpcServer, server)
+	// 方便使用grpcurl等工具调试
+	reflection.Register(grpcServer)
+	return grpcServer, nil
+}
+
+func runGrpcServer(config util.Config, grpcServer *grpc.Server) error {
+	listener, err := net.Listen("tcp", config.GRPCServerAddress)
+	if err != nil {
+		return fmt.Errorf("cannot create gRPC listener: %w", err)
 	}
 
-	conn, err := sql.Open(config.DBDriver, config.DBSource)
+	slog.Info("start gRPC server", "address", listener.Addr().String())
+	err = grpcServer.Serve(listener)
 	if err != nil {
-		log.Fatal("cannot connect to db: ", err)
+		return fmt.Errorf("cannot start gRPC server: %w", err)
+	}
+	return nil
+}
+
+// GracefulStop会一直等待处理中的RPC，超时后强制关闭
+func stopGrpcServer(ctx context.Context, grpcServer *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		slog.Error("cannot shut down gRPC server gracefully", "error", ctx.Err())
+		grpcServer.Stop()
+	}
+}
+
+// 对账，发现差异时以非0状态码退出，方便定时任务告警
+func runReconcile(store db.Store) error {
+	report, err := reconcile.NewReconciler(store, reconcile.DefaultBatchSize).Run(context.Background())
+	if err != nil {
+		return fmt.Errorf("cannot reconcile ledger: %w", err)
 	}
 
-	store := db.NewStore(conn)
-	server, err := api.NewServer(config, store)
+	err = printJSON(report)
 	if err != nil {
-		log.Fatal("cannot create server: ", err)
+		return err
 	}
 
-	err = server.Start(config.ServerAddress)
+	if !report.OK() {
+		return errors.New("ledger is out of balance")
+	}
+	return nil
+}
+
+// 校验账户账目哈希链，用法：simplebank verify-chain <account_id>
+func runVerifyChain(store db.Store, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: simplebank verify-chain <account_id>")
+	}
+
+	accountID, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid account id: %w", err)
+	}
+
+	report, err := reconcile.NewReconciler(store, reconcile.DefaultBatchSize).VerifyEntryChain(context.Background(), accountID)
+	if err != nil {
+		return fmt.Errorf("cannot verify entry chain: %w", err)
+	}
+
+	err = printJSON(report)
+	if err != nil {
+		return err
+	}
+
+	if !report.OK {
+		return fmt.Errorf("entry chain of account %d is broken", accountID)
+	}
+	return nil
+}
+
+// 管理数据库迁移，用法：simplebank migrate up|down [N]|status
+// down默认只回滚一个版本，避免误删所有数据
+func runMigrate(config util.Config, conn *sql.DB, args []string) error {
+	usage := errors.New("usage: simplebank migrate up|down [N]|status")
+	if len(args) == 0 {
+		return usage
+	}
+
+	migrator := migration.NewMigrator(conn, config.MigrationURL)
+	ctx := context.Background()
+
+	var err error
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil {
+				return fmt.Errorf("invalid steps: %w", err)
+			}
+		}
+		err = migrator.Down(ctx, steps)
+	case "status":
+		// 只输出状态，不修改数据库
+	default:
+		return usage
+	}
+	if err != nil {
+		return fmt.Errorf("cannot migrate db: %w", err)
+	}
+
+	status, err := migrator.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot get migration status: %w", err)
+	}
+	return printJSON(status)
+}
+
+// 生成演示数据，用法：simplebank seed [-users N] [-transfers N] [-seed N]
+// 相同的种子在空数据库上生成相同的数据
+func runSeed(store db.Store, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	users := flags.Int("users", 20, "number of users, each gets one account per currency")
+	transfers := flags.Int("transfers", 200, "number of transfers between accounts")
+	seedValue := flags.Int64("seed", 1, "random seed")
+	password := flags.String("password", seed.DefaultPassword, "password of all generated users")
+	flags.Parse(args)
+
+	if *users <= 0 || *transfers < 0 {
+		return errors.New("-users must be positive and -transfers must not be negative")
+	}
+
+	summary, err := seed.NewSeeder(store, seed.Options{
+		Users:     *users,
+		Transfers: *transfers,
+		Seed:      *seedValue,
+		Password:  *password,
+	}).Run(context.Background())
+	if err != nil {
+		return fmt.Errorf("cannot seed db: %w", err)
+	}
+
+	return printJSON(summary)
+}
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	err := encoder.Encode(v)
 	if err != nil {
-		log.Fatal("cannot start server: ", err)
+		return fmt.Errorf("cannot print report: %w", err)
 	}
+	return nil
 }
@@ -0,0 +1,271 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+)
+
+func (q *queries) CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	if _, ok := q.st.users[arg.Owner]; !ok {
+		return db.Account{}, foreignKeyViolation("accounts_owner_fkey")
+	}
+
+	account := db.Account{
+		ID:        q.st.lastAccountID + 1,
+		Owner:     arg.Owner,
+		Balance:   arg.Balance,
+		Currency:  arg.Currency,
+		CreatedAt: q.now,
+		Type:      arg.Type,
+		Nickname:  arg.Nickname,
+		Status:    db.AccountStatusActive,
+	}
+	err := q.checkAccount(account)
+	if err != nil {
+		return db.Account{}, err
+	}
+
+	q.st.lastAccountID = account.ID
+	q.st.accounts[account.ID] = account
+	return account, nil
+}
+
+// 检查accounts表上的约束
+func (q *queries) checkAccount(account db.Account) error {
+	if !util.IsSupportedAccountType(account.Type) {
+		return checkViolation("accounts_type_check")
+	}
+
+	if account.Status != db.AccountStatusActive && account.Status != db.AccountStatusFrozen {
+		return checkViolation("accounts_status_check")
+	}
+
+	if account.Nickname.Valid {
+		for _, other := range q.st.accounts {
+			if other.ID != account.ID && other.Owner == account.Owner && other.Nickname == account.Nickname {
+				return uniqueViolation("accounts_owner_nickname_key")
+			}
+		}
+	}
+
+	if db.IsBankOwner(account.Owner) {
+		for _, other := range q.st.accounts {
+			if other.ID != account.ID && other.Owner == account.Owner && other.Currency == account.Currency {
+				return uniqueViolation("accounts_bank_owner_currency_key")
+			}
+		}
+	}
+	return nil
+}
+
+func (q *queries) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	account, ok := q.st.accounts[id]
+	if !ok {
+		return db.Account{}, sql.ErrNoRows
+	}
+	return account, nil
+}
+
+// 写操作已经串行化，不需要额外加锁
+func (q *queries) GetAccountForUpdate(ctx context.Context, id int64) (db.Account, error) {
+	return q.GetAccount(ctx, id)
+}
+
+func (q *queries) ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+	accounts := []db.Account{}
+	for _, account := range sortedByID(q.st.accounts) {
+		holder, ok := q.st.holders[holderKey{accountID: account.ID, username: arg.Owner}]
+		if account.Owner == arg.Owner || (ok && holder.Status == db.HolderStatusAccepted) {
+			accounts = append(accounts, account)
+		}
+	}
+	return paginate(accounts, arg.Limit, arg.Offset)
+}
+
+// 更新账户，更新后的账户不满足约束时不写入
+func (q *queries) updateAccount(id int64, update func(account *db.Account)) (db.Account, error) {
+	account, ok := q.st.accounts[id]
+	if !ok {
+		return db.Account{}, sql.ErrNoRows
+	}
+
+	update(&account)
+	err := q.checkAccount(account)
+	if err != nil {
+		return db.Account{}, err
+	}
+
+	q.st.accounts[id] = account
+	return account, nil
+}
+
+func (q *queries) UpdateAccountNickname(ctx context.Context, arg db.UpdateAccountNicknameParams) (db.Account, error) {
+	return q.updateAccount(arg.ID, func(account *db.Account) {
+		account.Nickname = arg.Nickname
+	})
+}
+
+func (q *queries) UpdateAccountStatus(ctx context.Context, arg db.UpdateAccountStatusParams) (db.Account, error) {
+	return q.updateAccount(arg.ID, func(account *db.Account) {
+		account.Status = arg.Status
+	})
+}
+
+func (q *queries) AddAccountBalance(ctx context.Context, arg db.AddAccountBalanceParams) (db.Account, error) {
+	return q.updateAccount(arg.ID, func(account *db.Account) {
+		account.Balance += arg.Amount
+	})
+}
+
+func (q *queries) SubtractAccruedInterest(ctx context.Context, arg db.SubtractAccruedInterestParams) (db.Account, error) {
+	return q.updateAccount(arg.ID, func(account *db.Account) {
+		account.AccruedInterest -= arg.Amount
+	})
+}
+
+// 与数据库一样，仍被其他表引用的账户不能删除
+func (q *queries) DeleteAccount(ctx context.Context, id int64) error {
+	if _, ok := q.st.accounts[id]; !ok {
+		return nil
+	}
+
+	for _, entry := range q.st.entries {
+		if entry.AccountID == id {
+			return foreignKeyViolation("entries_account_id_fkey")
+		}
+	}
+	for _, transfer := range q.st.transfers {
+		if transfer.FromAccountID == id || transfer.ToAccountID == id {
+			return foreignKeyViolation("transfers_from_account_id_fkey")
+		}
+	}
+	for _, hold := range q.st.holds {
+		if hold.AccountID == id || hold.ToAccountID == id {
+			return foreignKeyViolation("holds_account_id_fkey")
+		}
+	}
+	for key := range q.st.holders {
+		if key.accountID == id {
+			return foreignKeyViolation("account_holders_account_id_fkey")
+		}
+	}
+	for key := range q.st.snapshots {
+		if key.accountID == id {
+			return foreignKeyViolation("balance_snapshots_account_id_fkey")
+		}
+	}
+
+	delete(q.st.accounts, id)
+	return nil
+}
+
+func (q *queries) CountAccounts(ctx context.Context) (int64, error) {
+	return int64(len(q.st.accounts)), nil
+}
+
+func (q *queries) GetAccountByOwnerAndCurrency(ctx context.Context, arg db.GetAccountByOwnerAndCurrencyParams) (db.Account, error) {
+	for _, account := range sortedByID(q.st.accounts) {
+		if account.Owner == arg.Owner && account.Currency == arg.Currency {
+			return account, nil
+		}
+	}
+	return db.Account{}, sql.ErrNoRows
+}
+
+// 当天还未计息，interest_accrued_on和day都按UTC日期比较
+func notAccruedOn(account db.Account, day time.Time) bool {
+	return !account.InterestAccruedOn.Valid || account.InterestAccruedOn.Time.Before(day)
+}
+
+func (q *queries) ListAccountsForAccrual(ctx context.Context, arg db.ListAccountsForAccrualParams) ([]db.Account, error) {
+	day := db.SnapshotDate(arg.Day)
+
+	accounts := []db.Account{}
+	for _, account := range sortedByID(q.st.accounts) {
+		if account.Type == arg.Type && notAccruedOn(account, day) && account.ID > arg.AfterID {
+			accounts = append(accounts, account)
+		}
+	}
+	return paginate(accounts, arg.BatchSize, 0)
+}
+
+func (q *queries) AccrueInterest(ctx context.Context, arg db.AccrueInterestParams) (int64, error) {
+	day := db.SnapshotDate(arg.Day)
+
+	account, ok := q.st.accounts[arg.ID]
+	if !ok || !notAccruedOn(account, day) {
+		return 0, nil
+	}
+
+	account.AccruedInterest += arg.Amount
+	account.InterestAccruedOn = sql.NullTime{Time: day, Valid: true}
+	q.st.accounts[account.ID] = account
+	return 1, nil
+}
+
+func (q *queries) ListAccountsWithAccruedInterest(ctx context.Context, arg db.ListAccountsWithAccruedInterestParams) ([]db.Account, error) {
+	accounts := []db.Account{}
+	for _, account := range sortedByID(q.st.accounts) {
+		if account.AccruedInterest > 0 && account.ID > arg.AfterID {
+			accounts = append(accounts, account)
+		}
+	}
+	return paginate(accounts, arg.BatchSize, 0)
+}
+
+func (store *Store) CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	return write(store, ctx, func(q *queries) (db.Account, error) { return q.CreateAccount(ctx, arg) })
+}
+
+func (store *Store) GetAccount(ctx context.Context, id int64) (db.Account, error) {
+	return read(store, ctx, func(q *queries) (db.Account, error) { return q.GetAccount(ctx, id) })
+}
+
+func (store *Store) GetAccountForUpdate(ctx context.Context, id int64) (db.Account, error) {
+	return read(store, ctx, func(q *queries) (db.Account, error) { return q.GetAccountForUpdate(ctx, id) })
+}
+
+func (store *Store) ListAccounts(ctx context.Context, arg db.ListAccountsParams) ([]db.Account, error) {
+	return read(store, ctx, func(q *queries) ([]db.Account, error) { return q.ListAccounts(ctx, arg) })
+}
+
+func (store *Store) UpdateAccountNickname(ctx context.Context, arg db.UpdateAccountNicknameParams) (db.Account, error) {
+	return write(store, ctx, func(q *queries) (db.Account, error) { return q.UpdateAccountNickname(ctx, arg) })
+}
+
+func (store *Store) UpdateAccountStatus(ctx context.Context, arg db.UpdateAccountStatusParams) (db.Account, error) {
+	return write(store, ctx, func(q *queries) (db.Account, error) { return q.UpdateAccountStatus(ctx, arg) })
+}
+
+func (store *Store) SubtractAccruedInterest(ctx context.Context, arg db.SubtractAccruedInterestParams) (db.Account, error) {
+	return write(store, ctx, func(q *queries) (db.Account, error) { return q.SubtractAccruedInterest(ctx, arg) })
+}
+
+func (store *Store) DeleteAccount(ctx context.Context, id int64) error {
+	_, err := write(store, ctx, func(q *queries) (struct{}, error) { return struct{}{}, q.DeleteAccount(ctx, id) })
+	return err
+}
+
+func (store *Store) CountAccounts(ctx context.Context) (int64, error) {
+	return read(store, ctx, func(q *queries) (int64, error) { return q.CountAccounts(ctx) })
+}
+
+func (store *Store) GetAccountByOwnerAndCurrency(ctx context.Context, arg db.GetAccountByOwnerAndCurrencyParams) (db.Account, error) {
+	return read(store, ctx, func(q *queries) (db.Account, error) { return q.GetAccountByOwnerAndCurrency(ctx, arg) })
+}
+
+func (store *Store) ListAccountsForAccrual(ctx context.Context, arg db.ListAccountsForAccrualParams) ([]db.Account, error) {
+	return read(store, ctx, func(q *queries) ([]db.Account, error) { return q.ListAccountsForAccrual(ctx, arg) })
+}
+
+func (store *Store) AccrueInterest(ctx context.Context, arg db.AccrueInterestParams) (int64, error) {
+	return write(store, ctx, func(q *queries) (int64, error) { return q.AccrueInterest(ctx, arg) })
+}
+
+func (store *Store) ListAccountsWithAccruedInterest(ctx context.Context, arg db.ListAccountsWithAccruedInterestParams) ([]db.Account, error) {
+	return read(store, ctx, func(q *queries) ([]db.Account, error) { return q.ListAccountsWithAccruedInterest(ctx, arg) })
+}
@@ -0,0 +1,95 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+
+	db "simplebank/db/sqlc"
+)
+
+func (q *queries) CreateAccountHolder(ctx context.Context, arg db.CreateAccountHolderParams) (db.AccountHolder, error) {
+	if _, ok := q.st.accounts[arg.AccountID]; !ok {
+		return db.AccountHolder{}, foreignKeyViolation("account_holders_account_id_fkey")
+	}
+	if _, ok := q.st.users[arg.Username]; !ok {
+		return db.AccountHolder{}, foreignKeyViolation("account_holders_username_fkey")
+	}
+	if _, ok := q.st.users[arg.InvitedBy]; !ok {
+		return db.AccountHolder{}, foreignKeyViolation("account_holders_invited_by_fkey")
+	}
+	if arg.Permission != db.PermissionView && arg.Permission != db.PermissionTransfer {
+		return db.AccountHolder{}, checkViolation("account_holders_permission_check")
+	}
+
+	key := holderKey{accountID: arg.AccountID, username: arg.Username}
+	if _, ok := q.st.holders[key]; ok {
+		return db.AccountHolder{}, uniqueViolation("account_holders_pkey")
+	}
+
+	holder := db.AccountHolder{
+		AccountID:  arg.AccountID,
+		Username:   arg.Username,
+		Permission: arg.Permission,
+		Status:     db.HolderStatusInvited,
+		InvitedBy:  arg.InvitedBy,
+		CreatedAt:  q.now,
+	}
+	q.st.holders[key] = holder
+	return holder, nil
+}
+
+func (q *queries) GetAccountHolder(ctx context.Context, arg db.GetAccountHolderParams) (db.AccountHolder, error) {
+	holder, ok := q.st.holders[holderKey{accountID: arg.AccountID, username: arg.Username}]
+	if !ok {
+		return db.AccountHolder{}, sql.ErrNoRows
+	}
+	return holder, nil
+}
+
+// 只有待接受的邀请可以接受，否则与数据库一样没有更新任何行
+func (q *queries) AcceptAccountHolder(ctx context.Context, arg db.AcceptAccountHolderParams) (db.AccountHolder, error) {
+	key := holderKey{accountID: arg.AccountID, username: arg.Username}
+	holder, ok := q.st.holders[key]
+	if !ok || holder.Status != db.HolderStatusInvited {
+		return db.AccountHolder{}, sql.ErrNoRows
+	}
+
+	holder.Status = db.HolderStatusAccepted
+	holder.AcceptedAt = sql.NullTime{Time: q.now, Valid: true}
+	q.st.holders[key] = holder
+	return holder, nil
+}
+
+func (q *queries) ListAccountHolders(ctx context.Context, accountID int64) ([]db.AccountHolder, error) {
+	holders := []db.AccountHolder{}
+	for key, holder := range q.st.holders {
+		if key.accountID == accountID {
+			holders = append(holders, holder)
+		}
+	}
+
+	sort.Slice(holders, func(i, j int) bool {
+		if !holders[i].CreatedAt.Equal(holders[j].CreatedAt) {
+			return holders[i].CreatedAt.Before(holders[j].CreatedAt)
+		}
+		return holders[i].Username < holders[j].Username
+	})
+	return holders, nil
+}
+
+func (store *Store) CreateAccountHolder(ctx context.Context, arg db.CreateAccountHolderParams) (db.AccountHolder, error) {
+	return write(store, ctx, func(q *queries) (db.AccountHolder, error) { return q.CreateAccountHolder(ctx, arg) })
+}
+
+func (store *Store) GetAccountHolder(ctx context.Context, arg db.GetAccountHolderParams) (db.AccountHolder, error) {
+	return read(store, ctx, func(q *queries) (db.AccountHolder, error) { return q.GetAccountHolder(ctx, arg) })
+}
+
+func (store *Store) AcceptAccountHolder(ctx context.Context, arg db.AcceptAccountHolderParams) (db.AccountHolder, error) {
+	return write(store, ctx, func(q *queries) (db.AccountHolder, error) { return q.AcceptAccountHolder(ctx, arg) })
+}
+
+func (store *Store) ListAccountHolders(ctx context.Context, accountID int64) ([]db.AccountHolder, error) {
+	return read(store, ctx, func(q *queries) ([]db.AccountHolder, error) { return q.ListAccountHolders(ctx, accountID) })
+}
@@ -0,0 +1,185 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+
+	db "simplebank/db/sqlc"
+)
+
+// 账目在entries中的位置，不存在时返回应插入的位置
+func (st *state) searchEntry(id int64) (int, bool) {
+	i := sort.Search(len(st.entries), func(i int) bool { return st.entries[i].ID >= id })
+	return i, i < len(st.entries) && st.entries[i].ID == id
+}
+
+func (q *queries) CreateEntry(ctx context.Context, arg db.CreateEntryParams) (db.Entry, error) {
+	if _, ok := q.st.accounts[arg.AccountID]; !ok {
+		return db.Entry{}, foreignKeyViolation("entries_account_id_fkey")
+	}
+	if _, ok := q.st.journals[arg.JournalID]; !ok {
+		return db.Entry{}, foreignKeyViolation("entries_journal_id_fkey")
+	}
+
+	i, ok := q.st.searchEntry(arg.ID)
+	if ok {
+		return db.Entry{}, uniqueViolation("entries_pkey")
+	}
+
+	entry := db.Entry{
+		ID:        arg.ID,
+		AccountID: arg.AccountID,
+		Amount:    arg.Amount,
+		CreatedAt: arg.CreatedAt.UTC().Truncate(time.Microsecond),
+		JournalID: arg.JournalID,
+		PrevHash:  arg.PrevHash,
+		Hash:      arg.Hash,
+	}
+	q.st.entries = append(q.st.entries, db.Entry{})
+	copy(q.st.entries[i+1:], q.st.entries[i:])
+	q.st.entries[i] = entry
+
+	q.st.dirtyJournals[entry.JournalID] = true
+	return entry, nil
+}
+
+func (q *queries) NextEntryID(ctx context.Context) (db.NextEntryIDRow, error) {
+	q.st.lastEntryID++
+	return db.NextEntryIDRow{ID: q.st.lastEntryID, CreatedAt: q.now}, nil
+}
+
+func (q *queries) GetLastEntryHash(ctx context.Context, accountID int64) (string, error) {
+	for i := len(q.st.entries) - 1; i >= 0; i-- {
+		if q.st.entries[i].AccountID == accountID {
+			return q.st.entries[i].Hash, nil
+		}
+	}
+	return "", sql.ErrNoRows
+}
+
+func (q *queries) GetEntry(ctx context.Context, id int64) (db.Entry, error) {
+	i, ok := q.st.searchEntry(id)
+	if !ok {
+		return db.Entry{}, sql.ErrNoRows
+	}
+	return q.st.entries[i], nil
+}
+
+// 按id顺序筛选账目
+func (q *queries) filterEntries(match func(entry db.Entry) bool) []db.Entry {
+	entries := []db.Entry{}
+	for _, entry := range q.st.entries {
+		if match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (q *queries) ListAccountEntriesAfter(ctx context.Context, arg db.ListAccountEntriesAfterParams) ([]db.Entry, error) {
+	entries := q.filterEntries(func(entry db.Entry) bool {
+		return entry.AccountID == arg.AccountID && entry.ID > arg.AfterID
+	})
+	return paginate(entries, arg.BatchSize, 0)
+}
+
+func (q *queries) ListEntries(ctx context.Context, arg db.ListEntriesParams) ([]db.Entry, error) {
+	entries := q.filterEntries(func(entry db.Entry) bool {
+		return entry.AccountID == arg.AccountID
+	})
+	return paginate(entries, arg.Limit, arg.Offset)
+}
+
+func (q *queries) ListAccountEntrySums(ctx context.Context, arg db.ListAccountEntrySumsParams) ([]db.ListAccountEntrySumsRow, error) {
+	totals := make(map[int64]int64)
+	for _, entry := range q.st.entries {
+		totals[entry.AccountID] += entry.Amount
+	}
+
+	rows := []db.ListAccountEntrySumsRow{}
+	for _, account := range sortedByID(q.st.accounts) {
+		if account.ID > arg.AfterID {
+			rows = append(rows, db.ListAccountEntrySumsRow{
+				AccountID:    account.ID,
+				Balance:      account.Balance,
+				EntriesTotal: totals[account.ID],
+			})
+		}
+	}
+	return paginate(rows, arg.BatchSize, 0)
+}
+
+func (q *queries) ListOrphanEntries(ctx context.Context, arg db.ListOrphanEntriesParams) ([]db.Entry, error) {
+	entries := q.filterEntries(func(entry db.Entry) bool {
+		return entry.ID > arg.AfterID && !q.st.journals[entry.JournalID].TransferID.Valid
+	})
+	return paginate(entries, arg.BatchSize, 0)
+}
+
+func (q *queries) ListStatementEntries(ctx context.Context, arg db.ListStatementEntriesParams) ([]db.ListStatementEntriesRow, error) {
+	rows := []db.ListStatementEntriesRow{}
+	for _, entry := range q.st.entries {
+		if entry.AccountID != arg.AccountID || entry.CreatedAt.Before(arg.FromTime) || !entry.CreatedAt.Before(arg.ToTime) {
+			continue
+		}
+
+		journal := q.st.journals[entry.JournalID]
+		row := db.ListStatementEntriesRow{
+			ID:          entry.ID,
+			AccountID:   entry.AccountID,
+			Amount:      entry.Amount,
+			CreatedAt:   entry.CreatedAt,
+			Description: journal.Description,
+		}
+
+		if transfer, ok := q.st.transfers[journal.TransferID.Int64]; ok && journal.TransferID.Valid {
+			row.TransferID = sql.NullInt64{Int64: transfer.ID, Valid: true}
+
+			counterpartyID := transfer.FromAccountID
+			if transfer.FromAccountID == entry.AccountID {
+				counterpartyID = transfer.ToAccountID
+			}
+			if counterparty, ok := q.st.accounts[counterpartyID]; ok {
+				row.CounterpartyAccountID = sql.NullInt64{Int64: counterparty.ID, Valid: true}
+				row.CounterpartyOwner = sql.NullString{String: counterparty.Owner, Valid: true}
+			}
+		}
+
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func (store *Store) NextEntryID(ctx context.Context) (db.NextEntryIDRow, error) {
+	return write(store, ctx, func(q *queries) (db.NextEntryIDRow, error) { return q.NextEntryID(ctx) })
+}
+
+func (store *Store) GetLastEntryHash(ctx context.Context, accountID int64) (string, error) {
+	return read(store, ctx, func(q *queries) (string, error) { return q.GetLastEntryHash(ctx, accountID) })
+}
+
+func (store *Store) GetEntry(ctx context.Context, id int64) (db.Entry, error) {
+	return read(store, ctx, func(q *queries) (db.Entry, error) { return q.GetEntry(ctx, id) })
+}
+
+func (store *Store) ListAccountEntriesAfter(ctx context.Context, arg db.ListAccountEntriesAfterParams) ([]db.Entry, error) {
+	return read(store, ctx, func(q *queries) ([]db.Entry, error) { return q.ListAccountEntriesAfter(ctx, arg) })
+}
+
+func (store *Store) ListEntries(ctx context.Context, arg db.ListEntriesParams) ([]db.Entry, error) {
+	return read(store, ctx, func(q *queries) ([]db.Entry, error) { return q.ListEntries(ctx, arg) })
+}
+
+func (store *Store) ListAccountEntrySums(ctx context.Context, arg db.ListAccountEntrySumsParams) ([]db.ListAccountEntrySumsRow, error) {
+	return read(store, ctx, func(q *queries) ([]db.ListAccountEntrySumsRow, error) { return q.ListAccountEntrySums(ctx, arg) })
+}
+
+func (store *Store) ListOrphanEntries(ctx context.Context, arg db.ListOrphanEntriesParams) ([]db.Entry, error) {
+	return read(store, ctx, func(q *queries) ([]db.Entry, error) { return q.ListOrphanEntries(ctx, arg) })
+}
+
+func (store *Store) ListStatementEntries(ctx context.Context, arg db.ListStatementEntriesParams) ([]db.ListStatementEntriesRow, error) {
+	return read(store, ctx, func(q *queries) ([]db.ListStatementEntriesRow, error) { return q.ListStatementEntries(ctx, arg) })
+}
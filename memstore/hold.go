@@ -0,0 +1,124 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "simplebank/db/sqlc"
+)
+
+func (q *queries) CreateHold(ctx context.Context, arg db.CreateHoldParams) (db.Hold, error) {
+	if _, ok := q.st.accounts[arg.AccountID]; !ok {
+		return db.Hold{}, foreignKeyViolation("holds_account_id_fkey")
+	}
+	if _, ok := q.st.accounts[arg.ToAccountID]; !ok {
+		return db.Hold{}, foreignKeyViolation("holds_to_account_id_fkey")
+	}
+
+	hold := db.Hold{
+		ID:          q.st.lastHoldID + 1,
+		AccountID:   arg.AccountID,
+		ToAccountID: arg.ToAccountID,
+		Amount:      arg.Amount,
+		Status:      db.HoldStatusActive,
+		ExpiresAt:   arg.ExpiresAt.UTC().Truncate(time.Microsecond),
+		CreatedAt:   q.now,
+	}
+	q.st.lastHoldID = hold.ID
+	q.st.holds[hold.ID] = hold
+	return hold, nil
+}
+
+func (q *queries) GetHold(ctx context.Context, id int64) (db.Hold, error) {
+	hold, ok := q.st.holds[id]
+	if !ok {
+		return db.Hold{}, sql.ErrNoRows
+	}
+	return hold, nil
+}
+
+func (q *queries) GetHoldForUpdate(ctx context.Context, id int64) (db.Hold, error) {
+	return q.GetHold(ctx, id)
+}
+
+func (q *queries) GetActiveHoldsAmount(ctx context.Context, accountID int64) (int64, error) {
+	var amount int64
+	for _, hold := range q.st.holds {
+		if hold.AccountID == accountID && hold.Status == db.HoldStatusActive && hold.ExpiresAt.After(q.now) {
+			amount += hold.Amount
+		}
+	}
+	return amount, nil
+}
+
+func (q *queries) CaptureHold(ctx context.Context, arg db.CaptureHoldParams) (db.Hold, error) {
+	hold, ok := q.st.holds[arg.ID]
+	if !ok || hold.Status != db.HoldStatusActive || !hold.ExpiresAt.After(q.now) {
+		return db.Hold{}, sql.ErrNoRows
+	}
+
+	if arg.TransferID.Valid {
+		if _, ok := q.st.transfers[arg.TransferID.Int64]; !ok {
+			return db.Hold{}, foreignKeyViolation("holds_transfer_id_fkey")
+		}
+	}
+
+	hold.Status = db.HoldStatusCaptured
+	hold.CapturedAmount = arg.CapturedAmount
+	hold.TransferID = arg.TransferID
+	q.st.holds[hold.ID] = hold
+	return hold, nil
+}
+
+// 只有有效的预授权可以释放，否则与数据库一样没有更新任何行
+func (q *queries) ReleaseHold(ctx context.Context, id int64) (db.Hold, error) {
+	hold, ok := q.st.holds[id]
+	if !ok || hold.Status != db.HoldStatusActive {
+		return db.Hold{}, sql.ErrNoRows
+	}
+
+	hold.Status = db.HoldStatusReleased
+	q.st.holds[hold.ID] = hold
+	return hold, nil
+}
+
+func (q *queries) ReleaseExpiredHolds(ctx context.Context) ([]db.Hold, error) {
+	holds := []db.Hold{}
+	for _, hold := range sortedByID(q.st.holds) {
+		if hold.Status == db.HoldStatusActive && !hold.ExpiresAt.After(q.now) {
+			hold.Status = db.HoldStatusExpired
+			q.st.holds[hold.ID] = hold
+			holds = append(holds, hold)
+		}
+	}
+	return holds, nil
+}
+
+func (store *Store) CreateHold(ctx context.Context, arg db.CreateHoldParams) (db.Hold, error) {
+	return write(store, ctx, func(q *queries) (db.Hold, error) { return q.CreateHold(ctx, arg) })
+}
+
+func (store *Store) GetHold(ctx context.Context, id int64) (db.Hold, error) {
+	return read(store, ctx, func(q *queries) (db.Hold, error) { return q.GetHold(ctx, id) })
+}
+
+func (store *Store) GetHoldForUpdate(ctx context.Context, id int64) (db.Hold, error) {
+	return read(store, ctx, func(q *queries) (db.Hold, error) { return q.GetHoldForUpdate(ctx, id) })
+}
+
+func (store *Store) GetActiveHoldsAmount(ctx context.Context, accountID int64) (int64, error) {
+	return read(store, ctx, func(q *queries) (int64, error) { return q.GetActiveHoldsAmount(ctx, accountID) })
+}
+
+func (store *Store) CaptureHold(ctx context.Context, arg db.CaptureHoldParams) (db.Hold, error) {
+	return write(store, ctx, func(q *queries) (db.Hold, error) { return q.CaptureHold(ctx, arg) })
+}
+
+func (store *Store) ReleaseHold(ctx context.Context, id int64) (db.Hold, error) {
+	return write(store, ctx, func(q *queries) (db.Hold, error) { return q.ReleaseHold(ctx, id) })
+}
+
+func (store *Store) ReleaseExpiredHolds(ctx context.Context) ([]db.Hold, error) {
+	return write(store, ctx, func(q *queries) ([]db.Hold, error) { return q.ReleaseExpiredHolds(ctx) })
+}
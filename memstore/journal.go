@@ -0,0 +1,91 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	db "simplebank/db/sqlc"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func (q *queries) CreateJournalTransaction(ctx context.Context, arg db.CreateJournalTransactionParams) (db.JournalTransaction, error) {
+	if arg.TransferID.Valid {
+		if _, ok := q.st.transfers[arg.TransferID.Int64]; !ok {
+			return db.JournalTransaction{}, foreignKeyViolation("journal_transactions_transfer_id_fkey")
+		}
+
+		for _, journal := range q.st.journals {
+			if journal.TransferID == arg.TransferID {
+				return db.JournalTransaction{}, uniqueViolation("journal_transactions_transfer_id_key")
+			}
+		}
+	}
+
+	journal := db.JournalTransaction{
+		ID:          q.st.lastJournalID + 1,
+		TransferID:  arg.TransferID,
+		Description: arg.Description,
+		CreatedAt:   q.now,
+	}
+	q.st.lastJournalID = journal.ID
+	q.st.journals[journal.ID] = journal
+	return journal, nil
+}
+
+func (q *queries) GetJournalTransaction(ctx context.Context, id int64) (db.JournalTransaction, error) {
+	journal, ok := q.st.journals[id]
+	if !ok {
+		return db.JournalTransaction{}, sql.ErrNoRows
+	}
+	return journal, nil
+}
+
+func (q *queries) ListJournalEntries(ctx context.Context, journalID int64) ([]db.Entry, error) {
+	return q.filterEntries(func(entry db.Entry) bool {
+		return entry.JournalID == journalID
+	}), nil
+}
+
+// 同一凭证下的账目按币种求和必须为0，对应数据库中提交时检查的约束触发器entries_journal_balanced
+func (q *queries) checkJournalsBalanced() error {
+	if len(q.st.dirtyJournals) == 0 {
+		return nil
+	}
+
+	sums := make(map[int64]map[string]int64)
+	for _, entry := range q.st.entries {
+		if !q.st.dirtyJournals[entry.JournalID] {
+			continue
+		}
+		if sums[entry.JournalID] == nil {
+			sums[entry.JournalID] = make(map[string]int64)
+		}
+		sums[entry.JournalID][q.st.accounts[entry.AccountID].Currency] += entry.Amount
+	}
+
+	for journalID, byCurrency := range sums {
+		for _, sum := range byCurrency {
+			if sum != 0 {
+				return &pgconn.PgError{
+					Code:    db.CheckViolation,
+					Message: fmt.Sprintf("journal transaction %d is not balanced", journalID),
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (store *Store) CreateJournalTransaction(ctx context.Context, arg db.CreateJournalTransactionParams) (db.JournalTransaction, error) {
+	return write(store, ctx, func(q *queries) (db.JournalTransaction, error) { return q.CreateJournalTransaction(ctx, arg) })
+}
+
+func (store *Store) GetJournalTransaction(ctx context.Context, id int64) (db.JournalTransaction, error) {
+	return read(store, ctx, func(q *queries) (db.JournalTransaction, error) { return q.GetJournalTransaction(ctx, id) })
+}
+
+func (store *Store) ListJournalEntries(ctx context.Context, journalID int64) ([]db.Entry, error) {
+	return read(store, ctx, func(q *queries) ([]db.Entry, error) { return q.ListJournalEntries(ctx, journalID) })
+}
@@ -0,0 +1,311 @@
+// Package memstore 是db.Store的内存实现，用于处理函数测试和不依赖数据库的本地开发。
+// 约束、错误（sql.ErrNoRows、唯一约束冲突等）和事务语义与PostgreSQL实现保持一致，
+// 由db/storetest中的一致性测试保证
+package memstore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"simplebank/db/migration"
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+	"simplebank/util"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// DriverName 配置DB_DRIVER为该值时使用内存存储
+const DriverName = "memory"
+
+type holderKey struct {
+	accountID int64
+	username  string
+}
+
+type snapshotKey struct {
+	accountID int64
+	date      time.Time
+}
+
+// state 是某一时刻的全部数据，事务在副本上修改，提交时整体替换
+type state struct {
+	users     map[string]db.User
+	accounts  map[int64]db.Account
+	entries   []db.Entry // 按id排序
+	transfers map[int64]db.Transfer
+	journals  map[int64]db.JournalTransaction
+	holds     map[int64]db.Hold
+	holders   map[holderKey]db.AccountHolder
+	snapshots map[snapshotKey]db.BalanceSnapshot
+
+	// 各表的自增序列
+	lastAccountID  int64
+	lastEntryID    int64
+	lastTransferID int64
+	lastJournalID  int64
+	lastHoldID     int64
+
+	// 事务中写入过账目的凭证，提交时检查借贷平衡（对应数据库中延迟检查的约束触发器）
+	dirtyJournals map[int64]bool
+}
+
+func newState() *state {
+	return &state{
+		users:         make(map[string]db.User),
+		accounts:      make(map[int64]db.Account),
+		transfers:     make(map[int64]db.Transfer),
+		journals:      make(map[int64]db.JournalTransaction),
+		holds:         make(map[int64]db.Hold),
+		holders:       make(map[holderKey]db.AccountHolder),
+		snapshots:     make(map[snapshotKey]db.BalanceSnapshot),
+		dirtyJournals: make(map[int64]bool),
+	}
+}
+
+func (st *state) clone() *state {
+	copied := *st
+	copied.users = cloneMap(st.users)
+	copied.accounts = cloneMap(st.accounts)
+	copied.entries = append([]db.Entry(nil), st.entries...)
+	copied.transfers = cloneMap(st.transfers)
+	copied.journals = cloneMap(st.journals)
+	copied.holds = cloneMap(st.holds)
+	copied.holders = cloneMap(st.holders)
+	copied.snapshots = cloneMap(st.snapshots)
+	copied.dirtyJournals = make(map[int64]bool)
+	return &copied
+}
+
+func cloneMap[K comparable, V any](m map[K]V) map[K]V {
+	copied := make(map[K]V, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	return copied
+}
+
+// Store 用一把读写锁串行化所有写操作，事务的隔离级别相当于SERIALIZABLE
+type Store struct {
+	db.Transactions
+
+	mu    sync.RWMutex
+	state *state
+	// 上一个事务的开始时间，保证先提交的事务写入的时间戳更早
+	lastTxAt time.Time
+	metrics  db.Metrics
+}
+
+var _ db.Store = (*Store)(nil)
+
+// 迁移000004和000008中创建的银行内部用户
+var bankUsers = []db.CreateUserParams{
+	{
+		Username:       db.SettlementOwner,
+		HashedPassword: "!",
+		FullName:       "Bank Settlement",
+		Email:          "settlement@simplebank.internal",
+	},
+	{
+		Username:       db.InterestOwner,
+		HashedPassword: "!",
+		FullName:       "Bank Interest Expense",
+		Email:          "interest@simplebank.internal",
+	},
+}
+
+type Option func(store *Store)
+
+// WithMetrics 记录事务中的业务指标，与db.WithMetrics相同
+func WithMetrics(metrics db.Metrics) Option {
+	return func(store *Store) {
+		store.metrics = metrics
+	}
+}
+
+// New 创建一个内存存储，与执行完迁移的数据库一样包含银行内部用户及其各币种账户
+func New(options ...Option) *Store {
+	store := &Store{state: newState()}
+	for _, option := range options {
+		option(store)
+	}
+	store.Transactions = db.NewTransactions(store.runTx, store.metrics)
+	ctx := context.Background()
+
+	err := store.execTx(ctx, func(q *queries) error {
+		for _, arg := range bankUsers {
+			_, err := q.CreateUser(ctx, arg)
+			if err != nil {
+				return err
+			}
+
+			_, err = q.UpdateUserRole(ctx, db.UpdateUserRoleParams{
+				Username: arg.Username,
+				Role:     token.BankerRole,
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, currency := range util.SupportedCurrencies() {
+				_, err = q.CreateAccount(ctx, db.CreateAccountParams{
+					Owner:    arg.Username,
+					Currency: currency,
+					Type:     util.Checking,
+				})
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("cannot initialize memstore: %v", err))
+	}
+
+	return store
+}
+
+// queries 在某个state上执行查询，实现db.Querier，本身不加锁
+type queries struct {
+	st *state
+	// 对应数据库的now()，同一事务内保持不变
+	now time.Time
+}
+
+var _ db.Querier = (*queries)(nil)
+
+// 数据库时间精度为微秒
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// execTx 在state的副本上执行fn，成功且通过延迟约束检查后才替换当前state，否则丢弃副本
+func (store *Store) execTx(ctx context.Context, fn func(q *queries) error) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	txAt := now()
+	if !txAt.After(store.lastTxAt) {
+		txAt = store.lastTxAt.Add(time.Microsecond)
+	}
+
+	q := &queries{st: store.state.clone(), now: txAt}
+	err := fn(q)
+	if err != nil {
+		return err
+	}
+
+	err = q.checkJournalsBalanced()
+	if err != nil {
+		return err
+	}
+
+	store.state = q.st
+	store.lastTxAt = txAt
+	return nil
+}
+
+func read[T any](store *Store, ctx context.Context, fn func(q *queries) (T, error)) (T, error) {
+	store.mu.RLock()
+	defer store.mu.RUnlock()
+
+	if err := ctx.Err(); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return fn(&queries{st: store.state, now: now()})
+}
+
+// write 执行单条写操作，与数据库一样，单条语句也在一个隐式事务中执行
+func write[T any](store *Store, ctx context.Context, fn func(q *queries) (T, error)) (T, error) {
+	var result T
+	err := store.execTx(ctx, func(q *queries) error {
+		var err error
+		result, err = fn(q)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return result, nil
+}
+
+func (store *Store) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// GetSchemaVersion 内存存储总是与最新的表结构一致
+func (store *Store) GetSchemaVersion(ctx context.Context) (db.SchemaVersion, error) {
+	latest, err := migration.LatestVersion()
+	if err != nil {
+		return db.SchemaVersion{}, err
+	}
+	return db.SchemaVersion{Version: int64(latest)}, ctx.Err()
+}
+
+// 与数据库返回的错误一致，db.ErrorCode可以取到相同的错误码
+func uniqueViolation(constraint string) error {
+	return &pgconn.PgError{
+		Code:           db.UniqueViolation,
+		Message:        fmt.Sprintf("duplicate key value violates unique constraint %q", constraint),
+		ConstraintName: constraint,
+	}
+}
+
+func foreignKeyViolation(constraint string) error {
+	return &pgconn.PgError{
+		Code:           db.ForeignKeyViolation,
+		Message:        fmt.Sprintf("insert or update violates foreign key constraint %q", constraint),
+		ConstraintName: constraint,
+	}
+}
+
+func checkViolation(constraint string) error {
+	return &pgconn.PgError{
+		Code:           db.CheckViolation,
+		Message:        fmt.Sprintf("new row violates check constraint %q", constraint),
+		ConstraintName: constraint,
+	}
+}
+
+// LIMIT/OFFSET为负数时数据库报错
+func paginate[T any](rows []T, limit int32, offset int32) ([]T, error) {
+	if limit < 0 || offset < 0 {
+		return nil, &pgconn.PgError{Code: "2201W", Message: "LIMIT and OFFSET must not be negative"}
+	}
+
+	if int(offset) >= len(rows) {
+		return []T{}, nil
+	}
+	rows = rows[offset:]
+	if int(limit) < len(rows) {
+		rows = rows[:limit]
+	}
+	return rows, nil
+}
+
+// 按id排序的map值
+func sortedByID[V any](m map[int64]V) []V {
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	values := make([]V, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, m[id])
+	}
+	return values
+}
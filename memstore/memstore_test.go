@@ -0,0 +1,191 @@
+package memstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"simplebank/db/migration"
+	db "simplebank/db/sqlc"
+	"simplebank/db/storetest"
+	"simplebank/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) db.Store {
+		return New()
+	})
+}
+
+func TestNewSeedsBankAccounts(t *testing.T) {
+	store := New()
+
+	for _, owner := range []string{db.SettlementOwner, db.InterestOwner} {
+		for _, currency := range util.SupportedCurrencies() {
+			account, err := store.GetAccountByOwnerAndCurrency(context.Background(), db.GetAccountByOwnerAndCurrencyParams{
+				Owner:    owner,
+				Currency: currency,
+			})
+			require.NoError(t, err)
+			require.Zero(t, account.Balance)
+		}
+	}
+
+	latest, err := migration.LatestVersion()
+	require.NoError(t, err)
+
+	version, err := store.GetSchemaVersion(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, db.SchemaVersion{Version: int64(latest)}, version)
+}
+
+// 快照是全局操作，只在独立的内存存储上测试
+func TestDailyBalanceSnapshots(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+
+	user, err := store.CreateUser(ctx, db.CreateUserParams{Username: util.RandomOwner(), Email: util.RandomEmail()})
+	require.NoError(t, err)
+	// 开户时的初始余额没有对应账目，从期初余额开始累加
+	account, err := store.CreateAccount(ctx, db.CreateAccountParams{Owner: user.Username, Balance: 30, Currency: util.USD, Type: util.Checking})
+	require.NoError(t, err)
+	_, err = store.DepositTx(ctx, db.CashTxParams{AccountID: account.ID, Amount: 100})
+	require.NoError(t, err)
+
+	today := db.SnapshotDate(time.Now())
+	created, err := store.CreateDailyBalanceSnapshots(ctx, db.CreateDailyBalanceSnapshotsParams{
+		SnapshotDate: today,
+		Cutoff:       db.SnapshotCutoff(today),
+	})
+	require.NoError(t, err)
+	// 两个银行内部用户各3个账户，加上新账户
+	require.Equal(t, int64(7), created)
+
+	// 重复执行不会覆盖已有快照
+	created, err = store.CreateDailyBalanceSnapshots(ctx, db.CreateDailyBalanceSnapshotsParams{
+		SnapshotDate: today,
+		Cutoff:       db.SnapshotCutoff(today),
+	})
+	require.NoError(t, err)
+	require.Zero(t, created)
+
+	tomorrow := today.AddDate(0, 0, 1)
+	snapshot, err := store.GetLatestBalanceSnapshot(ctx, db.GetLatestBalanceSnapshotParams{AccountID: account.ID, BeforeDate: tomorrow})
+	require.NoError(t, err)
+	require.Equal(t, int64(130), snapshot.Balance)
+	require.True(t, today.Equal(snapshot.SnapshotDate))
+
+	balance, err := store.GetBalanceAt(ctx, account.ID, tomorrow.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(130), balance)
+}
+
+// 失败的事务不留下任何修改，包括约束检查失败的单条语句
+func TestExecTxRollback(t *testing.T) {
+	ctx := context.Background()
+	store := New()
+
+	before, err := store.CountAccounts(ctx)
+	require.NoError(t, err)
+
+	_, err = store.CreateAccount(ctx, db.CreateAccountParams{Owner: db.SettlementOwner, Currency: util.USD, Type: "brokerage"})
+	require.Equal(t, db.CheckViolation, db.ErrorCode(err))
+
+	// 借贷不平衡的凭证在提交时被拒绝
+	err = store.execTx(ctx, func(q *queries) error {
+		journal, err := q.CreateJournalTransaction(ctx, db.CreateJournalTransactionParams{Description: "unbalanced"})
+		if err != nil {
+			return err
+		}
+		next, err := q.NextEntryID(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = q.CreateEntry(ctx, db.CreateEntryParams{ID: next.ID, AccountID: 1, Amount: 10, JournalID: journal.ID, CreatedAt: next.CreatedAt})
+		return err
+	})
+	require.Equal(t, db.CheckViolation, db.ErrorCode(err))
+
+	after, err := store.CountAccounts(ctx)
+	require.NoError(t, err)
+	require.Equal(t, before, after)
+
+	entries, err := store.ListEntries(ctx, db.ListEntriesParams{AccountID: 1, Limit: 10})
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	store := New()
+	_, err := store.GetAccount(ctx, 1)
+	require.ErrorIs(t, err, context.Canceled)
+
+	_, err = store.DepositTx(ctx, db.CashTxParams{AccountID: 1, Amount: 10})
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+type recordedTransfer struct {
+	kind     string
+	currency string
+	amount   int64
+}
+
+type recordingMetrics struct {
+	transfers []recordedTransfer
+}
+
+func (metrics *recordingMetrics) ObserveTx(name string, duration time.Duration, err error) {}
+
+func (metrics *recordingMetrics) IncTxRetry(name string) {}
+
+func (metrics *recordingMetrics) TransferCompleted(kind string, currency string, amount int64) {
+	metrics.transfers = append(metrics.transfers, recordedTransfer{kind: kind, currency: currency, amount: amount})
+}
+
+// 存取款和预授权扣款同样记录为完成的资金转移，失败的事务不记录
+func TestTransferMetrics(t *testing.T) {
+	ctx := context.Background()
+	metrics := &recordingMetrics{}
+	store := New(WithMetrics(metrics))
+
+	var accounts []db.Account
+	for i := 0; i < 2; i++ {
+		user, err := store.CreateUser(ctx, db.CreateUserParams{Username: util.RandomOwner(), Email: util.RandomEmail()})
+		require.NoError(t, err)
+		account, err := store.CreateAccount(ctx, db.CreateAccountParams{Owner: user.Username, Currency: util.EUR, Type: util.Checking})
+		require.NoError(t, err)
+		accounts = append(accounts, account)
+	}
+
+	_, err := store.DepositTx(ctx, db.CashTxParams{AccountID: accounts[0].ID, Amount: 100})
+	require.NoError(t, err)
+	_, err = store.WithdrawTx(ctx, db.CashTxParams{AccountID: accounts[0].ID, Amount: 10})
+	require.NoError(t, err)
+	_, err = store.TransferTx(ctx, db.TransferTxParams{FromAccountID: accounts[0].ID, ToAccountID: accounts[1].ID, Amount: 20})
+	require.NoError(t, err)
+
+	hold, err := store.PlaceHoldTx(ctx, db.PlaceHoldTxParams{
+		AccountID:   accounts[0].ID,
+		ToAccountID: accounts[1].ID,
+		Amount:      30,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	_, err = store.CaptureHoldTx(ctx, db.CaptureHoldTxParams{HoldID: hold.ID, Amount: 25})
+	require.NoError(t, err)
+
+	_, err = store.WithdrawTx(ctx, db.CashTxParams{AccountID: accounts[0].ID, Amount: 1000})
+	require.ErrorIs(t, err, db.ErrInsufficientFunds)
+
+	require.Equal(t, []recordedTransfer{
+		{kind: db.TransferKindDeposit, currency: util.EUR, amount: 100},
+		{kind: db.TransferKindWithdrawal, currency: util.EUR, amount: 10},
+		{kind: db.TransferKindTransfer, currency: util.EUR, amount: 20},
+		{kind: db.TransferKindHoldCapture, currency: util.EUR, amount: 25},
+	}, metrics.transfers)
+}
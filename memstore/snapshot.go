@@ -0,0 +1,119 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "simplebank/db/sqlc"
+)
+
+// 账户在date之前的最近一份快照
+func (q *queries) latestSnapshot(accountID int64, date time.Time) (db.BalanceSnapshot, bool) {
+	var latest db.BalanceSnapshot
+	found := false
+	for key, snapshot := range q.st.snapshots {
+		if key.accountID != accountID || !key.date.Before(date) {
+			continue
+		}
+		if !found || key.date.After(latest.SnapshotDate) {
+			latest = snapshot
+			found = true
+		}
+	}
+	return latest, found
+}
+
+func (q *queries) CreateDailyBalanceSnapshots(ctx context.Context, arg db.CreateDailyBalanceSnapshotsParams) (int64, error) {
+	date := db.SnapshotDate(arg.SnapshotDate)
+
+	var created int64
+	for _, account := range sortedByID(q.st.accounts) {
+		if !account.CreatedAt.Before(arg.Cutoff) {
+			continue
+		}
+
+		// 从上一份快照的次日0点开始累加，没有快照时从期初余额开始
+		prev, ok := q.latestSnapshot(account.ID, date)
+		balance := prev.Balance
+		if !ok {
+			balance = q.openingBalance(account)
+		}
+		for _, entry := range q.st.entries {
+			if entry.AccountID != account.ID || !entry.CreatedAt.Before(arg.Cutoff) {
+				continue
+			}
+			if ok && entry.CreatedAt.Before(db.SnapshotCutoff(prev.SnapshotDate)) {
+				continue
+			}
+			balance += entry.Amount
+		}
+
+		key := snapshotKey{accountID: account.ID, date: date}
+		if _, exists := q.st.snapshots[key]; exists {
+			continue
+		}
+
+		q.st.snapshots[key] = db.BalanceSnapshot{
+			AccountID:    account.ID,
+			SnapshotDate: date,
+			Balance:      balance,
+			CreatedAt:    q.now,
+		}
+		created++
+	}
+	return created, nil
+}
+
+func (q *queries) GetLatestBalanceSnapshot(ctx context.Context, arg db.GetLatestBalanceSnapshotParams) (db.BalanceSnapshot, error) {
+	snapshot, ok := q.latestSnapshot(arg.AccountID, db.SnapshotDate(arg.BeforeDate))
+	if !ok {
+		return db.BalanceSnapshot{}, sql.ErrNoRows
+	}
+	return snapshot, nil
+}
+
+// 余额减去全部账目之和
+func (q *queries) openingBalance(account db.Account) int64 {
+	balance := account.Balance
+	for _, entry := range q.st.entries {
+		if entry.AccountID == account.ID {
+			balance -= entry.Amount
+		}
+	}
+	return balance
+}
+
+func (q *queries) GetOpeningBalance(ctx context.Context, accountID int64) (db.GetOpeningBalanceRow, error) {
+	account, ok := q.st.accounts[accountID]
+	if !ok {
+		return db.GetOpeningBalanceRow{}, sql.ErrNoRows
+	}
+	return db.GetOpeningBalanceRow{OpeningBalance: q.openingBalance(account), CreatedAt: account.CreatedAt}, nil
+}
+
+func (q *queries) SumEntriesBetween(ctx context.Context, arg db.SumEntriesBetweenParams) (int64, error) {
+	var amount int64
+	for _, entry := range q.st.entries {
+		if entry.AccountID == arg.AccountID && !entry.CreatedAt.Before(arg.FromTime) && entry.CreatedAt.Before(arg.ToTime) {
+			amount += entry.Amount
+		}
+	}
+	return amount, nil
+}
+
+func (store *Store) CreateDailyBalanceSnapshots(ctx context.Context, arg db.CreateDailyBalanceSnapshotsParams) (int64, error) {
+	return write(store, ctx, func(q *queries) (int64, error) { return q.CreateDailyBalanceSnapshots(ctx, arg) })
+}
+
+func (store *Store) GetLatestBalanceSnapshot(ctx context.Context, arg db.GetLatestBalanceSnapshotParams) (db.BalanceSnapshot, error) {
+	return read(store, ctx, func(q *queries) (db.BalanceSnapshot, error) { return q.GetLatestBalanceSnapshot(ctx, arg) })
+}
+
+func (store *Store) SumEntriesBetween(ctx context.Context, arg db.SumEntriesBetweenParams) (int64, error) {
+	return read(store, ctx, func(q *queries) (int64, error) { return q.SumEntriesBetween(ctx, arg) })
+}
+
+func (store *Store) GetOpeningBalance(ctx context.Context, accountID int64) (db.GetOpeningBalanceRow, error) {
+	return read(store, ctx, func(q *queries) (db.GetOpeningBalanceRow, error) { return q.GetOpeningBalance(ctx, accountID) })
+}
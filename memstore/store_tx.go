@@ -0,0 +1,25 @@
+package memstore
+
+import (
+	"context"
+	"time"
+
+	db "simplebank/db/sqlc"
+)
+
+// 事务方法由db.Transactions实现，与db.SQLStore共用同一份逻辑，这里只提供执行事务的TxRunner
+var _ db.TxQuerier = (*queries)(nil)
+
+// runTx execTx保证事务要么全部生效要么全部不生效；所有写操作已经串行执行，不会有序列化冲突，不需要重试
+func (store *Store) runTx(ctx context.Context, name string, fn func(q db.TxQuerier) error) error {
+	return store.execTx(ctx, func(q *queries) error {
+		return fn(q)
+	})
+}
+
+// GetBalanceAt 在同一个读锁内读取快照和账目
+func (store *Store) GetBalanceAt(ctx context.Context, accountID int64, at time.Time) (int64, error) {
+	return read(store, ctx, func(q *queries) (int64, error) {
+		return db.BalanceAt(ctx, q, accountID, at)
+	})
+}
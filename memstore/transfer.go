@@ -0,0 +1,102 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+
+	db "simplebank/db/sqlc"
+)
+
+func (q *queries) CreateTransfer(ctx context.Context, arg db.CreateTransferParams) (db.Transfer, error) {
+	if _, ok := q.st.accounts[arg.FromAccountID]; !ok {
+		return db.Transfer{}, foreignKeyViolation("transfers_from_account_id_fkey")
+	}
+	if _, ok := q.st.accounts[arg.ToAccountID]; !ok {
+		return db.Transfer{}, foreignKeyViolation("transfers_to_account_id_fkey")
+	}
+
+	transfer := db.Transfer{
+		ID:            q.st.lastTransferID + 1,
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		CreatedAt:     q.now,
+	}
+	q.st.lastTransferID = transfer.ID
+	q.st.transfers[transfer.ID] = transfer
+	return transfer, nil
+}
+
+func (q *queries) GetTransfer(ctx context.Context, id int64) (db.Transfer, error) {
+	transfer, ok := q.st.transfers[id]
+	if !ok {
+		return db.Transfer{}, sql.ErrNoRows
+	}
+	return transfer, nil
+}
+
+func (q *queries) ListTransfers(ctx context.Context, arg db.ListTransfersParams) ([]db.Transfer, error) {
+	transfers := []db.Transfer{}
+	for _, transfer := range sortedByID(q.st.transfers) {
+		if transfer.FromAccountID == arg.FromAccountID || transfer.ToAccountID == arg.ToAccountID {
+			transfers = append(transfers, transfer)
+		}
+	}
+	return paginate(transfers, arg.Limit, arg.Offset)
+}
+
+func (q *queries) ListUnbalancedTransfers(ctx context.Context, arg db.ListUnbalancedTransfersParams) ([]db.ListUnbalancedTransfersRow, error) {
+	journalIDs := make(map[int64]int64)
+	for _, journal := range q.st.journals {
+		if journal.TransferID.Valid {
+			journalIDs[journal.TransferID.Int64] = journal.ID
+		}
+	}
+
+	rows := []db.ListUnbalancedTransfersRow{}
+	for _, transfer := range sortedByID(q.st.transfers) {
+		if transfer.ID <= arg.AfterID {
+			continue
+		}
+
+		var count int64
+		if journalID, ok := journalIDs[transfer.ID]; ok {
+			for _, entry := range q.st.entries {
+				if entry.JournalID != journalID {
+					continue
+				}
+				if (entry.AccountID == transfer.FromAccountID && entry.Amount == -transfer.Amount) ||
+					(entry.AccountID == transfer.ToAccountID && entry.Amount == transfer.Amount) {
+					count++
+				}
+			}
+		}
+
+		if count != 2 {
+			rows = append(rows, db.ListUnbalancedTransfersRow{
+				ID:            transfer.ID,
+				FromAccountID: transfer.FromAccountID,
+				ToAccountID:   transfer.ToAccountID,
+				Amount:        transfer.Amount,
+				EntryCount:    count,
+			})
+		}
+	}
+	return paginate(rows, arg.BatchSize, 0)
+}
+
+func (store *Store) CreateTransfer(ctx context.Context, arg db.CreateTransferParams) (db.Transfer, error) {
+	return write(store, ctx, func(q *queries) (db.Transfer, error) { return q.CreateTransfer(ctx, arg) })
+}
+
+func (store *Store) GetTransfer(ctx context.Context, id int64) (db.Transfer, error) {
+	return read(store, ctx, func(q *queries) (db.Transfer, error) { return q.GetTransfer(ctx, id) })
+}
+
+func (store *Store) ListTransfers(ctx context.Context, arg db.ListTransfersParams) ([]db.Transfer, error) {
+	return read(store, ctx, func(q *queries) ([]db.Transfer, error) { return q.ListTransfers(ctx, arg) })
+}
+
+func (store *Store) ListUnbalancedTransfers(ctx context.Context, arg db.ListUnbalancedTransfersParams) ([]db.ListUnbalancedTransfersRow, error) {
+	return read(store, ctx, func(q *queries) ([]db.ListUnbalancedTransfersRow, error) { return q.ListUnbalancedTransfers(ctx, arg) })
+}
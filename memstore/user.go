@@ -0,0 +1,71 @@
+package memstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	db "simplebank/db/sqlc"
+	"simplebank/token"
+)
+
+// users.password_changed_at的默认值
+var zeroPasswordChangedAt = time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func (q *queries) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+	if _, ok := q.st.users[arg.Username]; ok {
+		return db.User{}, uniqueViolation("users_pkey")
+	}
+
+	for _, user := range q.st.users {
+		if user.Email == arg.Email {
+			return db.User{}, uniqueViolation("users_email_key")
+		}
+	}
+
+	user := db.User{
+		Username:          arg.Username,
+		HashedPassword:    arg.HashedPassword,
+		FullName:          arg.FullName,
+		Email:             arg.Email,
+		PasswordChangedAt: zeroPasswordChangedAt,
+		CreatedAt:         q.now,
+		Role:              token.DepositorRole,
+	}
+	if arg.Role.Valid {
+		user.Role = arg.Role.String
+	}
+	q.st.users[user.Username] = user
+	return user, nil
+}
+
+func (q *queries) GetUser(ctx context.Context, username string) (db.User, error) {
+	user, ok := q.st.users[username]
+	if !ok {
+		return db.User{}, sql.ErrNoRows
+	}
+	return user, nil
+}
+
+func (q *queries) UpdateUserRole(ctx context.Context, arg db.UpdateUserRoleParams) (db.User, error) {
+	user, ok := q.st.users[arg.Username]
+	if !ok {
+		return db.User{}, sql.ErrNoRows
+	}
+
+	user.Role = arg.Role
+	q.st.users[user.Username] = user
+	return user, nil
+}
+
+func (store *Store) CreateUser(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+	return write(store, ctx, func(q *queries) (db.User, error) { return q.CreateUser(ctx, arg) })
+}
+
+func (store *Store) GetUser(ctx context.Context, username string) (db.User, error) {
+	return read(store, ctx, func(q *queries) (db.User, error) { return q.GetUser(ctx, username) })
+}
+
+func (store *Store) UpdateUserRole(ctx context.Context, arg db.UpdateUserRoleParams) (db.User, error) {
+	return write(store, ctx, func(q *queries) (db.User, error) { return q.UpdateUserRole(ctx, arg) })
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "simple_bank"
+
+// Metrics 基于Prometheus的监控指标，实现db.Metrics接口；使用独立的registry，测试中可以创建多个实例
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequestDuration *prometheus.HistogramVec
+	txDuration          *prometheus.HistogramVec
+	txRetries           *prometheus.CounterVec
+	transfers           *prometheus.CounterVec
+	transferredAmount   *prometheus.CounterVec
+	failedLogins        prometheus.Counter
+}
+
+func New() *Metrics {
+	metrics := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route, method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		txDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_tx_duration_seconds",
+			Help:      "Database transaction latency by transaction name and result.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"tx", "result"}),
+		txRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_tx_retries_total",
+			Help:      "Database transactions retried after a serialization failure or deadlock.",
+		}, []string{"tx"}),
+		transfers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transfers_total",
+			Help:      "Completed money movements by kind (transfer, deposit, withdrawal, hold_capture, interest) and currency.",
+		}, []string{"kind", "currency"}),
+		transferredAmount: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "transferred_amount_total",
+			Help:      "Amount moved by completed money movements by kind and currency, in minor currency units.",
+		}, []string{"kind", "currency"}),
+		failedLogins: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "failed_logins_total",
+			Help:      "Login attempts rejected because of an unknown user or a wrong password.",
+		}),
+	}
+
+	metrics.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		metrics.httpRequestDuration,
+		metrics.txDuration,
+		metrics.txRetries,
+		metrics.transfers,
+		metrics.transferredAmount,
+		metrics.failedLogins,
+	)
+
+	return metrics
+}
+
+// RegisterDB 导出连接池的状态（pgxpool.Stat）
+func (metrics *Metrics) RegisterDB(pool *pgxpool.Pool, dbName string) {
+	metrics.registry.MustRegister(newPoolCollector(pool, dbName))
+}
+
+// Handler 返回/metrics的处理函数
+func (metrics *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(metrics.registry, promhttp.HandlerOpts{Registry: metrics.registry})
+}
+
+// Registry 用于注册其他模块自定义的指标
+func (metrics *Metrics) Registry() *prometheus.Registry {
+	return metrics.registry
+}
+
+// GinMiddleware 按路由模板（而不是实际路径）统计请求耗时，避免/accounts/:id产生大量时间序列
+func (metrics *Metrics) GinMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		start := time.Now()
+		ctx.Next()
+
+		route := ctx.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+
+		metrics.httpRequestDuration.
+			WithLabelValues(ctx.Request.Method, route, strconv.Itoa(ctx.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
+
+func (metrics *Metrics) ObserveTx(name string, duration time.Duration, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	metrics.txDuration.WithLabelValues(name, result).Observe(duration.Seconds())
+}
+
+func (metrics *Metrics) IncTxRetry(name string) {
+	metrics.txRetries.WithLabelValues(name).Inc()
+}
+
+func (metrics *Metrics) TransferCompleted(kind string, currency string, amount int64) {
+	metrics.transfers.WithLabelValues(kind, currency).Inc()
+	metrics.transferredAmount.WithLabelValues(kind, currency).Add(float64(amount))
+}
+
+func (metrics *Metrics) LoginFailed() {
+	metrics.failedLogins.Inc()
+}
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector 每次抓取时读取pgxpool.Stat，db_name区分主库和从库
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	maxConns          *prometheus.Desc
+	totalConns        *prometheus.Desc
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquireCount *prometheus.Desc
+	canceledAcquires  *prometheus.Desc
+}
+
+func newPoolCollector(pool *pgxpool.Pool, dbName string) *poolCollector {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "db_pool", name),
+			help, nil, prometheus.Labels{"db_name": dbName},
+		)
+	}
+
+	return &poolCollector{
+		pool:              pool,
+		maxConns:          desc("max_conns", "Maximum number of connections in the pool."),
+		totalConns:        desc("total_conns", "Number of open connections, including ones being established."),
+		acquiredConns:     desc("acquired_conns", "Number of connections currently in use."),
+		idleConns:         desc("idle_conns", "Number of idle connections."),
+		acquireCount:      desc("acquire_total", "Connections acquired from the pool."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Time spent acquiring connections from the pool."),
+		emptyAcquireCount: desc("empty_acquire_total", "Acquires that had to wait because the pool was empty."),
+		canceledAcquires:  desc("canceled_acquire_total", "Acquires canceled by their context."),
+	}
+}
+
+func (collector *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- collector.maxConns
+	ch <- collector.totalConns
+	ch <- collector.acquiredConns
+	ch <- collector.idleConns
+	ch <- collector.acquireCount
+	ch <- collector.acquireDuration
+	ch <- collector.emptyAcquireCount
+	ch <- collector.canceledAcquires
+}
+
+func (collector *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := collector.pool.Stat()
+	ch <- prometheus.MustNewConstMetric(collector.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(collector.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(collector.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(collector.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(collector.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(collector.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(collector.emptyAcquireCount, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(collector.canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
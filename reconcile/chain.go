@@ -0,0 +1,63 @@
+package reconcile
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+)
+
+// 账户哈希链校验结果，OK为false时BrokenEntryID是第一条断链的账目
+type ChainReport struct {
+	AccountID      int64  `json:"account_id"`
+	EntriesChecked int64  `json:"entries_checked"`
+	OK             bool   `json:"ok"`
+	BrokenEntryID  int64  `json:"broken_entry_id,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+// VerifyEntryChain 按id顺序遍历账户的全部账目，重新计算哈希并检查每条账目是否指向上一条
+func (reconciler *Reconciler) VerifyEntryChain(ctx context.Context, accountID int64) (ChainReport, error) {
+	ctx = db.WithPrimary(ctx)
+	report := ChainReport{
+		AccountID: accountID,
+		OK:        true,
+	}
+
+	var afterID int64
+	prevHash := ""
+	for {
+		entries, err := reconciler.store.ListAccountEntriesAfter(ctx, db.ListAccountEntriesAfterParams{
+			AccountID: accountID,
+			AfterID:   afterID,
+			BatchSize: reconciler.batchSize,
+		})
+		if err != nil {
+			return report, err
+		}
+
+		for _, entry := range entries {
+			report.EntriesChecked++
+
+			if entry.PrevHash != prevHash {
+				report.OK = false
+				report.BrokenEntryID = entry.ID
+				report.Reason = "prev_hash does not match the hash of the previous entry"
+				return report, nil
+			}
+
+			expected := db.EntryHash(entry.PrevHash, entry.Amount, entry.AccountID, entry.CreatedAt, entry.ID)
+			if entry.Hash != expected {
+				report.OK = false
+				report.BrokenEntryID = entry.ID
+				report.Reason = "hash does not match the entry content"
+				return report, nil
+			}
+
+			prevHash = entry.Hash
+		}
+
+		if len(entries) < int(reconciler.batchSize) {
+			return report, nil
+		}
+		afterID = entries[len(entries)-1].ID
+	}
+}
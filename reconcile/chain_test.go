@@ -0,0 +1,94 @@
+package reconcile
+
+import (
+	"context"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// 构造一条合法的哈希链
+func randomChain(accountID int64, n int) []db.Entry {
+	entries := make([]db.Entry, n)
+	prevHash := ""
+	createdAt := time.Date(2026, 3, 31, 12, 0, 0, 123456000, time.UTC)
+	for i := 0; i < n; i++ {
+		entry := db.Entry{
+			ID:        int64(i + 1),
+			AccountID: accountID,
+			Amount:    int64((i + 1) * 10),
+			CreatedAt: createdAt.Add(time.Duration(i) * time.Minute),
+			PrevHash:  prevHash,
+		}
+		entry.Hash = db.EntryHash(entry.PrevHash, entry.Amount, entry.AccountID, entry.CreatedAt, entry.ID)
+		prevHash = entry.Hash
+		entries[i] = entry
+	}
+	return entries
+}
+
+func TestVerifyEntryChain(t *testing.T) {
+	accountID := int64(7)
+
+	testCases := []struct {
+		name        string
+		tamper      func(entries []db.Entry)
+		checkReport func(t *testing.T, report ChainReport)
+	}{
+		{
+			name:   "OK",
+			tamper: func(entries []db.Entry) {},
+			checkReport: func(t *testing.T, report ChainReport) {
+				require.True(t, report.OK)
+				require.Equal(t, int64(3), report.EntriesChecked)
+			},
+		},
+		{
+			name: "AmountEdited",
+			tamper: func(entries []db.Entry) {
+				entries[1].Amount = 1000
+			},
+			checkReport: func(t *testing.T, report ChainReport) {
+				require.False(t, report.OK)
+				require.Equal(t, int64(2), report.BrokenEntryID)
+			},
+		},
+		{
+			name: "EntryRemoved",
+			tamper: func(entries []db.Entry) {
+				// 第二条被删除后，第三条的prev_hash指向不存在的账目
+				entries[1] = entries[2]
+			},
+			checkReport: func(t *testing.T, report ChainReport) {
+				require.False(t, report.OK)
+				require.Equal(t, int64(3), report.BrokenEntryID)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			entries := randomChain(accountID, 3)
+			tc.tamper(entries)
+
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				ListAccountEntriesAfter(gomock.Any(), gomock.Eq(db.ListAccountEntriesAfterParams{AccountID: accountID, AfterID: 0, BatchSize: 10})).
+				Times(1).
+				Return(entries, nil)
+
+			report, err := NewReconciler(store, 10).VerifyEntryChain(context.Background(), accountID)
+			require.NoError(t, err)
+			tc.checkReport(t, report)
+		})
+	}
+}
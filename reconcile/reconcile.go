@@ -0,0 +1,150 @@
+package reconcile
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+	"time"
+)
+
+const DefaultBatchSize = 500
+
+// 账户余额与其所有账目之和不一致
+type BalanceMismatch struct {
+	AccountID    int64 `json:"account_id"`
+	Balance      int64 `json:"balance"`
+	EntriesTotal int64 `json:"entries_total"`
+	Difference   int64 `json:"difference"`
+}
+
+// 对账报告
+type Report struct {
+	AccountsChecked     int64                           `json:"accounts_checked"`
+	BalanceMismatches   []BalanceMismatch               `json:"balance_mismatches"`
+	UnbalancedTransfers []db.ListUnbalancedTransfersRow `json:"unbalanced_transfers"`
+	OrphanEntries       []db.Entry                      `json:"orphan_entries"`
+	StartedAt           time.Time                       `json:"started_at"`
+	FinishedAt          time.Time                       `json:"finished_at"`
+}
+
+// 没有发现任何差异
+func (report *Report) OK() bool {
+	return len(report.BalanceMismatches) == 0 &&
+		len(report.UnbalancedTransfers) == 0 &&
+		len(report.OrphanEntries) == 0
+}
+
+// Reconciler 分批扫描账户、转账和账目，检查账本是否平衡
+type Reconciler struct {
+	store     db.Store
+	batchSize int32
+}
+
+func NewReconciler(store db.Store, batchSize int32) *Reconciler {
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	return &Reconciler{
+		store:     store,
+		batchSize: batchSize,
+	}
+}
+
+func (reconciler *Reconciler) Run(ctx context.Context) (Report, error) {
+	// 对账要检查最新的数据，从库可能落后于主库
+	ctx = db.WithPrimary(ctx)
+	report := Report{
+		BalanceMismatches:   []BalanceMismatch{},
+		UnbalancedTransfers: []db.ListUnbalancedTransfersRow{},
+		OrphanEntries:       []db.Entry{},
+		StartedAt:           time.Now(),
+	}
+
+	err := reconciler.checkBalances(ctx, &report)
+	if err != nil {
+		return report, err
+	}
+
+	err = reconciler.checkTransfers(ctx, &report)
+	if err != nil {
+		return report, err
+	}
+
+	err = reconciler.checkEntries(ctx, &report)
+	if err != nil {
+		return report, err
+	}
+
+	report.FinishedAt = time.Now()
+	return report, nil
+}
+
+func (reconciler *Reconciler) checkBalances(ctx context.Context, report *Report) error {
+	var afterID int64
+	for {
+		rows, err := reconciler.store.ListAccountEntrySums(ctx, db.ListAccountEntrySumsParams{
+			AfterID:   afterID,
+			BatchSize: reconciler.batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, row := range rows {
+			report.AccountsChecked++
+			if row.Balance != row.EntriesTotal {
+				report.BalanceMismatches = append(report.BalanceMismatches, BalanceMismatch{
+					AccountID:    row.AccountID,
+					Balance:      row.Balance,
+					EntriesTotal: row.EntriesTotal,
+					Difference:   row.Balance - row.EntriesTotal,
+				})
+			}
+		}
+
+		if len(rows) < int(reconciler.batchSize) {
+			return nil
+		}
+		afterID = rows[len(rows)-1].AccountID
+	}
+}
+
+func (reconciler *Reconciler) checkTransfers(ctx context.Context, report *Report) error {
+	var afterID int64
+	for {
+		rows, err := reconciler.store.ListUnbalancedTransfers(ctx, db.ListUnbalancedTransfersParams{
+			AfterID:   afterID,
+			BatchSize: reconciler.batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		report.UnbalancedTransfers = append(report.UnbalancedTransfers, rows...)
+
+		if len(rows) < int(reconciler.batchSize) {
+			return nil
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+func (reconciler *Reconciler) checkEntries(ctx context.Context, report *Report) error {
+	var afterID int64
+	for {
+		entries, err := reconciler.store.ListOrphanEntries(ctx, db.ListOrphanEntriesParams{
+			AfterID:   afterID,
+			BatchSize: reconciler.batchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		report.OrphanEntries = append(report.OrphanEntries, entries...)
+
+		if len(entries) < int(reconciler.batchSize) {
+			return nil
+		}
+		afterID = entries[len(entries)-1].ID
+	}
+}
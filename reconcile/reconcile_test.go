@@ -0,0 +1,85 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReconcilerRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+
+	// 批大小为2，账户需要分两批扫描，对账读主库
+	gomock.InOrder(
+		store.EXPECT().
+			ListAccountEntrySums(mockdb.PrimaryContext(), gomock.Eq(db.ListAccountEntrySumsParams{AfterID: 0, BatchSize: 2})).
+			Return([]db.ListAccountEntrySumsRow{
+				{AccountID: 1, Balance: 100, EntriesTotal: 100},
+				{AccountID: 2, Balance: 50, EntriesTotal: 40},
+			}, nil),
+		store.EXPECT().
+			ListAccountEntrySums(mockdb.PrimaryContext(), gomock.Eq(db.ListAccountEntrySumsParams{AfterID: 2, BatchSize: 2})).
+			Return([]db.ListAccountEntrySumsRow{
+				{AccountID: 3, Balance: -150, EntriesTotal: -150},
+			}, nil),
+	)
+
+	store.EXPECT().
+		ListUnbalancedTransfers(gomock.Any(), gomock.Eq(db.ListUnbalancedTransfersParams{AfterID: 0, BatchSize: 2})).
+		Times(1).
+		Return([]db.ListUnbalancedTransfersRow{{ID: 7, FromAccountID: 1, ToAccountID: 2, Amount: 10, EntryCount: 1}}, nil)
+
+	store.EXPECT().
+		ListOrphanEntries(gomock.Any(), gomock.Eq(db.ListOrphanEntriesParams{AfterID: 0, BatchSize: 2})).
+		Times(1).
+		Return([]db.Entry{}, nil)
+
+	report, err := NewReconciler(store, 2).Run(context.Background())
+	require.NoError(t, err)
+	require.False(t, report.OK())
+	require.Equal(t, int64(3), report.AccountsChecked)
+	require.Equal(t, []BalanceMismatch{{AccountID: 2, Balance: 50, EntriesTotal: 40, Difference: 10}}, report.BalanceMismatches)
+	require.Len(t, report.UnbalancedTransfers, 1)
+	require.Empty(t, report.OrphanEntries)
+}
+
+func TestReconcilerRunOK(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountEntrySums(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.ListAccountEntrySumsRow{{AccountID: 1, Balance: 100, EntriesTotal: 100}}, nil)
+	store.EXPECT().ListUnbalancedTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]db.ListUnbalancedTransfersRow{}, nil)
+	store.EXPECT().ListOrphanEntries(gomock.Any(), gomock.Any()).Times(1).Return([]db.Entry{}, nil)
+
+	report, err := NewReconciler(store, 0).Run(context.Background())
+	require.NoError(t, err)
+	require.True(t, report.OK())
+	require.False(t, report.FinishedAt.IsZero())
+}
+
+func TestReconcilerRunError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountEntrySums(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+	store.EXPECT().ListUnbalancedTransfers(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := NewReconciler(store, 10).Run(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
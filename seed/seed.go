@@ -0,0 +1,207 @@
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"strings"
+)
+
+// 演示用户统一使用的密码，方便前端和测试人员登录
+const DefaultPassword = "secret123"
+
+var (
+	firstNames = []string{"Alice", "Bob", "Carol", "David", "Emma", "Frank", "Grace", "Henry", "Iris", "Jack", "Kate", "Liam", "Mia", "Noah", "Olivia", "Peter"}
+	lastNames  = []string{"Smith", "Johnson", "Brown", "Taylor", "Wilson", "Martin", "Lee", "Walker", "Hall", "Young", "King", "Wright"}
+)
+
+type Options struct {
+	Users     int
+	Transfers int
+	// 相同的种子在空数据库上生成完全相同的数据
+	Seed     int64
+	Password string
+}
+
+// Summary 生成结果，Usernames可以直接用于登录
+type Summary struct {
+	Seed      int64    `json:"seed"`
+	Usernames []string `json:"usernames"`
+	Password  string   `json:"password"`
+	Accounts  int      `json:"accounts"`
+	Deposits  int      `json:"deposits"`
+	Transfers int      `json:"transfers"`
+}
+
+// Seeder 通过db.Store生成演示数据，所有资金都经过存款和转账事务入账，账本始终平衡
+type Seeder struct {
+	store   db.Store
+	options Options
+	rand    *rand.Rand
+}
+
+func NewSeeder(store db.Store, options Options) *Seeder {
+	if options.Password == "" {
+		options.Password = DefaultPassword
+	}
+
+	return &Seeder{
+		store:   store,
+		options: options,
+		rand:    rand.New(rand.NewSource(options.Seed)),
+	}
+}
+
+func (seeder *Seeder) Run(ctx context.Context) (Summary, error) {
+	summary := Summary{
+		Seed:      seeder.options.Seed,
+		Usernames: []string{},
+		Password:  seeder.options.Password,
+	}
+
+	// bcrypt很慢，所有用户共用一个哈希
+	hashedPassword, err := util.HashedPassword(seeder.options.Password)
+	if err != nil {
+		return summary, fmt.Errorf("cannot hash password: %w", err)
+	}
+
+	// 按币种分组，只在相同币种的账户之间转账
+	accounts := make(map[string][]db.Account)
+	currencies := util.SupportedCurrencies()
+
+	for i := 0; i < seeder.options.Users; i++ {
+		user, err := seeder.createUser(ctx, i, hashedPassword)
+		if err != nil {
+			return summary, err
+		}
+		summary.Usernames = append(summary.Usernames, user.Username)
+
+		for _, currency := range currencies {
+			account, err := seeder.createAccount(ctx, user, currency)
+			if err != nil {
+				return summary, err
+			}
+			summary.Accounts++
+
+			if account.Balance > 0 {
+				summary.Deposits++
+			}
+			accounts[currency] = append(accounts[currency], account)
+		}
+	}
+
+	for i := 0; i < seeder.options.Transfers; i++ {
+		currency := currencies[seeder.rand.Intn(len(currencies))]
+		ok, err := seeder.transfer(ctx, accounts[currency])
+		if err != nil {
+			return summary, err
+		}
+		if ok {
+			summary.Transfers++
+		}
+	}
+
+	return summary, nil
+}
+
+func (seeder *Seeder) createUser(ctx context.Context, index int, hashedPassword string) (db.User, error) {
+	firstName := firstNames[seeder.rand.Intn(len(firstNames))]
+	lastName := lastNames[seeder.rand.Intn(len(lastNames))]
+	// 序号保证同一批用户名不重复
+	username := fmt.Sprintf("%s_%s%d", strings.ToLower(firstName), strings.ToLower(lastName), index+1)
+
+	user, err := seeder.store.CreateUser(ctx, db.CreateUserParams{
+		Username:       username,
+		HashedPassword: hashedPassword,
+		FullName:       firstName + " " + lastName,
+		Email:          username + "@example.com",
+	})
+	if err != nil {
+		if db.ErrorCode(err) == db.UniqueViolation {
+			return user, fmt.Errorf("user %s already exists, use another seed or an empty database: %w", username, err)
+		}
+		return user, fmt.Errorf("cannot create user %s: %w", username, err)
+	}
+	return user, nil
+}
+
+// 创建账户并存入初始资金，少数账户保持0余额
+func (seeder *Seeder) createAccount(ctx context.Context, user db.User, currency string) (db.Account, error) {
+	accountType := util.Checking
+	if seeder.rand.Intn(10) < 3 {
+		accountType = util.Savings
+	}
+
+	account, err := seeder.store.CreateAccount(ctx, db.CreateAccountParams{
+		Owner:    user.Username,
+		Balance:  0,
+		Currency: currency,
+		Type:     accountType,
+	})
+	if err != nil {
+		return account, fmt.Errorf("cannot create %s account for %s: %w", currency, user.Username, err)
+	}
+
+	if seeder.rand.Intn(5) == 0 {
+		return account, nil
+	}
+
+	// 10.00 ~ 5000.00
+	amount := 1_000 + seeder.rand.Int63n(499_001)
+	result, err := seeder.store.DepositTx(ctx, db.CashTxParams{
+		AccountID: account.ID,
+		Amount:    amount,
+	})
+	if err != nil {
+		return account, fmt.Errorf("cannot deposit into account %d: %w", account.ID, err)
+	}
+	return result.ToAccount, nil
+}
+
+// 随机选择一个有余额的账户转出，收款方集中在少数账户上（类似商户），金额不超过转出方余额
+// 没有可用的转出方时返回false
+func (seeder *Seeder) transfer(ctx context.Context, accounts []db.Account) (bool, error) {
+	if len(accounts) < 2 {
+		return false, nil
+	}
+
+	funded := []int{}
+	for i, account := range accounts {
+		if account.Balance > 0 {
+			funded = append(funded, i)
+		}
+	}
+	if len(funded) == 0 {
+		return false, nil
+	}
+
+	from := funded[seeder.rand.Intn(len(funded))]
+
+	// Zipf分布：序号越小的账户被选为收款方的概率越大
+	zipf := rand.NewZipf(seeder.rand, 1.2, 1, uint64(len(accounts)-2))
+	to := int(zipf.Uint64())
+	if to >= from {
+		to++
+	}
+
+	// 每次最多转出余额的四分之一
+	amount := 1 + seeder.rand.Int63n(accounts[from].Balance/4+1)
+	if amount > accounts[from].Balance {
+		amount = accounts[from].Balance
+	}
+
+	result, err := seeder.store.TransferTx(ctx, db.TransferTxParams{
+		FromAccountID: accounts[from].ID,
+		ToAccountID:   accounts[to].ID,
+		Amount:        amount,
+	})
+	if err != nil {
+		return false, fmt.Errorf("cannot transfer from account %d to %d: %w", accounts[from].ID, accounts[to].ID, err)
+	}
+
+	accounts[from] = result.FromAccount
+	accounts[to] = result.ToAccount
+	return true, nil
+}
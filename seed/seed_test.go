@@ -0,0 +1,146 @@
+package seed
+
+import (
+	"context"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+// ledger 用内存模拟存储，记录生成过程中的所有转账
+type ledger struct {
+	users     []db.CreateUserParams
+	accounts  map[int64]*db.Account
+	transfers []db.TransferTxParams
+	deposited int64
+}
+
+func newMockLedger(t *testing.T) (*ledger, *mockdb.MockStore) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	l := &ledger{accounts: make(map[int64]*db.Account)}
+	store := mockdb.NewMockStore(ctrl)
+
+	store.EXPECT().CreateUser(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, arg db.CreateUserParams) (db.User, error) {
+			l.users = append(l.users, arg)
+			return db.User{Username: arg.Username, FullName: arg.FullName, Email: arg.Email}, nil
+		})
+	store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+			account := db.Account{ID: int64(len(l.accounts) + 1), Owner: arg.Owner, Currency: arg.Currency, Type: arg.Type}
+			l.accounts[account.ID] = &account
+			return account, nil
+		})
+	store.EXPECT().DepositTx(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, arg db.CashTxParams) (db.TransferTxResult, error) {
+			require.Positive(t, arg.Amount)
+			account := l.accounts[arg.AccountID]
+			account.Balance += arg.Amount
+			l.deposited += arg.Amount
+			return db.TransferTxResult{ToAccount: *account}, nil
+		})
+	store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).AnyTimes().
+		DoAndReturn(func(ctx context.Context, arg db.TransferTxParams) (db.TransferTxResult, error) {
+			from := l.accounts[arg.FromAccountID]
+			to := l.accounts[arg.ToAccountID]
+
+			require.NotEqual(t, from.ID, to.ID)
+			require.Equal(t, from.Currency, to.Currency)
+			require.Positive(t, arg.Amount)
+			require.LessOrEqual(t, arg.Amount, from.Balance)
+
+			from.Balance -= arg.Amount
+			to.Balance += arg.Amount
+			l.transfers = append(l.transfers, arg)
+			return db.TransferTxResult{FromAccount: *from, ToAccount: *to}, nil
+		})
+
+	return l, store
+}
+
+func (l *ledger) total() int64 {
+	var total int64
+	for _, account := range l.accounts {
+		total += account.Balance
+	}
+	return total
+}
+
+func TestSeeder(t *testing.T) {
+	l, store := newMockLedger(t)
+
+	options := Options{Users: 10, Transfers: 100, Seed: 42}
+	summary, err := NewSeeder(store, options).Run(context.Background())
+	require.NoError(t, err)
+
+	currencies := util.SupportedCurrencies()
+	require.Len(t, summary.Usernames, options.Users)
+	require.Equal(t, options.Users*len(currencies), summary.Accounts)
+	require.Equal(t, DefaultPassword, summary.Password)
+	require.Len(t, l.transfers, summary.Transfers)
+	require.Positive(t, summary.Transfers)
+
+	// 用户名不重复，所有用户共用同一个密码哈希
+	seen := make(map[string]bool)
+	for _, user := range l.users {
+		require.False(t, seen[user.Username])
+		seen[user.Username] = true
+		require.Equal(t, l.users[0].HashedPassword, user.HashedPassword)
+		require.True(t, util.CheckPassword(DefaultPassword, user.HashedPassword))
+	}
+
+	// 转账不改变资金总量，也不会出现负余额
+	for _, account := range l.accounts {
+		require.GreaterOrEqual(t, account.Balance, int64(0))
+	}
+	require.Equal(t, l.deposited, l.total())
+}
+
+func TestSeederDeterministic(t *testing.T) {
+	run := func(seed int64) *ledger {
+		l, store := newMockLedger(t)
+		_, err := NewSeeder(store, Options{Users: 5, Transfers: 50, Seed: seed}).Run(context.Background())
+		require.NoError(t, err)
+		return l
+	}
+
+	first := run(7)
+	second := run(7)
+	other := run(8)
+
+	require.Equal(t, first.transfers, second.transfers)
+	require.Equal(t, len(first.users), len(second.users))
+	for i := range first.users {
+		require.Equal(t, first.users[i].Username, second.users[i].Username)
+	}
+	for id, account := range first.accounts {
+		require.Equal(t, *account, *second.accounts[id])
+	}
+
+	require.NotEqual(t, first.transfers, other.transfers)
+}
+
+func TestSeederSingleUser(t *testing.T) {
+	l, store := newMockLedger(t)
+
+	// 只有一个用户时每个币种只有一个账户，无法转账
+	summary, err := NewSeeder(store, Options{Users: 1, Transfers: 10, Seed: 1}).Run(context.Background())
+	require.NoError(t, err)
+	require.Zero(t, summary.Transfers)
+	require.Empty(t, l.transfers)
+}
+
+func TestSeederTwoUsers(t *testing.T) {
+	l, store := newMockLedger(t)
+
+	summary, err := NewSeeder(store, Options{Users: 2, Transfers: 20, Seed: 3}).Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, l.transfers, summary.Transfers)
+	require.Equal(t, l.deposited, l.total())
+}
@@ -0,0 +1,108 @@
+package statement
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"simplebank/util"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+var csvHeader = []string{
+	"entry_id",
+	"date",
+	"description",
+	"counterparty_account_id",
+	"counterparty_owner",
+	"amount",
+	"running_balance",
+}
+
+// WriteCSV 输出CSV对账单，首尾两行分别是期初余额和期末余额
+func (statement *Statement) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	records := [][]string{csvHeader}
+	records = append(records, []string{"", statement.PeriodStart.Format(time.RFC3339), "opening balance", "", "", "", statement.formatAmount(statement.OpeningBalance)})
+	for _, line := range statement.Lines {
+		records = append(records, []string{
+			formatInt(line.EntryID),
+			line.Date.UTC().Format(time.RFC3339),
+			line.Description,
+			formatOptionalInt(line.CounterpartyAccountID),
+			line.CounterpartyOwner,
+			statement.formatAmount(line.Amount),
+			statement.formatAmount(line.RunningBalance),
+		})
+	}
+	records = append(records, []string{"", statement.PeriodEnd.Format(time.RFC3339), "closing balance", "", "", "", statement.formatAmount(statement.ClosingBalance)})
+
+	err := writer.WriteAll(records)
+	if err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// WritePDF 输出A4纸的PDF对账单
+func (statement *Statement) WritePDF(w io.Writer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(fmt.Sprintf("Statement %d %s", statement.AccountID, statement.Month), false)
+	pdf.AddPage()
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.Cell(0, 10, fmt.Sprintf("Account statement - %s", statement.Month))
+	pdf.Ln(12)
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.Cell(0, 6, fmt.Sprintf("Account: %d    Owner: %s    Currency: %s", statement.AccountID, statement.Owner, statement.Currency))
+	pdf.Ln(6)
+	pdf.Cell(0, 6, fmt.Sprintf("Opening balance: %s    Closing balance: %s", statement.formatAmount(statement.OpeningBalance), statement.formatAmount(statement.ClosingBalance)))
+	pdf.Ln(10)
+
+	widths := []float64{18, 40, 25, 45, 30, 32}
+	headers := []string{"Entry", "Date (UTC)", "Description", "Counterparty", "Amount", "Balance"}
+
+	pdf.SetFont("Helvetica", "B", 9)
+	for i, header := range headers {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range statement.Lines {
+		counterparty := line.CounterpartyOwner
+		if line.CounterpartyAccountID != 0 {
+			counterparty = fmt.Sprintf("%s (#%d)", line.CounterpartyOwner, line.CounterpartyAccountID)
+		}
+
+		pdf.CellFormat(widths[0], 6, formatInt(line.EntryID), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[1], 6, line.Date.UTC().Format("2006-01-02 15:04:05"), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[2], 6, line.Description, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, counterparty, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[4], 6, statement.formatAmount(line.Amount), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(widths[5], 6, statement.formatAmount(line.RunningBalance), "1", 0, "R", false, 0, "")
+		pdf.Ln(-1)
+	}
+
+	return pdf.Output(w)
+}
+
+// 金额按币种的小数位数输出，例如USD的250输出为2.50
+func (statement *Statement) formatAmount(amount int64) string {
+	return util.FormatAmount(amount, statement.Currency)
+}
+
+func formatInt(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
+
+func formatOptionalInt(n int64) string {
+	if n == 0 {
+		return ""
+	}
+	return formatInt(n)
+}
@@ -0,0 +1,81 @@
+package statement
+
+import (
+	"errors"
+	db "simplebank/db/sqlc"
+	"time"
+)
+
+const MonthLayout = "2006-01"
+
+var ErrInvalidMonth = errors.New("month must be in YYYY-MM format")
+
+// 对账单中的一行，对应一条账目
+type Line struct {
+	EntryID               int64     `json:"entry_id"`
+	Date                  time.Time `json:"date"`
+	Description           string    `json:"description"`
+	Amount                int64     `json:"amount"`
+	RunningBalance        int64     `json:"running_balance"`
+	TransferID            int64     `json:"transfer_id,omitempty"`
+	CounterpartyAccountID int64     `json:"counterparty_account_id,omitempty"`
+	CounterpartyOwner     string    `json:"counterparty_owner,omitempty"`
+}
+
+// 月度对账单
+type Statement struct {
+	AccountID      int64     `json:"account_id"`
+	Owner          string    `json:"owner"`
+	Currency       string    `json:"currency"`
+	AccountType    string    `json:"account_type"`
+	Month          string    `json:"month"`
+	PeriodStart    time.Time `json:"period_start"`
+	PeriodEnd      time.Time `json:"period_end"`
+	OpeningBalance int64     `json:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance"`
+	Lines          []Line    `json:"lines"`
+}
+
+// ParseMonth 解析YYYY-MM，返回该月的起止时间（UTC，左闭右开）
+func ParseMonth(month string) (start time.Time, end time.Time, err error) {
+	start, err = time.Parse(MonthLayout, month)
+	if err != nil {
+		return start, end, ErrInvalidMonth
+	}
+
+	end = start.AddDate(0, 1, 0)
+	return start, end, nil
+}
+
+// Build 从期初余额开始逐条累加账目，得到每一行的余额和期末余额
+func Build(account db.Account, start time.Time, end time.Time, openingBalance int64, entries []db.ListStatementEntriesRow) Statement {
+	statement := Statement{
+		AccountID:      account.ID,
+		Owner:          account.Owner,
+		Currency:       account.Currency,
+		AccountType:    account.Type,
+		Month:          start.Format(MonthLayout),
+		PeriodStart:    start,
+		PeriodEnd:      end,
+		OpeningBalance: openingBalance,
+		Lines:          make([]Line, 0, len(entries)),
+	}
+
+	balance := openingBalance
+	for _, entry := range entries {
+		balance += entry.Amount
+		statement.Lines = append(statement.Lines, Line{
+			EntryID:               entry.ID,
+			Date:                  entry.CreatedAt,
+			Description:           entry.Description,
+			Amount:                entry.Amount,
+			RunningBalance:        balance,
+			TransferID:            entry.TransferID.Int64,
+			CounterpartyAccountID: entry.CounterpartyAccountID.Int64,
+			CounterpartyOwner:     entry.CounterpartyOwner.String,
+		})
+	}
+	statement.ClosingBalance = balance
+
+	return statement
+}
@@ -0,0 +1,88 @@
+package statement
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	db "simplebank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMonth(t *testing.T) {
+	start, end, err := ParseMonth("2026-02")
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), start)
+	require.Equal(t, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), end)
+
+	_, _, err = ParseMonth("2026-2")
+	require.ErrorIs(t, err, ErrInvalidMonth)
+}
+
+func testStatement() Statement {
+	account := db.Account{ID: 1, Owner: "bob", Currency: "USD"}
+	start, end, _ := ParseMonth("2026-03")
+	entries := []db.ListStatementEntriesRow{
+		{
+			ID:                    10,
+			AccountID:             1,
+			Amount:                25,
+			CreatedAt:             start.Add(time.Hour),
+			Description:           "transfer",
+			TransferID:            sql.NullInt64{Int64: 3, Valid: true},
+			CounterpartyAccountID: sql.NullInt64{Int64: 2, Valid: true},
+			CounterpartyOwner:     sql.NullString{String: "alice", Valid: true},
+		},
+		{
+			ID:          11,
+			AccountID:   1,
+			Amount:      -40,
+			CreatedAt:   start.Add(2 * time.Hour),
+			Description: "withdraw",
+		},
+	}
+	return Build(account, start, end, 100, entries)
+}
+
+func TestBuild(t *testing.T) {
+	statement := testStatement()
+
+	require.Equal(t, "2026-03", statement.Month)
+	require.Equal(t, int64(100), statement.OpeningBalance)
+	require.Equal(t, int64(85), statement.ClosingBalance)
+	require.Len(t, statement.Lines, 2)
+	require.Equal(t, int64(125), statement.Lines[0].RunningBalance)
+	require.Equal(t, int64(2), statement.Lines[0].CounterpartyAccountID)
+	require.Equal(t, int64(85), statement.Lines[1].RunningBalance)
+	require.Zero(t, statement.Lines[1].TransferID)
+}
+
+func TestWriteCSV(t *testing.T) {
+	statement := testStatement()
+
+	var buf bytes.Buffer
+	err := statement.WriteCSV(&buf)
+	require.NoError(t, err)
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	// 表头 + 期初 + 2行明细 + 期末
+	require.Len(t, records, 5)
+	require.Equal(t, csvHeader, records[0])
+	// 金额按币种小数位输出，USD的100输出为1.00
+	require.Equal(t, "1.00", records[1][6])
+	require.Equal(t, []string{"10", "2026-03-01T01:00:00Z", "transfer", "2", "alice", "0.25", "1.25"}, records[2])
+	require.Equal(t, []string{"11", "2026-03-01T02:00:00Z", "withdraw", "", "", "-0.40", "0.85"}, records[3])
+	require.Equal(t, "0.85", records[4][6])
+}
+
+func TestWritePDF(t *testing.T) {
+	statement := testStatement()
+
+	var buf bytes.Buffer
+	err := statement.WritePDF(&buf)
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+}
@@ -1,7 +1,9 @@
 package token
 
 import (
+	"crypto/rand"
 	"fmt"
+	"math/big"
 	"time"
 
 	"github.com/aead/chacha20poly1305"
@@ -15,9 +17,12 @@ var (
 type PasetoMaker struct {
 	paseto       *paseto.V2
 	symmetricKey []byte
+	// 轮换前使用的密钥，只用于校验尚未过期的旧token
+	previousKeys [][]byte
 }
 
-func NewPasetoMaker(symmetricKey string) (Maker, error) {
+// NewPasetoMaker 使用symmetricKey签发token，previousKeys为轮换前的密钥
+func NewPasetoMaker(symmetricKey string, previousKeys ...string) (Maker, error) {
 	if len(symmetricKey) != chacha20poly1305.KeySize {
 		return nil, ErrShortSymmetricKey
 	}
@@ -27,9 +32,32 @@ func NewPasetoMaker(symmetricKey string) (Maker, error) {
 		symmetricKey: []byte(symmetricKey),
 	}
 
+	for _, key := range previousKeys {
+		if len(key) != chacha20poly1305.KeySize {
+			return nil, ErrShortSymmetricKey
+		}
+		maker.previousKeys = append(maker.previousKeys, []byte(key))
+	}
+
 	return maker, nil
 }
 
+const symmetricKeyAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// NewSymmetricKey 用安全的随机数生成一个新的密钥，用于密钥轮换
+func NewSymmetricKey() (string, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	max := big.NewInt(int64(len(symmetricKeyAlphabet)))
+	for i := range key {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		key[i] = symmetricKeyAlphabet[n.Int64()]
+	}
+	return string(key), nil
+}
+
 func (pasetoMaker *PasetoMaker) CreateToken(username string, role string, duration time.Duration) (string, *Payload, error) {
 	payload, err := NewPayload(username, role, duration)
 	if err != nil {
@@ -44,6 +72,12 @@ func (pasetoMaker *PasetoMaker) VerifyToken(token string) (*Payload, error) {
 	payload := &Payload{}
 
 	err := pasetoMaker.paseto.Decrypt(token, pasetoMaker.symmetricKey, payload, nil)
+	for _, key := range pasetoMaker.previousKeys {
+		if err == nil {
+			break
+		}
+		err = pasetoMaker.paseto.Decrypt(token, key, payload, nil)
+	}
 	if err != nil {
 		return nil, ErrInvalidToken
 	}
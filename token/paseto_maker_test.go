@@ -61,3 +61,42 @@ func TestExpiredPasetoToken(t *testing.T) {
 	require.EqualError(t, err, ErrExpiredToken.Error())
 	require.Empty(t, payload)
 }
+
+func TestPasetoMakerKeyRotation(t *testing.T) {
+	oldKey := util.RandomString(32)
+	oldMaker, err := NewPasetoMaker(oldKey)
+	require.NoError(t, err)
+
+	oldToken, _, err := oldMaker.CreateToken(util.RandomOwner(), DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	newKey, err := NewSymmetricKey()
+	require.NoError(t, err)
+	require.Len(t, newKey, 32)
+	require.NotEqual(t, oldKey, newKey)
+
+	// 轮换后旧token在过期前仍然有效
+	maker, err := NewPasetoMaker(newKey, oldKey)
+	require.NoError(t, err)
+
+	payload, err := maker.VerifyToken(oldToken)
+	require.NoError(t, err)
+	require.NotEmpty(t, payload)
+
+	// 新token只能用新密钥校验
+	newToken, _, err := maker.CreateToken(util.RandomOwner(), DepositorRole, time.Minute)
+	require.NoError(t, err)
+
+	_, err = oldMaker.VerifyToken(newToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	// 移除旧密钥后旧token失效
+	maker, err = NewPasetoMaker(newKey)
+	require.NoError(t, err)
+
+	_, err = maker.VerifyToken(oldToken)
+	require.EqualError(t, err, ErrInvalidToken.Error())
+
+	_, err = NewPasetoMaker(newKey, util.RandomString(30))
+	require.EqualError(t, err, ErrShortSymmetricKey.Error())
+}
@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+const ServiceName = "simplebank"
+
+// 支持的导出方式
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Setup 按配置创建全局的TracerProvider，返回的shutdown函数在退出前调用，确保缓冲的span被导出
+// exporter为none时不导出，但仍然生成trace ID，便于日志关联
+func Setup(ctx context.Context, exporter string, otlpEndpoint string) (func(context.Context) error, error) {
+	return setup(ctx, exporter, otlpEndpoint, os.Stdout)
+}
+
+func setup(ctx context.Context, exporter string, otlpEndpoint string, stdout io.Writer) (func(context.Context) error, error) {
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(ServiceName))),
+	}
+
+	switch exporter {
+	case "", ExporterNone:
+	case ExporterStdout:
+		spanExporter, err := stdouttrace.New(stdouttrace.WithWriter(stdout))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create stdout exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(spanExporter))
+	case ExporterOTLP:
+		spanExporter, err := otlptracegrpc.New(ctx,
+			otlptracegrpc.WithEndpoint(otlpEndpoint),
+			otlptracegrpc.WithInsecure(),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create OTLP exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(spanExporter))
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter: %s", exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+)
+
+func TestSetupStdout(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	var buf bytes.Buffer
+	shutdown, err := setup(context.Background(), ExporterStdout, "", &buf)
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	span.End()
+
+	// shutdown时导出缓冲的span
+	require.NoError(t, shutdown(context.Background()))
+	require.Contains(t, buf.String(), `"Name":"test-span"`)
+	require.Contains(t, buf.String(), ServiceName)
+}
+
+func TestSetupNone(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	defer otel.SetTracerProvider(previous)
+
+	shutdown, err := Setup(context.Background(), ExporterNone, "")
+	require.NoError(t, err)
+
+	// 不导出也会生成trace ID
+	_, span := otel.Tracer("test").Start(context.Background(), "test-span")
+	require.True(t, span.SpanContext().HasTraceID())
+	span.End()
+
+	require.NoError(t, shutdown(context.Background()))
+}
+
+func TestSetupUnsupported(t *testing.T) {
+	_, err := Setup(context.Background(), "zipkin", "")
+	require.EqualError(t, err, "unsupported tracing exporter: zipkin")
+}
@@ -0,0 +1,14 @@
+package util
+
+const (
+	Checking = "checking"
+	Savings  = "savings"
+)
+
+func IsSupportedAccountType(accountType string) bool {
+	switch accountType {
+	case Checking, Savings:
+		return true
+	}
+	return false
+}
@@ -7,11 +7,55 @@ import (
 )
 
 type Config struct {
-	DBDriver            string        `mapstructure:"DB_DRIVER"`
-	DBSource            string        `mapstructure:"DB_SOURCE"`
-	ServerAddress       string        `mapstructure:"SERVER_ADDRESS"`
+	DBDriver          string `mapstructure:"DB_DRIVER"`
+	DBSource          string `mapstructure:"DB_SOURCE"`
+	ServerAddress     string `mapstructure:"SERVER_ADDRESS"`
+	GRPCServerAddress string `mapstructure:"GRPC_SERVER_ADDRESS"`
+	// Prometheus指标的监听地址，与业务接口分开，不应对公网开放；为空时不导出指标
+	MetricsAddress      string        `mapstructure:"METRICS_ADDRESS"`
 	TokenSymmetricKey   string        `mapstructure:"TOKEN_SYMMETRIC_KEY"`
 	AccessTokenDuration time.Duration `mapstructure:"ACCESS_TOKEN_DURATION"`
+	HoldSweepInterval   time.Duration `mapstructure:"HOLD_SWEEP_INTERVAL"`
+	// 年利率，单位为基点（1bp = 0.01%）
+	CheckingInterestRateBps int64 `mapstructure:"CHECKING_INTEREST_RATE_BPS"`
+	SavingsInterestRateBps  int64 `mapstructure:"SAVINGS_INTEREST_RATE_BPS"`
+	// debug、info、warn、error
+	LogLevel string `mapstructure:"LOG_LEVEL"`
+	// none、stdout、otlp
+	TracingExporter string `mapstructure:"TRACING_EXPORTER"`
+	OTLPEndpoint    string `mapstructure:"OTLP_ENDPOINT"`
+	// 为空时使用编译进二进制的迁移文件，否则为golang-migrate的source URL，例如file://db/migration
+	MigrationURL string `mapstructure:"MIGRATION_URL"`
+	// 启动HTTP/gRPC服务前自动执行待执行的迁移
+	AutoMigrate bool `mapstructure:"AUTO_MIGRATE"`
+	// 收到退出信号后等待处理中的请求和后台任务的最长时间
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	// 轮换前的token密钥，逗号分隔，只用于校验尚未过期的旧token，超过ACCESS_TOKEN_DURATION后可以移除
+	TokenPreviousSymmetricKeys []string `mapstructure:"TOKEN_PREVIOUS_SYMMETRIC_KEYS"`
+	// 事务遇到序列化冲突或死锁时的最多重试次数和退避时间
+	TxMaxRetries     int           `mapstructure:"TX_MAX_RETRIES"`
+	TxRetryBaseDelay time.Duration `mapstructure:"TX_RETRY_BASE_DELAY"`
+	TxRetryMaxDelay  time.Duration `mapstructure:"TX_RETRY_MAX_DELAY"`
+	// 各事务的隔离级别，逗号分隔，例如 TransferTx=serializable,WithdrawTx=repeatable_read
+	TxIsolation []string `mapstructure:"TX_ISOLATION"`
+	// pgxpool连接池配置，DB_MIN_CONNS为连接池保持的最少连接数，0表示使用pgxpool的默认值
+	DBMaxConns         int32         `mapstructure:"DB_MAX_CONNS"`
+	DBMinConns         int32         `mapstructure:"DB_MIN_CONNS"`
+	DBMaxConnLifetime  time.Duration `mapstructure:"DB_MAX_CONN_LIFETIME"`
+	DBMaxConnIdleTime  time.Duration `mapstructure:"DB_MAX_CONN_IDLE_TIME"`
+	DBStatementTimeout time.Duration `mapstructure:"DB_STATEMENT_TIMEOUT"`
+	// 只读从库的连接串，为空时所有查询都走主库
+	DBReplicaSource string `mapstructure:"DB_REPLICA_SOURCE"`
+	// 用户写操作成功后的这段时间内，该用户的读请求走主库，应大于从库的复制延迟
+	ReadYourWritesWindow time.Duration `mapstructure:"READ_YOUR_WRITES_WINDOW"`
+}
+
+// InterestRates 各账户类型的年利率（基点）
+func (config Config) InterestRates() map[string]int64 {
+	return map[string]int64{
+		Checking: config.CheckingInterestRateBps,
+		Savings:  config.SavingsInterestRateBps,
+	}
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -1,11 +1,21 @@
 package util
 
+import (
+	"fmt"
+	"strconv"
+)
+
 const (
 	USD = "USD"
 	EUR = "EUR"
 	CAD = "CAD"
 )
 
+// SupportedCurrencies 返回所有支持的币种
+func SupportedCurrencies() []string {
+	return []string{USD, EUR, CAD}
+}
+
 func IsSupportedCurrency(currency string) bool {
 	switch currency {
 	case USD, EUR, CAD:
@@ -13,3 +23,36 @@ func IsSupportedCurrency(currency string) bool {
 	}
 	return false
 }
+
+// 各币种的小数位数（ISO 4217 minor unit），新增币种时同步添加
+var currencyExponents = map[string]int{
+	USD: 2,
+	EUR: 2,
+	CAD: 2,
+}
+
+// CurrencyExponent 返回币种的小数位数，金额以最小货币单位存储，例如USD的1.00存储为100
+func CurrencyExponent(currency string) int {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return 2
+	}
+	return exponent
+}
+
+// FormatAmount 把以最小货币单位存储的金额格式化为小数，例如USD的250格式化为2.50
+func FormatAmount(amount int64, currency string) string {
+	exponent := CurrencyExponent(currency)
+	if exponent == 0 {
+		return strconv.FormatInt(amount, 10)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := fmt.Sprintf("%0*d", exponent+1, amount)
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
@@ -0,0 +1,15 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAmount(t *testing.T) {
+	require.Equal(t, "2.50", FormatAmount(250, "USD"))
+	require.Equal(t, "0.05", FormatAmount(5, "EUR"))
+	require.Equal(t, "0.00", FormatAmount(0, "CAD"))
+	require.Equal(t, "-0.30", FormatAmount(-30, "USD"))
+	require.Equal(t, "-1234.56", FormatAmount(-123456, "USD"))
+}
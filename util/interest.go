@@ -0,0 +1,33 @@
+package util
+
+import "math/big"
+
+// 一年按365天计息
+const DaysPerYear = 365
+
+// DailyInterest 计算一天的利息（最小货币单位），年利率以基点表示（1bp = 0.01%），
+// 结果按银行家舍入法（四舍六入五取偶）取整
+func DailyInterest(balance int64, annualRateBps int64) int64 {
+	numerator := new(big.Int).Mul(big.NewInt(balance), big.NewInt(annualRateBps))
+	denominator := big.NewInt(10000 * DaysPerYear)
+	return RoundHalfEven(numerator, denominator)
+}
+
+// RoundHalfEven 计算numerator/denominator并按银行家舍入法取整，denominator必须为正数
+func RoundHalfEven(numerator *big.Int, denominator *big.Int) int64 {
+	quotient, remainder := new(big.Int).QuoRem(numerator, denominator, new(big.Int))
+
+	// 比较2*|余数|与除数的大小，决定是否进位
+	twice := new(big.Int).Abs(remainder)
+	twice.Lsh(twice, 1)
+	cmp := twice.Cmp(denominator)
+	if cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1) {
+		if remainder.Sign() < 0 {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	return quotient.Int64()
+}
@@ -0,0 +1,42 @@
+package util
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundHalfEven(t *testing.T) {
+	testCases := []struct {
+		numerator   int64
+		denominator int64
+		want        int64
+	}{
+		{numerator: 5, denominator: 2, want: 2},
+		{numerator: 7, denominator: 2, want: 4},
+		{numerator: 11, denominator: 4, want: 3},
+		{numerator: 9, denominator: 4, want: 2},
+		{numerator: -5, denominator: 2, want: -2},
+		{numerator: -7, denominator: 2, want: -4},
+		{numerator: 6, denominator: 3, want: 2},
+	}
+
+	for _, tc := range testCases {
+		got := RoundHalfEven(big.NewInt(tc.numerator), big.NewInt(tc.denominator))
+		require.Equal(t, tc.want, got, "%d/%d", tc.numerator, tc.denominator)
+	}
+}
+
+func TestDailyInterest(t *testing.T) {
+	// 100万 * 3.65% / 365 = 100
+	require.Equal(t, int64(100), DailyInterest(1_000_000, 365))
+	// 1000 * 2.5% / 365 = 0.068...
+	require.Equal(t, int64(0), DailyInterest(1000, 250))
+	require.Equal(t, int64(0), DailyInterest(1_000_000, 0))
+	// 730000 * 2.5% / 365 = 50，恰好整除
+	require.Equal(t, int64(50), DailyInterest(730_000, 250))
+	// 恰好是0.5和1.5时取偶数
+	require.Equal(t, int64(0), DailyInterest(7300, 250))
+	require.Equal(t, int64(2), DailyInterest(21900, 250))
+}
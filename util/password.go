@@ -1,8 +1,6 @@
 package util
 
 import (
-	"log"
-
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -13,10 +11,5 @@ func HashedPassword(password string) (string, error) {
 
 func CheckPassword(password string, hashedPassword string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	if err != nil {
-		log.Println("Password is incorrect")
-		return false
-	} else {
-		return true
-	}
+	return err == nil
 }
@@ -0,0 +1,50 @@
+package worker
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"time"
+)
+
+// BalanceSnapshotJob 每天UTC零点后为所有账户生成前一天的日终余额快照
+type BalanceSnapshotJob struct {
+	store db.Store
+	now   func() time.Time
+}
+
+func NewBalanceSnapshotJob(store db.Store) *BalanceSnapshotJob {
+	return &BalanceSnapshotJob{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Run 启动时先补一次前一天的快照，之后每天零点执行，直到ctx被取消
+func (job *BalanceSnapshotJob) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		yesterday := db.SnapshotDate(job.now()).AddDate(0, 0, -1)
+		_, err := job.Snapshot(ctx, yesterday)
+		if err != nil {
+			logging.FromContext(ctx).Error("cannot create balance snapshots", "error", err)
+		}
+
+		now := job.now()
+		timer := time.NewTimer(db.SnapshotCutoff(now).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Snapshot 生成指定日期的日终快照，返回新增的快照数量；已存在的快照不会被覆盖
+func (job *BalanceSnapshotJob) Snapshot(ctx context.Context, day time.Time) (int64, error) {
+	day = db.SnapshotDate(day)
+	return job.store.CreateDailyBalanceSnapshots(ctx, db.CreateDailyBalanceSnapshotsParams{
+		SnapshotDate: day,
+		Cutoff:       db.SnapshotCutoff(day),
+	})
+}
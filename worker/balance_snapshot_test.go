@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceSnapshotJobSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	day := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateDailyBalanceSnapshots(gomock.Any(), gomock.Eq(db.CreateDailyBalanceSnapshotsParams{
+			SnapshotDate: day,
+			Cutoff:       time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		})).
+		Times(1).
+		Return(int64(3), nil)
+
+	job := NewBalanceSnapshotJob(store)
+
+	// 传入当天任意时刻都应该归到同一个快照日
+	n, err := job.Snapshot(context.Background(), day.Add(15*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), n)
+}
+
+func TestBalanceSnapshotJobRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateDailyBalanceSnapshots(gomock.Any(), gomock.Eq(db.CreateDailyBalanceSnapshotsParams{
+			SnapshotDate: time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC),
+			Cutoff:       time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		})).
+		Times(1).
+		DoAndReturn(func(context.Context, db.CreateDailyBalanceSnapshotsParams) (int64, error) {
+			cancel()
+			return 1, nil
+		})
+
+	job := NewBalanceSnapshotJob(store)
+	job.now = func() time.Time { return now }
+	job.Run(ctx)
+}
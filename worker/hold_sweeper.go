@@ -0,0 +1,52 @@
+package worker
+
+import (
+	"context"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"time"
+)
+
+const defaultHoldSweepInterval = time.Minute
+
+// HoldSweeper 定期把已过期但仍处于active状态的预授权标记为expired，释放被冻结的资金
+type HoldSweeper struct {
+	store    db.Store
+	interval time.Duration
+}
+
+func NewHoldSweeper(store db.Store, interval time.Duration) *HoldSweeper {
+	if interval <= 0 {
+		interval = defaultHoldSweepInterval
+	}
+
+	return &HoldSweeper{
+		store:    store,
+		interval: interval,
+	}
+}
+
+// Run 阻塞运行，直到ctx被取消
+func (sweeper *HoldSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(sweeper.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			holds, err := sweeper.Sweep(ctx)
+			if err != nil {
+				logging.FromContext(ctx).Error("cannot release expired holds", "error", err)
+			} else if len(holds) > 0 {
+				logging.FromContext(ctx).Info("expired holds released", "holds", len(holds))
+			}
+		}
+	}
+}
+
+// Sweep 执行一次清理，返回被释放的预授权
+func (sweeper *HoldSweeper) Sweep(ctx context.Context) ([]db.Hold, error) {
+	return sweeper.store.ReleaseExpiredHolds(ctx)
+}
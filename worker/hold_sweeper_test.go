@@ -0,0 +1,51 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHoldSweeperSweep(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	expired := []db.Hold{
+		{ID: 1, AccountID: 1, ToAccountID: 2, Amount: 10, Status: db.HoldStatusExpired},
+		{ID: 2, AccountID: 3, ToAccountID: 2, Amount: 20, Status: db.HoldStatusExpired},
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ReleaseExpiredHolds(gomock.Any()).
+		Times(1).
+		Return(expired, nil)
+
+	sweeper := NewHoldSweeper(store, time.Minute)
+	holds, err := sweeper.Sweep(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, expired, holds)
+}
+
+func TestHoldSweeperSweepError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ReleaseExpiredHolds(gomock.Any()).
+		Times(1).
+		Return(nil, sql.ErrConnDone)
+
+	sweeper := NewHoldSweeper(store, 0)
+	require.Equal(t, defaultHoldSweepInterval, sweeper.interval)
+
+	_, err := sweeper.Sweep(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
@@ -0,0 +1,163 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	db "simplebank/db/sqlc"
+	"simplebank/logging"
+	"simplebank/util"
+	"time"
+)
+
+const interestBatchSize = 500
+
+// InterestJob 每天UTC零点后为前一天计息，每月最后一天计息后把应计利息结转到账户余额
+type InterestJob struct {
+	store db.Store
+	// 各账户类型的年利率，单位为基点
+	rates map[string]int64
+	now   func() time.Time
+}
+
+func NewInterestJob(store db.Store, rates map[string]int64) *InterestJob {
+	return &InterestJob{
+		store: store,
+		rates: rates,
+		now:   time.Now,
+	}
+}
+
+// Run 启动时先为前一天计息，之后每天零点执行，直到ctx被取消
+func (job *InterestJob) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		yesterday := db.SnapshotDate(job.now()).AddDate(0, 0, -1)
+		accrued, err := job.Accrue(ctx, yesterday)
+		if err != nil {
+			logging.FromContext(ctx).Error("cannot accrue interest", "day", yesterday.Format(time.DateOnly), "error", err)
+		} else {
+			logging.FromContext(ctx).Info("interest accrued", "day", yesterday.Format(time.DateOnly), "accounts", accrued)
+		}
+
+		// 前一天是月末，结息
+		if err == nil && yesterday.AddDate(0, 0, 1).Day() == 1 {
+			capitalized, err := job.Capitalize(ctx)
+			if err != nil {
+				logging.FromContext(ctx).Error("cannot capitalize interest", "error", err)
+			} else {
+				logging.FromContext(ctx).Info("interest capitalized", "accounts", capitalized)
+			}
+		}
+
+		now := job.now()
+		timer := time.NewTimer(db.SnapshotCutoff(now).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// Accrue 按账户在day日终的余额计算当天利息，累加到应计利息，返回计息的账户数；
+// 每个账户每天只计一次，重复执行是安全的。单个账户失败不影响其他账户，所有失败合并后返回
+func (job *InterestJob) Accrue(ctx context.Context, day time.Time) (int64, error) {
+	// 利息按余额计算后写入主库，不能读可能落后的从库
+	ctx = db.WithPrimary(ctx)
+	day = db.SnapshotDate(day)
+
+	var accrued int64
+	var errs []error
+	for _, accountType := range []string{util.Checking, util.Savings} {
+		rate := job.rates[accountType]
+		if rate <= 0 {
+			continue
+		}
+
+		var afterID int64
+		for {
+			accounts, err := job.store.ListAccountsForAccrual(ctx, db.ListAccountsForAccrualParams{
+				Type:      accountType,
+				Day:       day,
+				AfterID:   afterID,
+				BatchSize: interestBatchSize,
+			})
+			if err != nil {
+				return accrued, errors.Join(append(errs, err)...)
+			}
+
+			for _, account := range accounts {
+				n, err := job.accrueAccount(ctx, account.ID, day, rate)
+				if err != nil {
+					logging.FromContext(ctx).Error("cannot accrue interest", "account_id", account.ID, "error", err)
+					errs = append(errs, fmt.Errorf("account %d: %w", account.ID, err))
+					continue
+				}
+				accrued += n
+			}
+
+			if len(accounts) < interestBatchSize {
+				break
+			}
+			afterID = accounts[len(accounts)-1].ID
+		}
+	}
+
+	return accrued, errors.Join(errs...)
+}
+
+func (job *InterestJob) accrueAccount(ctx context.Context, accountID int64, day time.Time, rate int64) (int64, error) {
+	balance, err := job.store.GetBalanceAt(ctx, accountID, db.SnapshotCutoff(day))
+	if err != nil {
+		return 0, err
+	}
+
+	// 余额为负时不计息，但仍记录计息日期，避免重复处理
+	var amount int64
+	if balance > 0 {
+		amount = util.DailyInterest(balance, rate)
+	}
+
+	return job.store.AccrueInterest(ctx, db.AccrueInterestParams{
+		ID:     accountID,
+		Amount: amount,
+		Day:    day,
+	})
+}
+
+// Capitalize 为所有有应计利息的账户结息，返回结息的账户数；
+// 单个账户失败不影响其他账户，所有失败合并后返回
+func (job *InterestJob) Capitalize(ctx context.Context) (int64, error) {
+	var capitalized int64
+	var afterID int64
+	var errs []error
+	for {
+		accounts, err := job.store.ListAccountsWithAccruedInterest(ctx, db.ListAccountsWithAccruedInterestParams{
+			AfterID:   afterID,
+			BatchSize: interestBatchSize,
+		})
+		if err != nil {
+			return capitalized, errors.Join(append(errs, err)...)
+		}
+
+		for _, account := range accounts {
+			_, err = job.store.CapitalizeInterestTx(ctx, account.ID)
+			switch {
+			case err == nil:
+				capitalized++
+			case errors.Is(err, db.ErrNoAccruedInterest), errors.Is(err, db.ErrSettlementAccount):
+				// 列出后已被结息，或是银行内部账户，跳过
+				logging.FromContext(ctx).Warn("skip interest capitalization", "account_id", account.ID, "error", err)
+			default:
+				logging.FromContext(ctx).Error("cannot capitalize interest", "account_id", account.ID, "error", err)
+				errs = append(errs, fmt.Errorf("account %d: %w", account.ID, err))
+			}
+		}
+
+		if len(accounts) < interestBatchSize {
+			return capitalized, errors.Join(errs...)
+		}
+		afterID = accounts[len(accounts)-1].ID
+	}
+}
@@ -0,0 +1,164 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	mockdb "simplebank/db/mock"
+	db "simplebank/db/sqlc"
+	"simplebank/util"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInterestJobAccrue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	day := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	cutoff := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+	accounts := []db.Account{
+		{ID: 1, Type: util.Savings},
+		{ID: 2, Type: util.Savings},
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	// 活期利率为0，不查询活期账户
+	store.EXPECT().
+		ListAccountsForAccrual(gomock.Any(), gomock.Eq(db.ListAccountsForAccrualParams{Type: util.Checking, Day: day, AfterID: 0, BatchSize: interestBatchSize})).
+		Times(0)
+	store.EXPECT().
+		ListAccountsForAccrual(gomock.Any(), gomock.Eq(db.ListAccountsForAccrualParams{Type: util.Savings, Day: day, AfterID: 0, BatchSize: interestBatchSize})).
+		Times(1).
+		Return(accounts, nil)
+	// 计息读主库的余额
+	store.EXPECT().GetBalanceAt(mockdb.PrimaryContext(), gomock.Eq(int64(1)), gomock.Eq(cutoff)).Times(1).Return(int64(21900), nil)
+	store.EXPECT().GetBalanceAt(mockdb.PrimaryContext(), gomock.Eq(int64(2)), gomock.Eq(cutoff)).Times(1).Return(int64(-500), nil)
+	// 21900 * 2.5% / 365 = 1.5，银行家舍入为2
+	store.EXPECT().
+		AccrueInterest(gomock.Any(), gomock.Eq(db.AccrueInterestParams{ID: 1, Amount: 2, Day: day})).
+		Times(1).
+		Return(int64(1), nil)
+	// 负余额不计息
+	store.EXPECT().
+		AccrueInterest(gomock.Any(), gomock.Eq(db.AccrueInterestParams{ID: 2, Amount: 0, Day: day})).
+		Times(1).
+		Return(int64(1), nil)
+
+	job := NewInterestJob(store, map[string]int64{util.Checking: 0, util.Savings: 250})
+
+	n, err := job.Accrue(context.Background(), day.Add(10*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), n)
+}
+
+func TestInterestJobRunCapitalizesAtMonthEnd(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountsForAccrual(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Account{}, nil)
+	store.EXPECT().
+		ListAccountsWithAccruedInterest(gomock.Any(), gomock.Eq(db.ListAccountsWithAccruedInterestParams{AfterID: 0, BatchSize: interestBatchSize})).
+		Times(1).
+		Return([]db.Account{{ID: 3, AccruedInterest: 40}}, nil)
+	store.EXPECT().
+		CapitalizeInterestTx(gomock.Any(), gomock.Eq(int64(3))).
+		Times(1).
+		DoAndReturn(func(context.Context, int64) (db.TransferTxResult, error) {
+			cancel()
+			return db.TransferTxResult{}, nil
+		})
+
+	job := NewInterestJob(store, map[string]int64{util.Savings: 250})
+	job.now = func() time.Time { return now }
+	job.Run(ctx)
+}
+
+func TestInterestJobRunSkipsCapitalizationMidMonth(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2026, 4, 15, 8, 0, 0, 0, time.UTC)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountsForAccrual(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(context.Context, db.ListAccountsForAccrualParams) ([]db.Account, error) {
+			cancel()
+			return []db.Account{}, nil
+		})
+	store.EXPECT().ListAccountsWithAccruedInterest(gomock.Any(), gomock.Any()).Times(0)
+
+	job := NewInterestJob(store, map[string]int64{util.Savings: 250})
+	job.now = func() time.Time { return now }
+	job.Run(ctx)
+}
+
+func TestInterestJobCapitalizeContinuesOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountsWithAccruedInterest(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]db.Account{{ID: 1}, {ID: 2}, {ID: 3}, {ID: 4}}, nil)
+	store.EXPECT().CapitalizeInterestTx(gomock.Any(), gomock.Eq(int64(1))).Times(1).Return(db.TransferTxResult{}, db.ErrNoAccruedInterest)
+	store.EXPECT().CapitalizeInterestTx(gomock.Any(), gomock.Eq(int64(2))).Times(1).Return(db.TransferTxResult{}, sql.ErrConnDone)
+	store.EXPECT().CapitalizeInterestTx(gomock.Any(), gomock.Eq(int64(3))).Times(1).Return(db.TransferTxResult{}, db.ErrSettlementAccount)
+	store.EXPECT().CapitalizeInterestTx(gomock.Any(), gomock.Eq(int64(4))).Times(1).Return(db.TransferTxResult{}, nil)
+
+	job := NewInterestJob(store, map[string]int64{util.Savings: 250})
+
+	n, err := job.Capitalize(context.Background())
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.NotErrorIs(t, err, db.ErrNoAccruedInterest)
+	require.Equal(t, int64(1), n)
+}
+
+func TestInterestJobAccrueContinuesOnError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	day := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	accounts := []db.Account{
+		{ID: 1, Type: util.Savings},
+		{ID: 2, Type: util.Savings},
+		{ID: 3, Type: util.Savings},
+	}
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountsForAccrual(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(accounts, nil)
+	store.EXPECT().GetBalanceAt(gomock.Any(), gomock.Eq(int64(1)), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone)
+	store.EXPECT().GetBalanceAt(gomock.Any(), gomock.Eq(int64(2)), gomock.Any()).Times(1).Return(int64(21900), nil)
+	store.EXPECT().GetBalanceAt(gomock.Any(), gomock.Eq(int64(3)), gomock.Any()).Times(1).Return(int64(21900), nil)
+	store.EXPECT().
+		AccrueInterest(gomock.Any(), gomock.Eq(db.AccrueInterestParams{ID: 2, Amount: 2, Day: day})).
+		Times(1).
+		Return(int64(0), sql.ErrTxDone)
+	store.EXPECT().
+		AccrueInterest(gomock.Any(), gomock.Eq(db.AccrueInterestParams{ID: 3, Amount: 2, Day: day})).
+		Times(1).
+		Return(int64(1), nil)
+
+	job := NewInterestJob(store, map[string]int64{util.Savings: 250})
+
+	n, err := job.Accrue(context.Background(), day)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+	require.ErrorIs(t, err, sql.ErrTxDone)
+	require.Equal(t, int64(1), n)
+}